@@ -0,0 +1,195 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// NewStorage creates a storage for Azure Blob containers
+func NewStorage() *Storage {
+	return &Storage{}
+}
+
+// Storage is the storage for Azure Blob containers, the bucket of a request
+// is the container name
+type Storage struct {
+}
+
+// blockSize is the size of the blocks of the stream uploads. With at most
+// 50000 blocks, it allows objects up to 400GiB
+const blockSize = 8 * 1024 * 1024
+
+// getContainer returns the container of the request bucket. The account and
+// its key are read from AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY and
+// AZURE_STORAGE_ENDPOINT overrides the blob service URL, e.g. to use Azurite
+func getContainer(request *openapi.BackupRequest) (*azblob.ContainerURL, error) {
+	for _, v := range request.Envs {
+		os.Setenv(v.Name, v.Value)
+	}
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+	credential, err := azblob.NewSharedKeyCredential(account, os.Getenv("AZURE_STORAGE_KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("azblob.NewSharedKeyCredential: %v", err)
+	}
+	endpoint := os.Getenv("AZURE_STORAGE_ENDPOINT")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+	u, err := url.Parse(fmt.Sprintf("%s/%s", strings.TrimSuffix(endpoint, "/"), request.Bucket))
+	if err != nil {
+		return nil, err
+	}
+	container := azblob.NewContainerURL(*u, azblob.NewPipeline(credential, azblob.PipelineOptions{}))
+	return &container, nil
+}
+
+func blobName(request *openapi.BackupRequest) string {
+	return strings.TrimPrefix(request.Location, "/")
+}
+
+// Push pushes a file to an Azure Blob container
+func (s *Storage) Push(request *openapi.BackupRequest, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		log.Printf("Error push/opening %s to %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		return fmt.Errorf("os.Open: %v", err)
+	}
+	defer f.Close()
+	if err := s.PushStream(request, f); err != nil {
+		return err
+	}
+	log.Printf("Pushing %s to %s:%s, succeeded", filename, request.Bucket, request.Location)
+	return nil
+}
+
+// PushStream uploads a stream to a block blob. The stream is sent by blocks
+// so that it is never buffered entirely
+func (s *Storage) PushStream(request *openapi.BackupRequest, r io.Reader) error {
+	container, err := getContainer(request)
+	if err != nil {
+		log.Printf("Error push/azure to %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	headers := azblob.BlobHTTPHeaders{
+		ContentType:        compress.ContentType(request.Compression),
+		ContentDisposition: "attachment",
+	}
+	var metadata azblob.Metadata
+	if request.Encryption != nil {
+		headers.ContentType = "application/octet-stream"
+		// metadata names are C# identifiers, the key ID is stored without
+		// dashes
+		metadata = azblob.Metadata{strings.ReplaceAll(encrypt.MetadataKeyID, "-", "_"): request.Encryption.KeyId}
+	}
+	_, err = azblob.UploadStreamToBlockBlob(
+		context.Background(),
+		r,
+		container.NewBlockBlobURL(blobName(request)),
+		azblob.UploadStreamToBlockBlobOptions{
+			BufferSize:      blockSize,
+			MaxBuffers:      2,
+			BlobHTTPHeaders: headers,
+			Metadata:        metadata,
+		},
+	)
+	if err != nil {
+		log.Printf("Error push/upload to %s:%s, error: %v", request.Bucket, request.Location, err)
+	}
+	return err
+}
+
+// Pull pulls a blob to a file
+func (s *Storage) Pull(request *openapi.BackupRequest, filename string) error {
+	fo, err := os.Create(filename)
+	if err != nil {
+		log.Printf("Error pull/create %s from %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		return err
+	}
+	defer fo.Close()
+	if err := s.PullStream(request, fo); err != nil {
+		return err
+	}
+	return fo.Close()
+}
+
+// PullStream copies a blob to a stream, the download is resumed when the
+// connection breaks
+func (s *Storage) PullStream(request *openapi.BackupRequest, w io.Writer) error {
+	container, err := getContainer(request)
+	if err != nil {
+		log.Printf("Error pull/azure from %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	ctx := context.Background()
+	response, err := container.NewBlobURL(blobName(request)).Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
+	if err != nil {
+		log.Printf("Error pull/download from %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	body := response.Body(azblob.RetryReaderOptions{MaxRetryRequests: 5})
+	defer body.Close()
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Error pull/copy from %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	return nil
+}
+
+// Delete deletes a blob and its snapshots
+func (s *Storage) Delete(request *openapi.BackupRequest) error {
+	container, err := getContainer(request)
+	if err != nil {
+		log.Printf("Error delete/azure for %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+	_, err = container.NewBlobURL(blobName(request)).Delete(ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
+	if err != nil {
+		log.Printf("Error delete/Delete for %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	return nil
+}
+
+// List returns the locations of the blobs that start with the prefix. Like
+// for the other requests, a leading slash is not part of the blob names
+func (s *Storage) List(request *openapi.BackupRequest, prefix string) ([]string, error) {
+	container, err := getContainer(request)
+	if err != nil {
+		log.Printf("Error list/azure for %s:%s, error: %v", request.Bucket, prefix, err)
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+
+	slash := strings.HasPrefix(prefix, "/")
+	locations := []string{}
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		page, err := container.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{Prefix: strings.TrimPrefix(prefix, "/")})
+		if err != nil {
+			log.Printf("Error list/ListBlobs for %s:%s, error: %v", request.Bucket, prefix, err)
+			return nil, err
+		}
+		for _, blob := range page.Segment.BlobItems {
+			if slash {
+				locations = append(locations, "/"+blob.Name)
+				continue
+			}
+			locations = append(locations, blob.Name)
+		}
+		marker = page.NextMarker
+	}
+	return locations, nil
+}
@@ -0,0 +1,175 @@
+// +build integration
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// The default values are the well-known development account of Azurite
+const (
+	azuriteAccount  = "devstoreaccount1"
+	azuriteKey      = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	azuriteEndpoint = "http://127.0.0.1:10000/devstoreaccount1"
+)
+
+type StorageSuite struct {
+	suite.Suite
+	Storage *Storage
+}
+
+func getenv(key, value string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return value
+}
+
+func request(bucket string) openapi.BackupRequest {
+	return openapi.BackupRequest{
+		Bucket:   bucket,
+		Location: getenv("BACKUP_LOCATION", "/backups/demo.txt"),
+		Envs: []openapi.EnvVar{
+			{
+				Name:  "AZURE_STORAGE_ACCOUNT",
+				Value: getenv("BACKUP_AZURE_STORAGE_ACCOUNT", azuriteAccount),
+			},
+			{
+				Name:  "AZURE_STORAGE_KEY",
+				Value: getenv("BACKUP_AZURE_STORAGE_KEY", azuriteKey),
+			},
+			{
+				Name:  "AZURE_STORAGE_ENDPOINT",
+				Value: getenv("BACKUP_AZURE_STORAGE_ENDPOINT", azuriteEndpoint),
+			},
+		},
+	}
+}
+
+func (s *StorageSuite) SetupTest() {
+	_ = godotenv.Load()
+	s.Storage = NewStorage()
+
+	b := request(getenv("BACKUP_BUCKET", "backups"))
+	container, err := getContainer(&b)
+	assert.NoError(s.T(), err, "No Error")
+	_, err = container.Create(context.Background(), azblob.Metadata{}, azblob.PublicAccessNone)
+	if serr, ok := err.(azblob.StorageError); ok && serr.ServiceCode() == azblob.ServiceCodeContainerAlreadyExists {
+		err = nil
+	}
+	assert.NoError(s.T(), err, "No Error")
+}
+
+func initFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "[%s]", filename)
+	return nil
+}
+
+func validFile(filename, content string) error {
+	dat, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	c1 := fmt.Sprintf("[%s]", content)
+	c2 := string(dat)
+	if c2 != c1 {
+		return errors.New("wrong file")
+	}
+	return nil
+}
+
+func deleteFile(filename string) error {
+	return os.Remove(filename)
+}
+
+func (s *StorageSuite) TestAzureSuccess() {
+	b := request(getenv("BACKUP_BUCKET", "backups"))
+
+	err := initFile("test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = s.Storage.Push(&b, "test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = deleteFile("test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = s.Storage.Pull(&b, "test2.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	locations, err := s.Storage.List(&b, b.Location)
+	assert.NoError(s.T(), err, "No Error")
+	assert.Contains(s.T(), locations, b.Location)
+
+	err = s.Storage.Delete(&b)
+	assert.NoError(s.T(), err, "No Error")
+
+	err = validFile("test2.txt", "test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = deleteFile("test2.txt")
+	assert.NoError(s.T(), err, "No Error")
+}
+
+func (s *StorageSuite) TestAzureStream() {
+	b := request(getenv("BACKUP_BUCKET", "backups"))
+
+	// the object is larger than a block to be uploaded in several blocks
+	content := bytes.Repeat([]byte("0123456789abcdef"), blockSize/8)
+	err := s.Storage.PushStream(&b, bytes.NewReader(content))
+	assert.NoError(s.T(), err, "No Error")
+
+	buffer := &bytes.Buffer{}
+	err = s.Storage.PullStream(&b, buffer)
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), content, buffer.Bytes())
+
+	err = s.Storage.Delete(&b)
+	assert.NoError(s.T(), err, "No Error")
+}
+
+func (s *StorageSuite) TestAzureFailed() {
+	b := request("failed")
+
+	err := initFile("test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = s.Storage.Push(&b, "test.txt")
+	assert.Error(s.T(), err, "Error")
+	assert.Regexp(s.T(), "ContainerNotFound", err.Error(), "ContainerNotFound")
+
+	err = s.Storage.Push(&b, "test2.txt")
+	assert.Error(s.T(), err, "Error")
+	assert.Regexp(s.T(), "open.*", err.Error(), "open")
+
+	err = s.Storage.Pull(&b, "test2.txt")
+	assert.Error(s.T(), err, "Error")
+	assert.Regexp(s.T(), "ContainerNotFound", err.Error(), "ContainerNotFound")
+
+	err = deleteFile("test.txt")
+	assert.NoError(s.T(), err, "No Error")
+	_ = deleteFile("test2.txt")
+}
+
+func TestStorageSuite(t *testing.T) {
+	suite.Run(t, &StorageSuite{})
+}
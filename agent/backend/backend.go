@@ -1,12 +1,57 @@
 package backend
 
 import (
+	"io"
+
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 )
 
-// Backup provides the interfaces required to start backup an instance
+const (
+	// MethodMysqldump is a logical backup of all the databases
+	MethodMysqldump = "mysqldump"
+
+	// MethodXtrabackup is a hot physical backup with Percona XtraBackup
+	MethodXtrabackup = "xtrabackup"
+
+	// MethodClone is a physical backup with the MySQL clone plugin
+	MethodClone = "clone"
+)
+
+// Coordinates are the executed GTID set and the binary log position a backup
+// is consistent with. They are empty when the server has no binary log
+type Coordinates struct {
+	GTIDExecuted   string
+	BinlogFile     string
+	BinlogPosition int64
+}
+
+// Backup provides the interfaces required to start backup an instance. The
+// dump is compressed with the codec of the request while it is written to the
+// file or to the stream. Stream returns the coordinates read from the backup
+// itself
 type Backup interface {
+	Run(filename string, request *openapi.BackupRequest) error
+	Stream(w io.Writer, request *openapi.BackupRequest) (Coordinates, error)
+}
+
+// Physical provides the interfaces required to take a physical backup and to
+// restore it in the data directory of an instance that is not started
+type Physical interface {
+	Backup
+	CopyBack(r io.Reader, datadir string) error
+}
+
+// Restore provides the interfaces required to load a dump in an instance,
+// from a file or from a stream
+type Restore interface {
 	Run(string) error
+	Stream(r io.Reader) error
+}
+
+// Replica provides the interfaces required to seed a replica from its source
+type Replica interface {
+	Dump(source *openapi.ReplicationRequest, filename string) error
+	Load(filename string) error
 }
 
 // Instance provides the interfaces required to start an instance
@@ -15,9 +60,31 @@ type Instance interface {
 	Initialize() error
 }
 
-// Storage defines an interface to externalize stores
+// BinaryLog is a binary log of the server with its size in bytes
+type BinaryLog struct {
+	Name string
+	Size int64
+}
+
+// Binlog provides the interfaces required to archive the binary logs and
+// replay them for a point-in-time recovery
+type Binlog interface {
+	List() ([]BinaryLog, error)
+	Path(name string) (string, error)
+	ServerUUID() (string, error)
+	Flush() error
+	Extract(files []string, stopDatetime, includeGTIDs, filename string) error
+}
+
+// Storage defines an interface to externalize stores. PullStream and
+// PushStream copy the object from and to a stream so that a dump does not
+// have to be written on a local volume. List returns the locations of the
+// objects of the request bucket that start with the prefix
 type Storage interface {
 	Pull(backup *openapi.BackupRequest, filename string) error
 	Push(backup *openapi.BackupRequest, filename string) error
+	PullStream(backup *openapi.BackupRequest, w io.Writer) error
+	PushStream(backup *openapi.BackupRequest, r io.Reader) error
 	Delete(backup *openapi.BackupRequest) error
+	List(backup *openapi.BackupRequest, prefix string) ([]string, error)
 }
@@ -1,6 +1,8 @@
 package blackhole
 
 import (
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
 
@@ -25,15 +27,18 @@ func (s *Storage) Push(request *openapi.BackupRequest, filename string) error {
 		return err
 	}
 	defer file.Close()
-	fileInfo, err := file.Stat()
+	return s.PushStream(request, file)
+}
+
+// PushStream reads the stream to the end and drops it
+func (s *Storage) PushStream(request *openapi.BackupRequest, r io.Reader) error {
+	size, err := io.Copy(ioutil.Discard, r)
 	if err != nil {
-		log.Printf("Could not get file %s info, error: %v", filename, err)
+		log.Printf("Could not read stream, error: %v", err)
 		return err
 	}
-	var size int64 = fileInfo.Size()
 	log.Printf(
-		"Copying file %s (size: %d) to %s:%s",
-		filename,
+		"Copying stream (size: %d) to %s:%s",
 		size,
 		request.Bucket,
 		request.Location,
@@ -49,25 +54,29 @@ func (s *Storage) Pull(request *openapi.BackupRequest, filename string) error {
 		return err
 	}
 	defer file.Close()
+	if err := s.PullStream(request, file); err != nil {
+		log.Printf("Could not write file %s, error: %v", filename, err)
+		return err
+	}
+	return file.Sync()
+}
+
+// PullStream writes the blue dump to the stream
+func (s *Storage) PullStream(request *openapi.BackupRequest, w io.Writer) error {
 	dumps := packr.New("dumps", "./dumps")
 	blue, err := dumps.FindString("blue.sql")
 	if err != nil {
 		return err
 	}
-
-	_, err = file.WriteString(blue)
-	if err != nil {
-		log.Printf("Could not write file %s, error: %v", filename, err)
+	if _, err := io.WriteString(w, blue); err != nil {
 		return err
 	}
-	err = file.Sync()
 	log.Printf(
-		"Pulling file %s from %s:%s",
-		filename,
+		"Pulling stream from %s:%s",
 		request.Bucket,
 		request.Location,
 	)
-	return err
+	return nil
 }
 
 // Delete deletes a file from the blackhole
@@ -79,3 +88,20 @@ func (s *Storage) Delete(request *openapi.BackupRequest) error {
 	)
 	return nil
 }
+
+// List returns the dumps of the blackhole as if they were stored with the
+// prefix
+func (s *Storage) List(request *openapi.BackupRequest, prefix string) ([]string, error) {
+	dumps := packr.New("dumps", "./dumps")
+	locations := []string{}
+	for _, name := range dumps.List() {
+		locations = append(locations, prefix+"/"+name)
+	}
+	log.Printf(
+		"Listing %d files from %s:%s",
+		len(locations),
+		request.Bucket,
+		prefix,
+	)
+	return locations, nil
+}
@@ -1,10 +1,12 @@
 package blackhole
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -86,6 +88,26 @@ func (s *StorageSuite) TestBlackholeSuccess() {
 
 }
 
+func (s *StorageSuite) TestBlackholeStream() {
+	b := openapi.BackupRequest{Bucket: "bucket", Location: "/blue.sql"}
+
+	err := s.Storage.PushStream(&b, strings.NewReader("SELECT 1;\n"))
+	assert.NoError(s.T(), err, "No Error")
+
+	buffer := &bytes.Buffer{}
+	err = s.Storage.PullStream(&b, buffer)
+	assert.NoError(s.T(), err, "No Error")
+	assert.Contains(s.T(), buffer.String(), "blue")
+}
+
+func (s *StorageSuite) TestBlackholeList() {
+	b := openapi.BackupRequest{Bucket: "bucket"}
+
+	locations, err := s.Storage.List(&b, "/backup")
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), []string{"/backup/blue.sql"}, locations)
+}
+
 func TestStorageSuite(t *testing.T) {
 	suite.Run(t, &StorageSuite{})
 }
@@ -0,0 +1,204 @@
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// None stores the dump as it is
+	None = "none"
+
+	// Gzip compresses the dump with gzip
+	Gzip = "gzip"
+
+	// Zstd compresses the dump with zstandard
+	Zstd = "zstd"
+)
+
+var (
+	// ErrUnsupportedCodec is returned when the compression is not none,
+	// gzip or zstd
+	ErrUnsupportedCodec = errors.New("UnsupportedCodec")
+
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Validate checks the compression is supported, an empty compression is none
+func Validate(codec string) error {
+	switch codec {
+	case "", None, Gzip, Zstd:
+		return nil
+	}
+	return ErrUnsupportedCodec
+}
+
+// Extension returns the extension of the objects compressed with the codec
+func Extension(codec string) string {
+	switch codec {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// ContentType returns the content type of the objects compressed with the
+// codec. It is empty when the dump is not compressed
+func ContentType(codec string) string {
+	switch codec {
+	case Gzip:
+		return "application/gzip"
+	case Zstd:
+		return "application/zstd"
+	}
+	return ""
+}
+
+// Location adds the extension of the codec to the location when it does not
+// end with it already
+func Location(location, codec string) string {
+	ext := Extension(codec)
+	if strings.HasSuffix(location, ext) {
+		return location
+	}
+	return location + ext
+}
+
+// Trim removes the extension of a compressed object from the location
+func Trim(location string) string {
+	for _, codec := range []string{Gzip, Zstd} {
+		location = strings.TrimSuffix(location, Extension(codec))
+	}
+	return location
+}
+
+type zstdWriter struct {
+	*zstd.Encoder
+}
+
+// Close flushes the encoder, it does not close the underlying writer
+func (z zstdWriter) Close() error {
+	return z.Encoder.Close()
+}
+
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+// Close releases the decoder
+func (z zstdReader) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+// NewWriter returns a writer that compresses to w with the codec. Close must
+// be called to flush the data, it does not close w
+func NewWriter(w io.Writer, codec string) (io.WriteCloser, error) {
+	switch codec {
+	case "", None:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		encoder, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		return zstdWriter{encoder}, nil
+	}
+	return nil, ErrUnsupportedCodec
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// NewReader detects the codec from the first bytes of r and returns a reader
+// that decompresses it with the codec
+func NewReader(r io.Reader) (io.ReadCloser, string, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, "", err
+		}
+		return reader, Gzip, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, "", err
+		}
+		return zstdReader{decoder}, Zstd, nil
+	}
+	return ioutil.NopCloser(buffered), None, nil
+}
+
+// Detect returns the codec of a file
+func Detect(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	reader, codec, err := NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	reader.Close()
+	return codec, nil
+}
+
+// Unpack decompresses src into dst and removes src. A file that is not
+// compressed is renamed. It returns the codec of src
+func Unpack(src, dst string) (string, error) {
+	codec, err := Detect(src)
+	if err != nil {
+		return "", err
+	}
+	if codec == None {
+		return codec, os.Rename(src, dst)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	reader, _, err := NewReader(in)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	return codec, os.Remove(src)
+}
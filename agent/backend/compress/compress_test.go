@@ -0,0 +1,73 @@
+package compress
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CompressSuite struct {
+	suite.Suite
+}
+
+func (s *CompressSuite) TestLocation() {
+	require.Equal(s.T(), "/red.sql.gz", Location("/red.sql", Gzip))
+	require.Equal(s.T(), "/red.sql.zst", Location("/red.sql.zst", Zstd))
+	require.Equal(s.T(), "/red.sql", Location("/red.sql", None))
+	require.Equal(s.T(), "/red.sql", Trim("/red.sql.zst"))
+	require.Equal(s.T(), "/red.sql", Trim("/red.sql"))
+	require.Equal(s.T(), ErrUnsupportedCodec, Validate("xz"))
+}
+
+func (s *CompressSuite) TestRoundTrip() {
+	dump := []byte("CREATE DATABASE blue;\n")
+	for _, codec := range []string{None, Gzip, Zstd} {
+		buffer := &bytes.Buffer{}
+		w, err := NewWriter(buffer, codec)
+		require.NoError(s.T(), err)
+		_, err = w.Write(dump)
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), w.Close())
+
+		r, detected, err := NewReader(buffer)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), codec, detected)
+		content, err := ioutil.ReadAll(r)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), dump, content)
+		require.NoError(s.T(), r.Close())
+	}
+}
+
+func (s *CompressSuite) TestUnpack() {
+	dir, err := ioutil.TempDir("", "compress")
+	require.NoError(s.T(), err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "red.sql.zst")
+	dst := filepath.Join(dir, "red.sql")
+	f, err := os.Create(src)
+	require.NoError(s.T(), err)
+	w, err := NewWriter(f, Zstd)
+	require.NoError(s.T(), err)
+	w.Write([]byte("SELECT 1;\n"))
+	require.NoError(s.T(), w.Close())
+	require.NoError(s.T(), f.Close())
+
+	codec, err := Unpack(src, dst)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), Zstd, codec)
+	content, err := ioutil.ReadFile(dst)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "SELECT 1;\n", string(content))
+	_, err = os.Stat(src)
+	require.True(s.T(), os.IsNotExist(err))
+}
+
+func TestCompressSuite(t *testing.T) {
+	suite.Run(t, &CompressSuite{})
+}
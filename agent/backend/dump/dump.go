@@ -0,0 +1,165 @@
+package dump
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+const (
+	// SingleTransaction dumps the InnoDB tables in a transaction, without
+	// locking them
+	SingleTransaction = "single-transaction"
+
+	// LockAll locks all the tables for the duration of the dump
+	LockAll = "lock-all"
+)
+
+var (
+	// ErrInvalidOptions is returned when the options of a dump are not
+	// consistent
+	ErrInvalidOptions = errors.New("InvalidOptions")
+
+	// systemDatabases are not dumped with --all-databases
+	systemDatabases = map[string]bool{
+		"information_schema": true,
+		"performance_schema": true,
+		"sys":                true,
+	}
+)
+
+// splitTable returns the database and the name of a database.table
+func splitTable(table string) (string, string, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%w: table %q is not database.table", ErrInvalidOptions, table)
+	}
+	if strings.HasPrefix(parts[0], "-") || strings.HasPrefix(parts[1], "-") {
+		return "", "", fmt.Errorf("%w: table %q cannot start with -", ErrInvalidOptions, table)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Validate checks the options of a dump, nil options dump all the databases
+func Validate(options *openapi.DumpOptions) error {
+	if options == nil {
+		return nil
+	}
+	switch options.Consistency {
+	case "", SingleTransaction, LockAll:
+	default:
+		return fmt.Errorf("%w: consistency %q is not %s or %s", ErrInvalidOptions, options.Consistency, SingleTransaction, LockAll)
+	}
+	if len(options.Databases) > 0 && len(options.ExcludeDatabases) > 0 {
+		return fmt.Errorf("%w: databases and exclude_databases cannot be used together", ErrInvalidOptions)
+	}
+	for _, database := range append(append([]string{}, options.Databases...), options.ExcludeDatabases...) {
+		if database == "" {
+			return fmt.Errorf("%w: a database is empty", ErrInvalidOptions)
+		}
+		// mysqldump would read the name as an option
+		if strings.HasPrefix(database, "-") {
+			return fmt.Errorf("%w: database %q cannot start with -", ErrInvalidOptions, database)
+		}
+	}
+	if len(options.Tables) > 0 && (len(options.Databases) > 0 || len(options.ExcludeDatabases) > 0) {
+		return fmt.Errorf("%w: tables cannot be used with databases or exclude_databases", ErrInvalidOptions)
+	}
+	database := ""
+	for _, table := range options.Tables {
+		d, _, err := splitTable(table)
+		if err != nil {
+			return err
+		}
+		if database != "" && d != database {
+			return fmt.Errorf("%w: tables must belong to a single database", ErrInvalidOptions)
+		}
+		database = d
+	}
+	for _, table := range options.ExcludeTables {
+		if _, _, err := splitTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Excludes returns true when the databases of the server are required to
+// build the arguments
+func Excludes(options *openapi.DumpOptions) bool {
+	return options != nil && len(options.ExcludeDatabases) > 0
+}
+
+// Databases returns the databases of the server that a dump includes, the
+// system databases are not part of a dump
+func Databases(options *openapi.DumpOptions, databases []string) []string {
+	if options == nil {
+		options = &openapi.DumpOptions{}
+	}
+	if len(options.Tables) > 0 {
+		database, _, _ := splitTable(options.Tables[0])
+		return []string{database}
+	}
+	if len(options.Databases) > 0 {
+		return options.Databases
+	}
+	excluded := map[string]bool{}
+	for _, database := range options.ExcludeDatabases {
+		excluded[database] = true
+	}
+	included := []string{}
+	for _, database := range databases {
+		if !excluded[database] && !systemDatabases[database] {
+			included = append(included, database)
+		}
+	}
+	return included
+}
+
+// Args returns the flags of mysqldump and the databases or tables that are
+// dumped. The databases of the server are only used to exclude some of them
+func Args(options *openapi.DumpOptions, databases []string) ([]string, []string) {
+	if options == nil {
+		options = &openapi.DumpOptions{}
+	}
+	flags := []string{}
+	names := []string{}
+	switch {
+	case len(options.Tables) > 0:
+		for _, table := range options.Tables {
+			database, name, _ := splitTable(table)
+			if len(names) == 0 {
+				names = append(names, database)
+			}
+			names = append(names, name)
+		}
+	case len(options.Databases) > 0:
+		flags = append(flags, "--databases")
+		names = append(names, options.Databases...)
+	case len(options.ExcludeDatabases) > 0:
+		flags = append(flags, "--databases")
+		names = append(names, Databases(options, databases)...)
+	default:
+		flags = append(flags, "--all-databases")
+	}
+	if options.Consistency == SingleTransaction {
+		flags = append(flags, "--single-transaction")
+	} else {
+		flags = append(flags, "--lock-all-tables")
+	}
+	if options.Routines {
+		flags = append(flags, "--routines")
+	}
+	if options.Triggers != nil && !*options.Triggers {
+		flags = append(flags, "--skip-triggers")
+	}
+	if options.Events {
+		flags = append(flags, "--events")
+	}
+	for _, table := range options.ExcludeTables {
+		flags = append(flags, "--ignore-table="+table)
+	}
+	return flags, names
+}
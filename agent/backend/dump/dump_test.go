@@ -0,0 +1,80 @@
+package dump
+
+import (
+	"errors"
+	"testing"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type DumpSuite struct {
+	suite.Suite
+}
+
+func (s *DumpSuite) TestValidate() {
+	require.NoError(s.T(), Validate(nil))
+	require.NoError(s.T(), Validate(&openapi.DumpOptions{
+		Consistency:   SingleTransaction,
+		Tables:        []string{"blue.users", "blue.orders"},
+		ExcludeTables: []string{"red.logs"},
+	}))
+	invalid := []*openapi.DumpOptions{
+		{Consistency: "snapshot"},
+		{Databases: []string{"blue"}, ExcludeDatabases: []string{"red"}},
+		{Databases: []string{""}},
+		{Databases: []string{"blue"}, Tables: []string{"blue.users"}},
+		{Tables: []string{"users"}},
+		{Tables: []string{"blue.users", "red.users"}},
+		{ExcludeTables: []string{"blue."}},
+		{Databases: []string{"--defaults-file=/tmp/my.cnf"}},
+		{ExcludeDatabases: []string{"-e"}},
+		{Tables: []string{"blue.--help"}},
+		{Tables: []string{"-blue.users"}},
+	}
+	for _, options := range invalid {
+		err := Validate(options)
+		require.True(s.T(), errors.Is(err, ErrInvalidOptions), "%v", options)
+	}
+}
+
+func (s *DumpSuite) TestArgs() {
+	flags, names := Args(nil, nil)
+	require.Equal(s.T(), []string{"--all-databases", "--lock-all-tables"}, flags)
+	require.Empty(s.T(), names)
+
+	triggers := false
+	flags, names = Args(&openapi.DumpOptions{
+		Consistency:   SingleTransaction,
+		Databases:     []string{"blue", "red"},
+		ExcludeTables: []string{"blue.logs"},
+		Routines:      true,
+		Triggers:      &triggers,
+		Events:        true,
+	}, nil)
+	require.Equal(s.T(), []string{"--databases", "--single-transaction", "--routines", "--skip-triggers", "--events", "--ignore-table=blue.logs"}, flags)
+	require.Equal(s.T(), []string{"blue", "red"}, names)
+
+	flags, names = Args(&openapi.DumpOptions{Tables: []string{"blue.users", "blue.orders"}}, nil)
+	require.Equal(s.T(), []string{"--lock-all-tables"}, flags)
+	require.Equal(s.T(), []string{"blue", "users", "orders"}, names)
+
+	options := &openapi.DumpOptions{ExcludeDatabases: []string{"red"}}
+	require.True(s.T(), Excludes(options))
+	flags, names = Args(options, []string{"blue", "information_schema", "mysql", "performance_schema", "red", "sys"})
+	require.Equal(s.T(), []string{"--databases", "--lock-all-tables"}, flags)
+	require.Equal(s.T(), []string{"blue", "mysql"}, names)
+}
+
+func (s *DumpSuite) TestDatabases() {
+	databases := []string{"blue", "information_schema", "mysql", "red", "sys"}
+	require.Equal(s.T(), []string{"blue", "mysql", "red"}, Databases(nil, databases))
+	require.Equal(s.T(), []string{"blue"}, Databases(&openapi.DumpOptions{Tables: []string{"blue.users"}}, databases))
+	require.Equal(s.T(), []string{"red"}, Databases(&openapi.DumpOptions{Databases: []string{"red"}}, databases))
+	require.Equal(s.T(), []string{"blue", "red"}, Databases(&openapi.DumpOptions{ExcludeDatabases: []string{"mysql"}}, databases))
+}
+
+func TestDumpSuite(t *testing.T) {
+	suite.Run(t, &DumpSuite{})
+}
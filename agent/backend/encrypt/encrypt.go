@@ -0,0 +1,371 @@
+package encrypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Backups are encrypted with a random data key that is wrapped with the
+// store key. The header is followed by chunks sealed with AES-256-GCM, the
+// nonce of a chunk is its counter and a flag set on the last chunk so that
+// chunks cannot be reordered, removed or truncated:
+//
+//   magic | key ID length | key ID | wrap nonce | wrapped data key | chunks...
+const (
+	// KeySize is the size of the keys, in bytes
+	KeySize = 32
+
+	// MetadataKeyID is the object metadata that records the key ID
+	MetadataKeyID = "blaqkube-key-id"
+
+	chunkSize = 64 * 1024
+)
+
+var (
+	// ErrInvalidKey is returned when a key is not 32 bytes, raw or base64
+	// encoded, or when its ID is empty or too long
+	ErrInvalidKey = errors.New("InvalidKey")
+
+	// ErrKeyNotFound is returned when the key used to encrypt a backup is
+	// not part of the keys
+	ErrKeyNotFound = errors.New("KeyNotFound")
+
+	// ErrCorrupted is returned when an encrypted backup has been modified
+	// or truncated
+	ErrCorrupted = errors.New("Corrupted")
+
+	// ErrNotEncrypted is returned when a backup is not encrypted while the
+	// store has keys and does not allow unencrypted backups
+	ErrNotEncrypted = errors.New("NotEncrypted")
+
+	magic = []byte("BLQKENC1")
+)
+
+// ParseKey returns a key from its raw or base64 encoded value
+func ParseKey(value []byte) ([]byte, error) {
+	if len(value) == KeySize {
+		return value, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(value)))
+	if err != nil || len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// FromRequest returns the ID of the key that encrypts new backups and every
+// key of the request, by ID. It returns an empty ID when the request is not
+// encrypted
+func FromRequest(encryption *openapi.Encryption) (string, map[string][]byte, error) {
+	if encryption == nil {
+		return "", nil, nil
+	}
+	keys := map[string][]byte{}
+	for _, v := range encryption.Keys {
+		key, err := ParseKey([]byte(v.Key))
+		if err != nil {
+			return "", nil, err
+		}
+		keys[v.Id] = key
+	}
+	if _, ok := keys[encryption.KeyId]; !ok {
+		return "", nil, ErrKeyNotFound
+	}
+	return encryption.KeyId, keys, nil
+}
+
+// AllowUnencrypted returns true when backups that are not encrypted can be
+// read, that is when the request has no key or explicitly allows them
+func AllowUnencrypted(encryption *openapi.Encryption) bool {
+	return encryption == nil || encryption.AllowUnencrypted
+}
+
+// LoadKeys reads the keys from a directory, like a mounted secret. The file
+// names are the key IDs
+func LoadKeys(dir string) (map[string][]byte, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string][]byte{}
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		value, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParseKey(value)
+		if err != nil {
+			return nil, err
+		}
+		keys[f.Name()] = key
+	}
+	return keys, nil
+}
+
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type writer struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint64
+}
+
+// NewWriter returns a writer that encrypts to w with the key. Close must be
+// called to write the last chunk, it does not close w
+func NewWriter(w io.Writer, keyID string, key []byte) (io.WriteCloser, error) {
+	if len(keyID) == 0 || len(keyID) > 255 || len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	wrap, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	dataKey := make([]byte, KeySize)
+	nonce := make([]byte, wrap.NonceSize())
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header := append([]byte{}, magic...)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	wrapped := wrap.Seal(nil, nonce, dataKey, header)
+	header = append(header, nonce...)
+	header = append(header, wrapped...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{w: w, aead: aead, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (w *writer) flush(last bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.counter, last), w.buf, nil)
+	w.counter++
+	w.buf = w.buf[:0]
+	_, err := w.w.Write(sealed)
+	return err
+}
+
+// Write encrypts p. A full chunk is only sealed when more data comes so that
+// the last chunk is sealed by Close
+func (w *writer) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if len(w.buf) == chunkSize {
+			if err := w.flush(false); err != nil {
+				return n, err
+			}
+		}
+		c := copy(w.buf[len(w.buf):chunkSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close seals the last chunk
+func (w *writer) Close() error {
+	return w.flush(true)
+}
+
+type reader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	sealed  []byte
+	buf     []byte
+	counter uint64
+	done    bool
+}
+
+// NewReader detects an encrypted stream from its first bytes and returns a
+// reader that decrypts it with the key recorded in its header. It returns
+// the key ID, that is empty when the stream is not encrypted. A stream that
+// is not encrypted is rejected when there are keys, unless allowUnencrypted
+// is set
+func NewReader(r io.Reader, keys map[string][]byte, allowUnencrypted bool) (io.Reader, string, error) {
+	buffered := bufio.NewReader(r)
+	head, err := buffered.Peek(len(magic) + 1)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	if !bytes.HasPrefix(head, magic) {
+		if len(keys) > 0 && !allowUnencrypted {
+			return nil, "", ErrNotEncrypted
+		}
+		return buffered, "", nil
+	}
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(buffered, header); err != nil {
+		return nil, "", ErrCorrupted
+	}
+	id := make([]byte, int(header[len(magic)]))
+	if _, err := io.ReadFull(buffered, id); err != nil {
+		return nil, "", ErrCorrupted
+	}
+	header = append(header, id...)
+	keyID := string(id)
+	key, ok := keys[keyID]
+	if !ok {
+		return nil, keyID, ErrKeyNotFound
+	}
+	wrap, err := newGCM(key)
+	if err != nil {
+		return nil, keyID, err
+	}
+	nonce := make([]byte, wrap.NonceSize())
+	wrapped := make([]byte, KeySize+wrap.Overhead())
+	if _, err := io.ReadFull(buffered, nonce); err != nil {
+		return nil, keyID, ErrCorrupted
+	}
+	if _, err := io.ReadFull(buffered, wrapped); err != nil {
+		return nil, keyID, ErrCorrupted
+	}
+	dataKey, err := wrap.Open(nil, nonce, wrapped, header)
+	if err != nil {
+		return nil, keyID, ErrCorrupted
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, keyID, err
+	}
+	return &reader{
+		r:      buffered,
+		aead:   aead,
+		sealed: make([]byte, chunkSize+aead.Overhead()),
+	}, keyID, nil
+}
+
+func (r *reader) next() error {
+	n, err := io.ReadFull(r.r, r.sealed)
+	last := false
+	switch err {
+	case nil:
+		if _, err := r.r.Peek(1); err == io.EOF {
+			last = true
+		}
+	case io.ErrUnexpectedEOF:
+		last = true
+	default:
+		// the stream ends before its last chunk
+		return ErrCorrupted
+	}
+	buf, err := r.aead.Open(r.sealed[:0], chunkNonce(r.counter, last), r.sealed[:n], nil)
+	if err != nil {
+		return ErrCorrupted
+	}
+	r.counter++
+	r.buf = buf
+	r.done = last
+	return nil
+}
+
+// Read decrypts the stream, it fails when a chunk is not authenticated
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Seal encrypts src into dst with the key
+func Seal(src, dst, keyID string, key []byte) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	w, err := NewWriter(out, keyID, key)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Unseal decrypts src into dst and removes src. A file that is not encrypted
+// is renamed when there are no keys or allowUnencrypted is set. It returns the
+// ID of the key that has encrypted src
+func Unseal(src, dst string, keys map[string][]byte, allowUnencrypted bool) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	r, keyID, err := NewReader(in, keys, allowUnencrypted)
+	if err != nil {
+		return keyID, err
+	}
+	if keyID == "" {
+		in.Close()
+		return "", os.Rename(src, dst)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return keyID, err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return keyID, err
+	}
+	if err := out.Close(); err != nil {
+		return keyID, err
+	}
+	return keyID, os.Remove(src)
+}
@@ -0,0 +1,152 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type EncryptSuite struct {
+	suite.Suite
+	keys map[string][]byte
+}
+
+func (s *EncryptSuite) SetupSuite() {
+	s.keys = map[string][]byte{
+		"2021-01": bytes.Repeat([]byte{1}, KeySize),
+		"2021-02": bytes.Repeat([]byte{2}, KeySize),
+	}
+}
+
+func (s *EncryptSuite) encrypt(keyID string, content []byte) []byte {
+	buffer := &bytes.Buffer{}
+	w, err := NewWriter(buffer, keyID, s.keys[keyID])
+	require.NoError(s.T(), err)
+	_, err = w.Write(content)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), w.Close())
+	return buffer.Bytes()
+}
+
+func (s *EncryptSuite) TestRoundTrip() {
+	for _, size := range []int{0, 10, chunkSize, 3*chunkSize + 7} {
+		content := bytes.Repeat([]byte("a"), size)
+		sealed := s.encrypt("2021-02", content)
+		require.False(s.T(), bytes.Contains(sealed, []byte("aaaa")))
+
+		r, keyID, err := NewReader(bytes.NewReader(sealed), s.keys, false)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), "2021-02", keyID)
+		plain, err := ioutil.ReadAll(r)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), content, plain)
+	}
+}
+
+func (s *EncryptSuite) TestRotation() {
+	sealed := s.encrypt("2021-01", []byte("SELECT 1;\n"))
+	r, keyID, err := NewReader(bytes.NewReader(sealed), s.keys, false)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "2021-01", keyID)
+	plain, err := ioutil.ReadAll(r)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "SELECT 1;\n", string(plain))
+
+	_, keyID, err = NewReader(bytes.NewReader(sealed), map[string][]byte{"2021-02": s.keys["2021-02"]}, false)
+	require.Equal(s.T(), ErrKeyNotFound, err)
+	require.Equal(s.T(), "2021-01", keyID)
+}
+
+func (s *EncryptSuite) TestTampering() {
+	content := bytes.Repeat([]byte("b"), 2*chunkSize)
+	sealed := s.encrypt("2021-01", content)
+
+	modified := append([]byte{}, sealed...)
+	modified[len(modified)-20] ^= 1
+	r, _, err := NewReader(bytes.NewReader(modified), s.keys, false)
+	require.NoError(s.T(), err)
+	_, err = ioutil.ReadAll(r)
+	require.Equal(s.T(), ErrCorrupted, err)
+
+	// a stream truncated after a full chunk misses its last chunk
+	header := len(magic) + 1 + len("2021-01") + 12 + KeySize + 16
+	truncated := sealed[:header+chunkSize+16]
+	r, _, err = NewReader(bytes.NewReader(truncated), s.keys, false)
+	require.NoError(s.T(), err)
+	_, err = ioutil.ReadAll(r)
+	require.Equal(s.T(), ErrCorrupted, err)
+}
+
+func (s *EncryptSuite) TestPlaintext() {
+	r, keyID, err := NewReader(bytes.NewReader([]byte("SELECT 1;\n")), nil, false)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "", keyID)
+	plain, err := ioutil.ReadAll(r)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "SELECT 1;\n", string(plain))
+
+	// the store has keys, a backup that is not encrypted must be allowed
+	_, _, err = NewReader(bytes.NewReader([]byte("SELECT 1;\n")), s.keys, false)
+	require.Equal(s.T(), ErrNotEncrypted, err)
+	r, _, err = NewReader(bytes.NewReader([]byte("SELECT 1;\n")), s.keys, true)
+	require.NoError(s.T(), err)
+	plain, err = ioutil.ReadAll(r)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "SELECT 1;\n", string(plain))
+}
+
+func (s *EncryptSuite) TestKeys() {
+	dir, err := ioutil.TempDir("", "keys")
+	require.NoError(s.T(), err)
+	defer os.RemoveAll(dir)
+	encoded := base64.StdEncoding.EncodeToString(s.keys["2021-02"])
+	require.NoError(s.T(), ioutil.WriteFile(filepath.Join(dir, "2021-01"), s.keys["2021-01"], 0600))
+	require.NoError(s.T(), ioutil.WriteFile(filepath.Join(dir, "2021-02"), []byte(encoded+"\n"), 0600))
+	keys, err := LoadKeys(dir)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), s.keys, keys)
+
+	keyID, keys, err := FromRequest(&openapi.Encryption{
+		KeyId: "2021-02",
+		Keys:  []openapi.EncryptionKey{{Id: "2021-02", Key: encoded}},
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "2021-02", keyID)
+	require.Equal(s.T(), s.keys["2021-02"], keys["2021-02"])
+
+	_, _, err = FromRequest(&openapi.Encryption{KeyId: "2021-03", Keys: []openapi.EncryptionKey{{Id: "2021-02", Key: encoded}}})
+	require.Equal(s.T(), ErrKeyNotFound, err)
+	_, _, err = FromRequest(&openapi.Encryption{KeyId: "2021-02", Keys: []openapi.EncryptionKey{{Id: "2021-02", Key: "short"}}})
+	require.Equal(s.T(), ErrInvalidKey, err)
+}
+
+func (s *EncryptSuite) TestSealUnseal() {
+	dir, err := ioutil.TempDir("", "seal")
+	require.NoError(s.T(), err)
+	defer os.RemoveAll(dir)
+	plain := filepath.Join(dir, "red.sql")
+	sealed := filepath.Join(dir, "red.sql.enc")
+	require.NoError(s.T(), ioutil.WriteFile(plain, []byte("SELECT 1;\n"), 0600))
+	require.NoError(s.T(), Seal(plain, sealed, "2021-01", s.keys["2021-01"]))
+	os.Remove(plain)
+
+	keyID, err := Unseal(sealed, plain, s.keys, false)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "2021-01", keyID)
+	content, err := ioutil.ReadFile(plain)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "SELECT 1;\n", string(content))
+	_, err = os.Stat(sealed)
+	require.True(s.T(), os.IsNotExist(err))
+}
+
+func TestEncryptSuite(t *testing.T) {
+	suite.Run(t, &EncryptSuite{})
+}
@@ -0,0 +1,137 @@
+package filesystem
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// DefaultRoot is the directory the volumes of the stores are mounted in, each
+// store is mounted in a directory named after its bucket
+const DefaultRoot = "/var/lib/blaqkube/stores"
+
+// NewStorage creates a storage that keeps the objects in files under root
+func NewStorage(root string) *Storage {
+	return &Storage{Root: root}
+}
+
+// Storage is the storage for mounted volumes, like NFS backed claims. The
+// object of a request is the file <root>/<bucket>/<location>
+type Storage struct {
+	Root string
+}
+
+// path returns the file of a location. The bucket and the location are
+// cleaned so that the file cannot be outside of the bucket directory
+func (s *Storage) path(bucket, location string) string {
+	return filepath.Join(s.Root, filepath.Clean("/"+bucket), filepath.Clean("/"+location))
+}
+
+// Push copies a file to the store
+func (s *Storage) Push(request *openapi.BackupRequest, filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Printf("Could not open file %s, error: %v", filename, err)
+		return err
+	}
+	defer file.Close()
+	return s.PushStream(request, file)
+}
+
+// PushStream writes a stream to a temporary file that is renamed when the
+// stream ends, an object is never partially written
+func (s *Storage) PushStream(request *openapi.BackupRequest, r io.Reader) error {
+	path := s.path(request.Bucket, request.Location)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("Error push/mkdir to %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		log.Printf("Error push/create to %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		log.Printf("Error push/copy to %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Pull copies an object of the store to a file
+func (s *Storage) Pull(request *openapi.BackupRequest, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		log.Printf("Error pull/create %s from %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		return err
+	}
+	defer file.Close()
+	if err := s.PullStream(request, file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// PullStream copies an object of the store to a stream
+func (s *Storage) PullStream(request *openapi.BackupRequest, w io.Writer) error {
+	file, err := os.Open(s.path(request.Bucket, request.Location))
+	if err != nil {
+		log.Printf("Error pull/open from %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// Delete removes an object from the store
+func (s *Storage) Delete(request *openapi.BackupRequest) error {
+	if err := os.Remove(s.path(request.Bucket, request.Location)); err != nil {
+		log.Printf("Error delete/remove for %s:%s, error: %v", request.Bucket, request.Location, err)
+		return err
+	}
+	return nil
+}
+
+// List returns the locations of the files of the bucket that start with the
+// prefix, the locations start with a slash when the prefix does
+func (s *Storage) List(request *openapi.BackupRequest, prefix string) ([]string, error) {
+	root := s.path(request.Bucket, "/")
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("bucket %s is not mounted: %v", request.Bucket, err)
+	}
+	slash := strings.HasPrefix(prefix, "/")
+	locations := []string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		location, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		location = filepath.ToSlash(location)
+		if slash {
+			location = "/" + location
+		}
+		if strings.HasPrefix(location, prefix) {
+			locations = append(locations, location)
+		}
+		return nil
+	})
+	return locations, err
+}
@@ -0,0 +1,93 @@
+package filesystem
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+type StorageSuite struct {
+	suite.Suite
+	Root    string
+	Storage *Storage
+}
+
+func (s *StorageSuite) SetupTest() {
+	root, err := ioutil.TempDir("", "stores")
+	assert.NoError(s.T(), err)
+	s.Root = root
+	s.Storage = NewStorage(root)
+}
+
+func (s *StorageSuite) TearDownTest() {
+	os.RemoveAll(s.Root)
+}
+
+func (s *StorageSuite) TestFilesystemSuccess() {
+	b := openapi.BackupRequest{Bucket: "backups", Location: "/blue/blue-20210301-000000.sql"}
+
+	filename := filepath.Join(s.Root, "test.txt")
+	assert.NoError(s.T(), ioutil.WriteFile(filename, []byte("[test.txt]"), 0600))
+
+	err := s.Storage.Push(&b, filename)
+	assert.NoError(s.T(), err, "No Error")
+	assert.FileExists(s.T(), filepath.Join(s.Root, "backups", "blue", "blue-20210301-000000.sql"))
+
+	err = s.Storage.Pull(&b, filename+".pulled")
+	assert.NoError(s.T(), err, "No Error")
+	content, err := ioutil.ReadFile(filename + ".pulled")
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), "[test.txt]", string(content))
+
+	locations, err := s.Storage.List(&b, "/blue")
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), []string{"/blue/blue-20210301-000000.sql"}, locations)
+
+	err = s.Storage.Delete(&b)
+	assert.NoError(s.T(), err, "No Error")
+	err = s.Storage.Pull(&b, filename+".pulled")
+	assert.Error(s.T(), err, "Error")
+}
+
+func (s *StorageSuite) TestFilesystemStream() {
+	b := openapi.BackupRequest{Bucket: "backups", Location: "../../blue.sql"}
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1024)
+	err := s.Storage.PushStream(&b, bytes.NewReader(content))
+	assert.NoError(s.T(), err, "No Error")
+	// the location cannot leave the bucket directory
+	assert.FileExists(s.T(), filepath.Join(s.Root, "backups", "blue.sql"))
+
+	buffer := &bytes.Buffer{}
+	err = s.Storage.PullStream(&b, buffer)
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), content, buffer.Bytes())
+
+	locations, err := s.Storage.List(&b, "")
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), []string{"blue.sql"}, locations)
+}
+
+func (s *StorageSuite) TestFilesystemFailed() {
+	b := openapi.BackupRequest{Bucket: "missing", Location: "/blue.sql"}
+
+	_, err := s.Storage.List(&b, "/")
+	assert.Error(s.T(), err, "Error")
+
+	err = s.Storage.Delete(&b)
+	assert.Error(s.T(), err, "Error")
+
+	err = s.Storage.Push(&b, filepath.Join(s.Root, "test2.txt"))
+	assert.Error(s.T(), err, "Error")
+}
+
+func TestStorageSuite(t *testing.T) {
+	suite.Run(t, &StorageSuite{})
+}
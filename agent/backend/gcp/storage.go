@@ -7,11 +7,15 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -43,97 +47,101 @@ func getClient(ctx context.Context) (client *storage.Client, err error) {
 	return storage.NewClient(ctx)
 }
 
+func objectName(request *openapi.BackupRequest) string {
+	if request.Location[0:1] == "/" {
+		return request.Location[1:]
+	}
+	return request.Location
+}
+
 // Push pushes a file to blaqhole bucket
 func (s *Storage) Push(request *openapi.BackupRequest, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		log.Printf("Error push/opening %s to %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		return fmt.Errorf("os.Open: %v", err)
+	}
+	defer f.Close()
+	if err := s.PushStream(request, f); err != nil {
+		return err
+	}
+	log.Printf("Pushing %s to %s:%s, succeeded", filename, request.Bucket, request.Location)
+	return nil
+}
+
+// PushStream uploads a stream to GCS. The writer sends the object in chunks,
+// the upload is cancelled when the stream fails
+func (s *Storage) PushStream(request *openapi.BackupRequest, r io.Reader) error {
 	for _, v := range request.Envs {
 		os.Setenv(v.Name, v.Value)
 	}
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	client, err := getClient(ctx)
 	if err != nil {
-		log.Printf("Error push/gcp %s to %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		log.Printf("Error push/gcp to %s:%s, error: %v", request.Bucket, request.Location, err)
 		return fmt.Errorf("Cannot get Client: %v", err)
 	}
 	defer client.Close()
 
-	// Open local file.
-	f, err := os.Open(filename)
-	if err != nil {
-		log.Printf("Error push/opening %s to %s:%s, error: %v", filename, request.Bucket, request.Location, err)
-		return fmt.Errorf("os.Open: %v", err)
-	}
-	defer f.Close()
-
-	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
-	defer cancel()
-	location := request.Location
-	if request.Location[0:1] == "/" {
-		location = request.Location[1:]
+	wc := client.Bucket(request.Bucket).Object(objectName(request)).NewWriter(ctx)
+	wc.ContentType = compress.ContentType(request.Compression)
+	if request.Encryption != nil {
+		wc.ContentType = "application/octet-stream"
+		wc.Metadata = map[string]string{encrypt.MetadataKeyID: request.Encryption.KeyId}
 	}
-	wc := client.Bucket(request.Bucket).Object(location).NewWriter(ctx)
-	if _, err = io.Copy(wc, f); err != nil {
-		log.Printf("Error push/writer %s to %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+	if _, err = io.Copy(wc, r); err != nil {
+		cancel()
+		wc.Close()
+		log.Printf("Error push/writer to %s:%s, error: %v", request.Bucket, request.Location, err)
 		return fmt.Errorf("io.Copy: %v", err)
 	}
 	if err := wc.Close(); err != nil {
-		log.Printf("Error push/close %s to %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		log.Printf("Error push/close to %s:%s, error: %v", request.Bucket, request.Location, err)
 		return fmt.Errorf("Writer.Close: %v", err)
 	}
-	log.Printf("Pushing %s to %s:%s, succeeded", filename, request.Bucket, request.Location)
 	return nil
 }
 
 // Pull pull a file from the blackhole
 func (s *Storage) Pull(request *openapi.BackupRequest, filename string) error {
+	fo, err := os.Create(filename)
+	if err != nil {
+		log.Printf("Error pull/create %s from %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		return err
+	}
+	defer fo.Close()
+	if err := s.PullStream(request, fo); err != nil {
+		return err
+	}
+	return fo.Close()
+}
+
+// PullStream copies an object from GCS to a stream
+func (s *Storage) PullStream(request *openapi.BackupRequest, w io.Writer) error {
 	for _, v := range request.Envs {
 		os.Setenv(v.Name, v.Value)
 	}
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	client, err := getClient(ctx)
 	if err != nil {
-		log.Printf("Error pull/gcp %s from %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		log.Printf("Error pull/gcp from %s:%s, error: %v", request.Bucket, request.Location, err)
 		return fmt.Errorf("Cannot get Client: %v", err)
 	}
 	defer client.Close()
 
-	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
-	defer cancel()
-
-	location := request.Location
-	if request.Location[0:1] == "/" {
-		location = request.Location[1:]
-	}
+	location := objectName(request)
 	rc, err := client.Bucket(request.Bucket).Object(location).NewReader(ctx)
 	if err != nil {
-		log.Printf("Error pull/reader %s from %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		log.Printf("Error pull/reader from %s:%s, error: %v", request.Bucket, request.Location, err)
 		return fmt.Errorf("Object(%s).NewReader: %v", location, err)
 	}
 	defer rc.Close()
-
-	buf := make([]byte, 1024)
-	fo, err := os.Create(filename)
-	if err != nil {
-		log.Printf("Error pull/create %s from %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Printf("Error pull/copy from %s:%s, error: %v", request.Bucket, request.Location, err)
 		return err
 	}
-	defer fo.Close()
-
-	for {
-		// read a chunk
-		n, err := rc.Read(buf)
-		if err != nil && err != io.EOF {
-			log.Printf("Error pull/read chunk %s from %s:%s, error: %v", filename, request.Bucket, request.Location, err)
-			return err
-		}
-		if n == 0 {
-			break
-		}
-		// write a chunk
-		if _, err := fo.Write(buf[:n]); err != nil {
-			log.Printf("Error pull/write chunk %s from %s:%s, error: %v", filename, request.Bucket, request.Location, err)
-			return err
-		}
-	}
 	return nil
 }
 
@@ -153,11 +161,7 @@ func (s *Storage) Delete(request *openapi.BackupRequest) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
 	defer cancel()
 
-	location := request.Location
-	if request.Location[0:1] == "/" {
-		location = request.Location[1:]
-	}
-	o := client.Bucket(request.Bucket).Object(location)
+	o := client.Bucket(request.Bucket).Object(objectName(request))
 	if err := o.Delete(ctx); err != nil {
 		log.Printf("Error delete/Delete for %s:%s, error: %v", request.Bucket, request.Location, err)
 		return fmt.Errorf("Object(%q).Delete: %v", request.Location, err)
@@ -165,3 +169,41 @@ func (s *Storage) Delete(request *openapi.BackupRequest) error {
 	fmt.Printf("Delete for %s:%s deleted.\n", request.Bucket, request.Location)
 	return nil
 }
+
+// List returns the locations of the objects that start with the prefix. Like
+// for the other requests, a leading slash is not part of the object names
+func (s *Storage) List(request *openapi.BackupRequest, prefix string) ([]string, error) {
+	for _, v := range request.Envs {
+		os.Setenv(v.Name, v.Value)
+	}
+	ctx := context.Background()
+	client, err := getClient(ctx)
+	if err != nil {
+		log.Printf("Error list/getClient for %s:%s, error: %v", request.Bucket, prefix, err)
+		return nil, fmt.Errorf("Cannot get Client: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*60)
+	defer cancel()
+
+	slash := strings.HasPrefix(prefix, "/")
+	it := client.Bucket(request.Bucket).Objects(ctx, &storage.Query{Prefix: strings.TrimPrefix(prefix, "/")})
+	locations := []string{}
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Printf("Error list/Next for %s:%s, error: %v", request.Bucket, prefix, err)
+			return nil, err
+		}
+		if slash {
+			locations = append(locations, "/"+attrs.Name)
+			continue
+		}
+		locations = append(locations, attrs.Name)
+	}
+	return locations, nil
+}
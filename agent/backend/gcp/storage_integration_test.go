@@ -80,6 +80,10 @@ func (s *StorageSuite) TestGCPStorageSuccess() {
 	err = deleteFile("test.txt")
 	assert.NoError(s.T(), err, "No Error")
 
+	locations, err := s.Storage.List(&b, b.Location)
+	assert.NoError(s.T(), err, "No Error")
+	assert.Contains(s.T(), locations, b.Location)
+
 	err = s.Storage.Delete(&b)
 	assert.NoError(s.T(), err, "No Error")
 
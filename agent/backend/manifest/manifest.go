@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"log"
+	"os"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// extension is added to the location of a backup to get its manifest
+const extension = ".manifest.json"
+
+// ErrChecksum is returned when a backup does not match its manifest
+var ErrChecksum = errors.New("ChecksumMismatch")
+
+// Location returns the location of the manifest of a backup
+func Location(location string) string {
+	return location + extension
+}
+
+// Request returns the request of the manifest of a backup. The manifest is
+// not compressed nor encrypted
+func Request(request *openapi.BackupRequest) *openapi.BackupRequest {
+	return &openapi.BackupRequest{
+		Backend:  request.Backend,
+		Bucket:   request.Bucket,
+		Location: Location(request.Location),
+		Envs:     request.Envs,
+		S3:       request.S3,
+	}
+}
+
+// Digest is a writer that computes the sha256 and the size of the bytes
+// written to it
+type Digest struct {
+	hash hash.Hash
+	size int64
+}
+
+// NewDigest returns an empty digest
+func NewDigest() *Digest {
+	return &Digest{hash: sha256.New()}
+}
+
+// Write adds p to the digest
+func (d *Digest) Write(p []byte) (int, error) {
+	d.size += int64(len(p))
+	return d.hash.Write(p)
+}
+
+// Sum returns the hex encoded sha256 of the bytes written so far
+func (d *Digest) Sum() string {
+	return hex.EncodeToString(d.hash.Sum(nil))
+}
+
+// Size returns the number of bytes written so far
+func (d *Digest) Size() int64 {
+	return d.size
+}
+
+// Check returns ErrChecksum when the digest does not match the manifest
+func (d *Digest) Check(m *openapi.Manifest) error {
+	if d.Sum() != m.Sha256 || d.Size() != m.Size {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// Write pushes the manifest of a backup next to it
+func Write(storage backend.Storage, request *openapi.BackupRequest, m *openapi.Manifest) error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.PushStream(Request(request), bytes.NewReader(content))
+}
+
+// Read pulls the manifest of a backup
+func Read(storage backend.Storage, request *openapi.BackupRequest) (*openapi.Manifest, error) {
+	buffer := &bytes.Buffer{}
+	if err := storage.PullStream(Request(request), buffer); err != nil {
+		return nil, err
+	}
+	m := &openapi.Manifest{}
+	if err := json.Unmarshal(buffer.Bytes(), m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Lookup pulls the manifest of a backup, it returns nil when the manifest
+// cannot be read, like for a backup taken before manifests were written
+func Lookup(storage backend.Storage, request *openapi.BackupRequest) *openapi.Manifest {
+	m, err := Read(storage, request)
+	if err != nil {
+		log.Printf("No manifest for %s, its checksum is not verified: %v", request.Location, err)
+		return nil
+	}
+	return m
+}
+
+// Verify checks a downloaded backup matches the manifest next to it in the
+// store, a backup without a manifest is not verified
+func Verify(storage backend.Storage, request *openapi.BackupRequest, filename string) error {
+	m := Lookup(storage, request)
+	if m == nil {
+		return nil
+	}
+	return VerifyFile(filename, m)
+}
+
+// VerifyFile checks a downloaded backup matches its manifest
+func VerifyFile(filename string, m *openapi.Manifest) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d := NewDigest()
+	if _, err := io.Copy(d, f); err != nil {
+		return err
+	}
+	return d.Check(m)
+}
@@ -0,0 +1,83 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blaqkube/mysql-operator/agent/backend/mock"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// memory is a storage that keeps the objects in memory
+type memory struct {
+	*mock.Storage
+	objects map[string][]byte
+}
+
+func (m *memory) PushStream(backup *openapi.BackupRequest, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	m.objects[backup.Location] = content
+	return err
+}
+
+func (m *memory) PullStream(backup *openapi.BackupRequest, w io.Writer) error {
+	content, ok := m.objects[backup.Location]
+	if !ok {
+		return errors.New("NoSuchKey")
+	}
+	_, err := w.Write(content)
+	return err
+}
+
+type ManifestSuite struct {
+	suite.Suite
+}
+
+func (s *ManifestSuite) TestDigest() {
+	d := NewDigest()
+	d.Write([]byte("SELECT 1;\n"))
+	require.Equal(s.T(), int64(10), d.Size())
+	require.NoError(s.T(), d.Check(&openapi.Manifest{Sha256: d.Sum(), Size: 10}))
+	require.Equal(s.T(), ErrChecksum, d.Check(&openapi.Manifest{Sha256: d.Sum(), Size: 9}))
+}
+
+func (s *ManifestSuite) TestVerify() {
+	dir, err := ioutil.TempDir("", "manifest")
+	require.NoError(s.T(), err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "red.sql")
+	require.NoError(s.T(), ioutil.WriteFile(filename, []byte("SELECT 1;\n"), 0600))
+	d := NewDigest()
+	d.Write([]byte("SELECT 1;\n"))
+
+	storage := &memory{Storage: mock.NewStorage(), objects: map[string][]byte{}}
+	request := &openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/red.sql.zst", Compression: "zstd"}
+	require.Equal(s.T(), "/red.sql.zst.manifest.json", Request(request).Location)
+	require.Equal(s.T(), "", Request(request).Compression)
+
+	// a backup without a manifest is not verified
+	require.Nil(s.T(), Lookup(storage, request))
+	require.NoError(s.T(), Verify(storage, request, filename))
+
+	m := &openapi.Manifest{Sha256: d.Sum(), Size: d.Size(), MysqlVersion: "8.0.23", Databases: []string{"blue"}}
+	require.NoError(s.T(), Write(storage, request, m))
+	require.True(s.T(), bytes.Contains(storage.objects["/red.sql.zst.manifest.json"], []byte(`"mysql_version": "8.0.23"`)))
+	read, err := Read(storage, request)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), m, read)
+	require.NoError(s.T(), Verify(storage, request, filename))
+
+	require.NoError(s.T(), ioutil.WriteFile(filename, []byte("SELECT 2;\n"), 0600))
+	require.Equal(s.T(), ErrChecksum, Verify(storage, request, filename))
+}
+
+func TestManifestSuite(t *testing.T) {
+	suite.Run(t, &ManifestSuite{})
+}
@@ -1,12 +1,17 @@
 package mock
 
 import (
+	"io"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	"github.com/stretchr/testify/mock"
 )
 
 // Backup provides a mock for the database backup
 type Backup struct {
 	mock.Mock
+	Coordinates backend.Coordinates
 }
 
 // NewBackup instanciate a backup interface
@@ -15,6 +20,17 @@ func NewBackup() *Backup {
 }
 
 // Run runs a backup and store it as the filename
-func (m *Backup) Run(filename string) error {
+func (m *Backup) Run(filename string, request *openapi.BackupRequest) error {
+	return nil
+}
+
+// Stream runs a backup and writes it to the stream, it returns the
+// coordinates of the mock
+func (m *Backup) Stream(w io.Writer, request *openapi.BackupRequest) (backend.Coordinates, error) {
+	return m.Coordinates, nil
+}
+
+// CopyBack restores a physical backup in the data directory
+func (m *Backup) CopyBack(r io.Reader, datadir string) error {
 	return nil
 }
@@ -3,6 +3,7 @@ package mock
 import (
 	"testing"
 
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -18,7 +19,7 @@ func (s *BackupSuite) SetupTest() {
 
 func (s *BackupSuite) TestBackupSuccess() {
 
-	err := s.Service.Run("key")
+	err := s.Service.Run("key", &openapi.BackupRequest{Compression: "gzip"})
 	assert.NoError(s.T(), err, "No Error")
 }
 
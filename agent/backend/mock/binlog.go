@@ -0,0 +1,48 @@
+package mock
+
+import (
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/stretchr/testify/mock"
+)
+
+// Binlog provides a mock for the binary logs
+type Binlog struct {
+	mock.Mock
+	Logs    []backend.BinaryLog
+	UUID    string
+	Flushed int
+}
+
+// NewBinlog instanciate a binlog interface
+func NewBinlog() *Binlog {
+	return &Binlog{
+		Logs: []backend.BinaryLog{},
+		UUID: "3e11fa47-71ca-11e1-9e33-c80aa9429562",
+	}
+}
+
+// List returns the binary logs
+func (m *Binlog) List() ([]backend.BinaryLog, error) {
+	return m.Logs, nil
+}
+
+// Path returns the file of a binary log
+func (m *Binlog) Path(name string) (string, error) {
+	return name, nil
+}
+
+// ServerUUID returns the UUID of the server
+func (m *Binlog) ServerUUID() (string, error) {
+	return m.UUID, nil
+}
+
+// Flush counts the flushes
+func (m *Binlog) Flush() error {
+	m.Flushed++
+	return nil
+}
+
+// Extract converts binary logs into SQL statements
+func (m *Binlog) Extract(files []string, stopDatetime, includeGTIDs, filename string) error {
+	return nil
+}
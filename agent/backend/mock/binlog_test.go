@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+)
+
+type BinlogSuite struct {
+	suite.Suite
+	Service *Binlog
+}
+
+func (s *BinlogSuite) SetupTest() {
+	s.Service = NewBinlog()
+}
+
+func (s *BinlogSuite) TestBinlogSuccess() {
+	s.Service.Logs = []backend.BinaryLog{{Name: "binlog.000001", Size: 156}}
+	logs, err := s.Service.List()
+	assert.NoError(s.T(), err, "No Error")
+	assert.Len(s.T(), logs, 1, "One binlog")
+
+	err = s.Service.Flush()
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), 1, s.Service.Flushed, "Flushed once")
+
+	err = s.Service.Extract([]string{"binlog.000001"}, "", "", "pitr.sql")
+	assert.NoError(s.T(), err, "No Error")
+}
+
+func TestBinlogSuite(t *testing.T) {
+	suite.Run(t, &BinlogSuite{})
+}
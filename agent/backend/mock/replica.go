@@ -0,0 +1,26 @@
+package mock
+
+import (
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/mock"
+)
+
+// Replica provides a mock to seed a replica
+type Replica struct {
+	mock.Mock
+}
+
+// NewReplica instanciate a replica interface
+func NewReplica() *Replica {
+	return &Replica{}
+}
+
+// Dump dumps the source as the filename
+func (m *Replica) Dump(source *openapi.ReplicationRequest, filename string) error {
+	return nil
+}
+
+// Load loads the filename in the instance
+func (m *Replica) Load(filename string) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package mock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+type ReplicaSuite struct {
+	suite.Suite
+	Service *Replica
+}
+
+func (s *ReplicaSuite) SetupTest() {
+	s.Service = NewReplica()
+}
+
+func (s *ReplicaSuite) TestReplicaSuccess() {
+
+	err := s.Service.Dump(&openapi.ReplicationRequest{Host: "source"}, "seed.sql")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = s.Service.Load("seed.sql")
+	assert.NoError(s.T(), err, "No Error")
+}
+
+func TestReplicaSuite(t *testing.T) {
+	suite.Run(t, &ReplicaSuite{})
+}
@@ -0,0 +1,32 @@
+package mock
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// Restore provides a mock for the database restore, Err is returned by Run
+type Restore struct {
+	mock.Mock
+	Err error
+}
+
+// NewRestore instanciate a restore interface
+func NewRestore() *Restore {
+	return &Restore{}
+}
+
+// Run loads the filename in the database
+func (m *Restore) Run(filename string) error {
+	return m.Err
+}
+
+// Stream reads the dump to the end
+func (m *Restore) Stream(r io.Reader) error {
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return err
+	}
+	return m.Err
+}
@@ -0,0 +1,33 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RestoreSuite struct {
+	suite.Suite
+	Service *Restore
+}
+
+func (s *RestoreSuite) SetupTest() {
+	s.Service = NewRestore()
+}
+
+func (s *RestoreSuite) TestRestoreSuccess() {
+	err := s.Service.Run("key")
+	assert.NoError(s.T(), err, "No Error")
+}
+
+func (s *RestoreSuite) TestRestoreFailure() {
+	s.Service.Err = errors.New("failed")
+	err := s.Service.Run("key")
+	assert.Error(s.T(), err, "Error")
+}
+
+func TestRestoreSuite(t *testing.T) {
+	suite.Run(t, &RestoreSuite{})
+}
@@ -1,6 +1,10 @@
 package mock
 
 import (
+	"io"
+	"io/ioutil"
+	"os"
+
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	"github.com/stretchr/testify/mock"
 )
@@ -20,8 +24,23 @@ func (s *Storage) Push(backup *openapi.BackupRequest, filename string) error {
 	return nil
 }
 
-// Pull pull a file from S3, using a different location if necessary
+// Pull creates an empty file in place of the object
 func (s *Storage) Pull(backup *openapi.BackupRequest, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// PushStream reads the stream to the end
+func (s *Storage) PushStream(backup *openapi.BackupRequest, r io.Reader) error {
+	_, err := io.Copy(ioutil.Discard, r)
+	return err
+}
+
+// PullStream writes an empty object to the stream
+func (s *Storage) PullStream(backup *openapi.BackupRequest, w io.Writer) error {
 	return nil
 }
 
@@ -29,3 +48,8 @@ func (s *Storage) Pull(backup *openapi.BackupRequest, filename string) error {
 func (s *Storage) Delete(backup *openapi.BackupRequest) error {
 	return nil
 }
+
+// List returns no object
+func (s *Storage) List(backup *openapi.BackupRequest, prefix string) ([]string, error) {
+	return []string{}, nil
+}
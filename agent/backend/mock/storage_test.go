@@ -1,6 +1,9 @@
 package mock
 
 import (
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,6 +34,17 @@ func (s *StorageSuite) TestStorageSuccess() {
 
 	err = s.Storage.Pull(&b, "test2.txt")
 	assert.NoError(s.T(), err, "No Error")
+	os.Remove("test2.txt")
+
+	err = s.Storage.PushStream(&b, strings.NewReader("SELECT 1;"))
+	assert.NoError(s.T(), err, "No Error")
+
+	err = s.Storage.PullStream(&b, ioutil.Discard)
+	assert.NoError(s.T(), err, "No Error")
+
+	locations, err := s.Storage.List(&b, "/")
+	assert.NoError(s.T(), err, "No Error")
+	assert.Empty(s.T(), locations)
 
 	err = s.Storage.Delete(&b)
 	assert.NoError(s.T(), err, "No Error")
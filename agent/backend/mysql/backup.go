@@ -1,30 +1,189 @@
 package mysql
 
 import (
+	"database/sql"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/dump"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
 )
 
 // Backup can be used to generate database backups
 type Backup struct {
-	Exec string
+	Exec        string
+	DB          *sql.DB
+	Credentials Credentials
 }
 
 // NewBackup instanciate a backup interface
-func NewBackup() *Backup {
+func NewBackup(db *sql.DB, credentials Credentials) *Backup {
 	return &Backup{
-		Exec: "mysqldump",
+		Exec:        "mysqldump",
+		DB:          db,
+		Credentials: credentials,
+	}
+}
+
+// Run runs a backup and store it as the filename, the output of mysqldump is
+// compressed while it is written
+func (m *Backup) Run(filename string, request *openapi.BackupRequest) error {
+	return writeFile(filename, func(w io.Writer) error {
+		_, err := m.Stream(w, request)
+		return err
+	})
+}
+
+// writeFile creates the filename and writes a backup stream to it
+func writeFile(filename string, stream func(w io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := stream(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// databases returns the databases of the server
+func (m *Backup) databases() ([]string, error) {
+	rows, err := m.DB.Query("SHOW DATABASES")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	databases := []string{}
+	for rows.Next() {
+		database := ""
+		if err := rows.Scan(&database); err != nil {
+			return nil, err
+		}
+		databases = append(databases, database)
+	}
+	return databases, rows.Err()
+}
+
+// changeSource matches the commented CHANGE MASTER statement of a dump, it is
+// CHANGE REPLICATION SOURCE from MySQL 8.0.26
+var changeSource = regexp.MustCompile(`CHANGE (?:MASTER|REPLICATION SOURCE) TO (?:MASTER|SOURCE)_LOG_FILE='([^']+)', (?:MASTER|SOURCE)_LOG_POS=(\d+)`)
+
+// header reads the coordinates that mysqldump writes before the data of a
+// dump: the GTID_PURGED statement, that spans several lines when the server
+// has several UUIDs, and the CHANGE MASTER statement of --master-data=2
+type header struct {
+	coordinates backend.Coordinates
+	line        []byte
+	gtid        *strings.Builder
+	done        bool
+}
+
+// Write parses the lines of the dump until its data starts
+func (h *header) Write(p []byte) (int, error) {
+	for _, c := range p {
+		if h.done {
+			break
+		}
+		if c != '\n' {
+			h.line = append(h.line, c)
+			continue
+		}
+		h.parse(string(h.line))
+		h.line = h.line[:0]
 	}
+	return len(p), nil
 }
 
-// Run runs a backup and store it as the filename
-func (m *Backup) Run(filename string) error {
-	cmd := exec.Command(
-		m.Exec,
-		"--all-databases",
-		"--lock-all-tables",
-		"--host=127.0.0.1",
-		fmt.Sprintf(`--result-file=%s`, filename),
-	)
-	return cmd.Run()
+func (h *header) parse(line string) {
+	switch {
+	case h.gtid != nil:
+		h.addGTID(line)
+	case strings.HasPrefix(line, "SET @@GLOBAL.GTID_PURGED="):
+		value := strings.TrimPrefix(line, "SET @@GLOBAL.GTID_PURGED=")
+		// MySQL 8.0 adds the set to the GTIDs of the server, /*!80000 '+'*/
+		if i := strings.LastIndex(value, "*/"); i >= 0 {
+			value = value[i+2:]
+		}
+		h.gtid = &strings.Builder{}
+		h.addGTID(value)
+	case strings.HasPrefix(line, "-- CHANGE "):
+		if match := changeSource.FindStringSubmatch(line); match != nil {
+			h.coordinates.BinlogFile = match[1]
+			h.coordinates.BinlogPosition, _ = strconv.ParseInt(match[2], 10, 64)
+		}
+	case strings.HasPrefix(line, "-- Current Database:"),
+		strings.HasPrefix(line, "-- Table structure"),
+		strings.HasPrefix(line, "CREATE "):
+		h.done = true
+	}
+}
+
+func (h *header) addGTID(value string) {
+	value = strings.TrimSpace(value)
+	h.gtid.WriteString(strings.Trim(value, "';"))
+	if strings.HasSuffix(value, ";") {
+		h.coordinates.GTIDExecuted = h.gtid.String()
+		h.gtid = nil
+	}
+}
+
+// logBin returns true when the binary log is enabled, mysqldump fails to
+// write the binary log position when it is not
+func (m *Backup) logBin() (bool, error) {
+	logBin := false
+	err := m.DB.QueryRow("SELECT @@GLOBAL.log_bin").Scan(&logBin)
+	return logBin, err
+}
+
+// Stream runs a backup and writes the output of mysqldump to w, compressed
+// with the codec. The mysqldump arguments are built from the dump options of
+// the request. It returns the coordinates written in the dump and does not
+// close w
+func (m *Backup) Stream(w io.Writer, request *openapi.BackupRequest) (backend.Coordinates, error) {
+	if err := dump.Validate(request.Dump); err != nil {
+		return backend.Coordinates{}, err
+	}
+	databases := []string{}
+	if dump.Excludes(request.Dump) {
+		var err error
+		if databases, err = m.databases(); err != nil {
+			return backend.Coordinates{}, err
+		}
+	}
+	flags, names := dump.Args(request.Dump, databases)
+	if dump.Excludes(request.Dump) && len(names) == 0 {
+		return backend.Coordinates{}, fmt.Errorf("%w: every database is excluded", dump.ErrInvalidOptions)
+	}
+	logBin, err := m.logBin()
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	if logBin {
+		flags = append(flags, "--master-data=2")
+	}
+	cw, err := compress.NewWriter(w, request.Compression)
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	h := &header{}
+	cmd := exec.Command(m.Exec, append(flags, "--host=127.0.0.1")...)
+	setCredentials(cmd, m.Credentials)
+	if len(names) > 0 {
+		// the names are never read as options
+		cmd.Args = append(append(cmd.Args, "--"), names...)
+	}
+	cmd.Stdout = io.MultiWriter(cw, h)
+	if err := cmd.Run(); err != nil {
+		cw.Close()
+		return backend.Coordinates{}, err
+	}
+	return h.coordinates, cw.Close()
 }
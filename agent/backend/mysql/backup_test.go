@@ -1,32 +1,166 @@
 package mysql
 
 import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/dump"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
 type BackupSuite struct {
 	suite.Suite
+	db            *sql.DB
+	mock          sqlmock.Sqlmock
 	backupService *Backup
 }
 
-func (s *BackupSuite) SetupSuite() {
-	s.backupService = &Backup{}
+func (s *BackupSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+	s.backupService = &Backup{DB: s.db}
+}
+
+func (s *BackupSuite) TearDownTest() {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+	s.db.Close()
+}
+
+// expectLogBin returns the binary log status of the server
+func (s *BackupSuite) expectLogBin(logBin int) {
+	s.mock.ExpectQuery("SELECT @@GLOBAL.log_bin").
+		WillReturnRows(sqlmock.NewRows([]string{"@@GLOBAL.log_bin"}).AddRow(logBin))
 }
 
 func (s *BackupSuite) TestBackup() {
+	s.expectLogBin(1)
 	s.backupService.Exec = "true"
-	err := s.backupService.Run("backup.dmp")
+	err := s.backupService.Run("backup.dmp", &openapi.BackupRequest{})
 	require.NoError(s.T(), err)
+	os.Remove("backup.dmp")
 }
 
 func (s *BackupSuite) TestFailedBackup() {
+	s.expectLogBin(1)
 	s.backupService.Exec = "false"
-	err := s.backupService.Run("backup.dmp")
+	err := s.backupService.Run("backup.dmp", &openapi.BackupRequest{})
 	require.Error(s.T(), err)
 	require.Equal(s.T(), "exit status 1", err.Error())
+	os.Remove("backup.dmp")
+}
+
+func (s *BackupSuite) TestCompressedBackup() {
+	s.expectLogBin(1)
+	s.backupService.Exec = "echo"
+	err := s.backupService.Run("backup.dmp.zst", &openapi.BackupRequest{Compression: compress.Zstd})
+	require.NoError(s.T(), err)
+	codec, err := compress.Unpack("backup.dmp.zst", "backup.dmp")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), compress.Zstd, codec)
+	content, err := ioutil.ReadFile("backup.dmp")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "--all-databases --lock-all-tables --master-data=2 --host=127.0.0.1\n", string(content))
+	os.Remove("backup.dmp")
+}
+
+func (s *BackupSuite) TestStreamedBackup() {
+	s.expectLogBin(0)
+	s.backupService.Exec = "echo"
+	buffer := &bytes.Buffer{}
+	_, err := s.backupService.Stream(buffer, &openapi.BackupRequest{Compression: compress.Gzip})
+	require.NoError(s.T(), err)
+	r, codec, err := compress.NewReader(buffer)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), compress.Gzip, codec)
+	content, err := ioutil.ReadAll(r)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "--all-databases --lock-all-tables --host=127.0.0.1\n", string(content))
+}
+
+func (s *BackupSuite) TestDumpOptions() {
+	s.expectLogBin(0)
+	s.backupService.Exec = "echo"
+	buffer := &bytes.Buffer{}
+	_, err := s.backupService.Stream(buffer, &openapi.BackupRequest{Dump: &openapi.DumpOptions{
+		Consistency:   dump.SingleTransaction,
+		Tables:        []string{"blue.users", "blue.orders"},
+		ExcludeTables: []string{"blue.logs"},
+		Routines:      true,
+	}})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "--single-transaction --routines --ignore-table=blue.logs --host=127.0.0.1 -- blue users orders\n", buffer.String())
+
+	_, err = s.backupService.Stream(buffer, &openapi.BackupRequest{Dump: &openapi.DumpOptions{Consistency: "snapshot"}})
+	require.True(s.T(), errors.Is(err, dump.ErrInvalidOptions))
+}
+
+func (s *BackupSuite) TestExcludeDatabases() {
+	s.backupService.Exec = "echo"
+	s.mock.ExpectQuery("SHOW DATABASES").
+		WillReturnRows(sqlmock.NewRows([]string{"Database"}).AddRow("blue").AddRow("information_schema").AddRow("mysql").AddRow("red"))
+	s.expectLogBin(0)
+	buffer := &bytes.Buffer{}
+	_, err := s.backupService.Stream(buffer, &openapi.BackupRequest{Dump: &openapi.DumpOptions{ExcludeDatabases: []string{"red", "mysql"}}})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "--databases --lock-all-tables --host=127.0.0.1 -- blue\n", buffer.String())
+
+	s.mock.ExpectQuery("SHOW DATABASES").
+		WillReturnRows(sqlmock.NewRows([]string{"Database"}).AddRow("red"))
+	_, err = s.backupService.Stream(buffer, &openapi.BackupRequest{Dump: &openapi.DumpOptions{ExcludeDatabases: []string{"red"}}})
+	require.True(s.T(), errors.Is(err, dump.ErrInvalidOptions))
+}
+
+func (s *BackupSuite) TestHeader() {
+	output := "-- MySQL dump 10.13\n" +
+		"SET @@SESSION.SQL_LOG_BIN= 0;\n" +
+		"SET @@GLOBAL.GTID_PURGED=/*!80000 '+'*/ '3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5,\n" +
+		"8e11fa47-71ca-11e1-9e33-c80aa9429562:1-2';\n" +
+		"-- CHANGE MASTER TO MASTER_LOG_FILE='binlog.000003', MASTER_LOG_POS=156;\n" +
+		"-- Current Database: `blue`\n" +
+		"-- CHANGE MASTER TO MASTER_LOG_FILE='binlog.000009', MASTER_LOG_POS=4;\n"
+	h := &header{}
+	// the lines are split across writes
+	for i := 0; i < len(output); i += 7 {
+		end := i + 7
+		if end > len(output) {
+			end = len(output)
+		}
+		n, err := h.Write([]byte(output[i:end]))
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), end-i, n)
+	}
+	require.Equal(s.T(), backend.Coordinates{
+		GTIDExecuted:   "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5,8e11fa47-71ca-11e1-9e33-c80aa9429562:1-2",
+		BinlogFile:     "binlog.000003",
+		BinlogPosition: 156,
+	}, h.coordinates)
+
+	h = &header{}
+	h.Write([]byte("SET @@GLOBAL.GTID_PURGED='3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5';\n" +
+		"-- CHANGE REPLICATION SOURCE TO SOURCE_LOG_FILE='binlog.000004', SOURCE_LOG_POS=157;\n"))
+	require.Equal(s.T(), backend.Coordinates{
+		GTIDExecuted:   "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5",
+		BinlogFile:     "binlog.000004",
+		BinlogPosition: 157,
+	}, h.coordinates)
+}
+
+func (s *BackupSuite) TestUnsupportedCompression() {
+	s.expectLogBin(1)
+	s.backupService.Exec = "true"
+	err := s.backupService.Run("backup.dmp", &openapi.BackupRequest{Compression: "xz"})
+	require.Equal(s.T(), compress.ErrUnsupportedCodec, err)
+	os.Remove("backup.dmp")
 }
 
 func TestBackupSuite(t *testing.T) {
@@ -0,0 +1,100 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+)
+
+// Binlog gives access to the binary logs of the local instance
+type Binlog struct {
+	DB   *sql.DB
+	Exec string
+}
+
+// NewBinlog instanciate a binlog interface
+func NewBinlog(db *sql.DB) *Binlog {
+	return &Binlog{
+		DB:   db,
+		Exec: "mysqlbinlog",
+	}
+}
+
+// List returns the binary logs in order, the last one is in use
+func (m *Binlog) List() ([]backend.BinaryLog, error) {
+	rows, err := m.DB.Query("SHOW BINARY LOGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	logs := []backend.BinaryLog{}
+	for rows.Next() {
+		// MySQL 8.0.14 has added the Encrypted column
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		binaryLog := backend.BinaryLog{Name: values[0].String}
+		if len(values) > 1 {
+			fmt.Sscan(values[1].String, &binaryLog.Size)
+		}
+		logs = append(logs, binaryLog)
+	}
+	return logs, rows.Err()
+}
+
+// Path returns the file of a binary log, the agent shares the data
+// directory with the server
+func (m *Binlog) Path(name string) (string, error) {
+	var basename string
+	if err := m.DB.QueryRow("SELECT @@GLOBAL.log_bin_basename").Scan(&basename); err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(basename), name), nil
+}
+
+// ServerUUID returns the UUID of the server, it keeps the binary logs of
+// several servers apart in an archive
+func (m *Binlog) ServerUUID() (string, error) {
+	var uuid string
+	err := m.DB.QueryRow("SELECT @@GLOBAL.server_uuid").Scan(&uuid)
+	return uuid, err
+}
+
+// Flush closes the binary log in use and opens a new one
+func (m *Binlog) Flush() error {
+	_, err := m.DB.Exec("FLUSH BINARY LOGS")
+	return err
+}
+
+// Extract converts binary log files into SQL statements in filename. It
+// stops at the first event after stopDatetime, a UTC time like
+// 2021-03-01 10:00:00, and only keeps the transactions of includeGTIDs when
+// they are set
+func (m *Binlog) Extract(files []string, stopDatetime, includeGTIDs, filename string) error {
+	args := []string{}
+	if stopDatetime != "" {
+		args = append(args, fmt.Sprintf("--stop-datetime=%s", stopDatetime))
+	}
+	if includeGTIDs != "" {
+		args = append(args, fmt.Sprintf("--include-gtids=%s", includeGTIDs))
+	}
+	args = append(args, fmt.Sprintf("--result-file=%s", filename))
+	args = append(args, files...)
+	cmd := exec.Command(m.Exec, args...)
+	// mysqlbinlog reads --stop-datetime in the local time zone
+	cmd.Env = append(os.Environ(), "TZ=UTC")
+	return cmd.Run()
+}
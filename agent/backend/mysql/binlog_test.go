@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type BinlogSuite struct {
+	suite.Suite
+	db            *sql.DB
+	mock          sqlmock.Sqlmock
+	binlogService *Binlog
+}
+
+func (s *BinlogSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+	s.binlogService = NewBinlog(s.db)
+}
+
+func (s *BinlogSuite) TearDownTest() {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+	s.db.Close()
+}
+
+func (s *BinlogSuite) TestList() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SHOW BINARY LOGS")).
+		WillReturnRows(sqlmock.NewRows([]string{"Log_name", "File_size", "Encrypted"}).
+			AddRow("binlog.000001", "1024", "No").
+			AddRow("binlog.000002", "156", "No"))
+	logs, err := s.binlogService.List()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []backend.BinaryLog{
+		{Name: "binlog.000001", Size: 1024},
+		{Name: "binlog.000002", Size: 156},
+	}, logs)
+}
+
+func (s *BinlogSuite) TestPath() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT @@GLOBAL.log_bin_basename")).
+		WillReturnRows(sqlmock.NewRows([]string{"@@GLOBAL.log_bin_basename"}).AddRow("/var/lib/mysql/binlog"))
+	path, err := s.binlogService.Path("binlog.000001")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "/var/lib/mysql/binlog.000001", path)
+}
+
+func (s *BinlogSuite) TestFlush() {
+	s.mock.ExpectExec(regexp.QuoteMeta("FLUSH BINARY LOGS")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(s.T(), s.binlogService.Flush())
+}
+
+func (s *BinlogSuite) TestExtract() {
+	s.binlogService.Exec = "true"
+	err := s.binlogService.Extract([]string{"binlog.000001"}, "2021-03-01 10:00:00", "", "pitr.sql")
+	require.NoError(s.T(), err)
+}
+
+func (s *BinlogSuite) TestFailedExtract() {
+	s.binlogService.Exec = "false"
+	err := s.binlogService.Extract([]string{"binlog.000001"}, "", "", "pitr.sql")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), "exit status 1", err.Error())
+}
+
+func TestBinlogSuite(t *testing.T) {
+	suite.Run(t, &BinlogSuite{})
+}
@@ -0,0 +1,189 @@
+package mysql
+
+import (
+	"archive/tar"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// cloneDir is where the server clones its data. It is part of the data
+// volume so that the agent container can archive it
+const cloneDir = "#blaqkube-clone"
+
+// Clone can be used to take physical backups with the MySQL clone plugin and
+// to restore them
+type Clone struct {
+	DB      *sql.DB
+	Datadir string
+}
+
+// NewClone instanciate a physical backup interface with the clone plugin
+func NewClone(db *sql.DB) *Clone {
+	return &Clone{
+		DB:      db,
+		Datadir: defaultDatadir,
+	}
+}
+
+// Run runs a physical backup and store it as the filename
+func (m *Clone) Run(filename string, request *openapi.BackupRequest) error {
+	return writeFile(filename, func(w io.Writer) error {
+		_, err := m.Stream(w, request)
+		return err
+	})
+}
+
+// install loads the clone plugin when it is not
+func (m *Clone) install() error {
+	status := ""
+	err := m.DB.QueryRow("SELECT PLUGIN_STATUS FROM information_schema.PLUGINS WHERE PLUGIN_NAME = 'clone'").Scan(&status)
+	if err == sql.ErrNoRows {
+		_, err = m.DB.Exec("INSTALL PLUGIN clone SONAME 'mysql_clone.so'")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if status != "ACTIVE" {
+		return fmt.Errorf("clone plugin is %s", status)
+	}
+	return nil
+}
+
+// coordinates returns the binary log position and the GTID set of the last
+// clone, the clone is consistent with them
+func (m *Clone) coordinates() (backend.Coordinates, error) {
+	file, position, gtid := sql.NullString{}, sql.NullInt64{}, sql.NullString{}
+	err := m.DB.QueryRow("SELECT BINLOG_FILE, BINLOG_POSITION, GTID_EXECUTED FROM performance_schema.clone_status").
+		Scan(&file, &position, &gtid)
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	return backend.Coordinates{
+		GTIDExecuted:   strings.ReplaceAll(gtid.String, "\n", ""),
+		BinlogFile:     file.String,
+		BinlogPosition: position.Int64,
+	}, nil
+}
+
+// Stream clones the server data and writes it to w as a tar archive,
+// compressed with the codec. The clone is removed once it is archived. It
+// returns the coordinates of the clone
+func (m *Clone) Stream(w io.Writer, request *openapi.BackupRequest) (backend.Coordinates, error) {
+	cw, err := compress.NewWriter(w, request.Compression)
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	if err := m.install(); err != nil {
+		return backend.Coordinates{}, err
+	}
+	dir := filepath.Join(m.Datadir, cloneDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return backend.Coordinates{}, err
+	}
+	defer os.RemoveAll(dir)
+	if _, err := m.DB.Exec(fmt.Sprintf("CLONE LOCAL DATA DIRECTORY = '%s'", strings.ReplaceAll(dir, "'", "''"))); err != nil {
+		return backend.Coordinates{}, err
+	}
+	coordinates, err := m.coordinates()
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	if err := archive(dir, cw); err != nil {
+		cw.Close()
+		return backend.Coordinates{}, err
+	}
+	return coordinates, cw.Close()
+}
+
+// CopyBack extracts a clone in the data directory. The clone is consistent
+// and the server can start from it. The server must be stopped
+func (m *Clone) CopyBack(r io.Reader, datadir string) error {
+	return extract(r, datadir)
+}
+
+// archive writes the files of dir to w as a tar archive
+func archive(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil || name == "." {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extract writes the files of a tar archive to dir
+func extract(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path %s in archive", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, os.FileMode(header.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
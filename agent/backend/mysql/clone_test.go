@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CloneSuite struct {
+	suite.Suite
+	db    *sql.DB
+	mock  sqlmock.Sqlmock
+	clone *Clone
+}
+
+func (s *CloneSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+	s.clone = NewClone(s.db)
+}
+
+func (s *CloneSuite) TearDownTest() {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+	s.db.Close()
+}
+
+func (s *CloneSuite) TestInstall() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT PLUGIN_STATUS FROM information_schema.PLUGINS WHERE PLUGIN_NAME = 'clone'")).
+		WillReturnRows(sqlmock.NewRows([]string{"PLUGIN_STATUS"}))
+	s.mock.ExpectExec(regexp.QuoteMeta("INSTALL PLUGIN clone SONAME 'mysql_clone.so'")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(s.T(), s.clone.install())
+
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT PLUGIN_STATUS FROM information_schema.PLUGINS WHERE PLUGIN_NAME = 'clone'")).
+		WillReturnRows(sqlmock.NewRows([]string{"PLUGIN_STATUS"}).AddRow("DISABLED"))
+	require.Equal(s.T(), "clone plugin is DISABLED", s.clone.install().Error())
+}
+
+func (s *CloneSuite) TestFailedClone() {
+	datadir, err := ioutil.TempDir("", "datadir")
+	require.NoError(s.T(), err)
+	defer os.RemoveAll(datadir)
+	s.clone.Datadir = datadir
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT PLUGIN_STATUS FROM information_schema.PLUGINS WHERE PLUGIN_NAME = 'clone'")).
+		WillReturnRows(sqlmock.NewRows([]string{"PLUGIN_STATUS"}).AddRow("ACTIVE"))
+	s.mock.ExpectExec(regexp.QuoteMeta("CLONE LOCAL DATA DIRECTORY = '" + filepath.Join(datadir, cloneDir) + "'")).
+		WillReturnError(errors.New("ER_CLONE_DDL_IN_PROGRESS"))
+	_, err = s.clone.Stream(ioutil.Discard, &openapi.BackupRequest{})
+	require.Equal(s.T(), "ER_CLONE_DDL_IN_PROGRESS", err.Error())
+}
+
+func (s *CloneSuite) TestCoordinates() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT BINLOG_FILE, BINLOG_POSITION, GTID_EXECUTED FROM performance_schema.clone_status")).
+		WillReturnRows(sqlmock.NewRows([]string{"BINLOG_FILE", "BINLOG_POSITION", "GTID_EXECUTED"}).
+			AddRow("binlog.000003", 156, "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5,\n8e11fa47-71ca-11e1-9e33-c80aa9429562:1-2"))
+	coordinates, err := s.clone.coordinates()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), backend.Coordinates{
+		GTIDExecuted:   "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5,8e11fa47-71ca-11e1-9e33-c80aa9429562:1-2",
+		BinlogFile:     "binlog.000003",
+		BinlogPosition: 156,
+	}, coordinates)
+}
+
+func (s *CloneSuite) TestCopyBack() {
+	src, err := ioutil.TempDir("", "clone")
+	require.NoError(s.T(), err)
+	defer os.RemoveAll(src)
+	require.NoError(s.T(), os.MkdirAll(filepath.Join(src, "blue"), 0750))
+	require.NoError(s.T(), ioutil.WriteFile(filepath.Join(src, "mysql.ibd"), []byte("mysql"), 0640))
+	require.NoError(s.T(), ioutil.WriteFile(filepath.Join(src, "blue", "t.ibd"), []byte("blue"), 0640))
+	buffer := &bytes.Buffer{}
+	require.NoError(s.T(), archive(src, buffer))
+
+	datadir, err := ioutil.TempDir("", "datadir")
+	require.NoError(s.T(), err)
+	defer os.RemoveAll(datadir)
+	require.NoError(s.T(), s.clone.CopyBack(buffer, datadir))
+	content, err := ioutil.ReadFile(filepath.Join(datadir, "blue", "t.ibd"))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "blue", string(content))
+	content, err = ioutil.ReadFile(filepath.Join(datadir, "mysql.ibd"))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "mysql", string(content))
+}
+
+func TestCloneSuite(t *testing.T) {
+	suite.Run(t, &CloneSuite{})
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"os/exec"
+
+	gomysql "github.com/go-sql-driver/mysql"
+)
+
+// Credentials returns the account used to connect to the local instance.
+// It is called for every new connection so that a rotated password is used
+// without restarting the agent
+type Credentials func() (username, password string)
+
+// Connector opens connections to MySQL with the current credentials
+type Connector struct {
+	Address     string
+	Credentials Credentials
+}
+
+// NewConnector creates a connector for an address like 127.0.0.1:3306
+func NewConnector(address string, credentials Credentials) *Connector {
+	return &Connector{
+		Address:     address,
+		Credentials: credentials,
+	}
+}
+
+// Connect opens a connection with the credentials in use
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	config := gomysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = c.Address
+	config.User, config.Passwd = c.Credentials()
+	connector, err := gomysql.NewConnector(config)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// Driver returns the MySQL driver
+func (c *Connector) Driver() driver.Driver {
+	return gomysql.MySQLDriver{}
+}
+
+// setCredentials adds the user to the arguments of a MySQL client and its
+// password to the environment
+func setCredentials(cmd *exec.Cmd, credentials Credentials) {
+	if credentials == nil {
+		return
+	}
+	username, password := credentials()
+	if username == "" {
+		return
+	}
+	cmd.Args = append(cmd.Args, fmt.Sprintf("--user=%s", username))
+	cmd.Env = append(os.Environ(), fmt.Sprintf("MYSQL_PWD=%s", password))
+}
@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Replica can be used to seed a replica from its source
+type Replica struct {
+	DumpExec    string
+	LoadExec    string
+	Credentials Credentials
+}
+
+// NewReplica instanciate a replica interface
+func NewReplica(credentials Credentials) *Replica {
+	return &Replica{
+		DumpExec:    "mysqldump",
+		LoadExec:    "mysql",
+		Credentials: credentials,
+	}
+}
+
+// Dump runs a consistent dump of the source, including the GTID set, and
+// stores it as the filename
+func (m *Replica) Dump(source *openapi.ReplicationRequest, filename string) error {
+	port := source.Port
+	if port == 0 {
+		port = 3306
+	}
+	cmd := exec.Command(
+		m.DumpExec,
+		"--all-databases",
+		"--single-transaction",
+		"--set-gtid-purged=ON",
+		"--routines",
+		"--triggers",
+		"--events",
+		fmt.Sprintf("--host=%s", source.Host),
+		fmt.Sprintf("--port=%d", port),
+		fmt.Sprintf("--user=%s", source.Username),
+		fmt.Sprintf("--result-file=%s", filename),
+	)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("MYSQL_PWD=%s", source.Password))
+	return cmd.Run()
+}
+
+// Load loads a dump file in the local instance
+func (m *Replica) Load(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cmd := exec.Command(
+		m.LoadExec,
+		"--host=127.0.0.1",
+	)
+	setCredentials(cmd, m.Credentials)
+	cmd.Stdin = f
+	return cmd.Run()
+}
@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReplicaSuite struct {
+	suite.Suite
+	replicaService *Replica
+}
+
+func (s *ReplicaSuite) SetupSuite() {
+	s.replicaService = &Replica{}
+}
+
+func (s *ReplicaSuite) TestDump() {
+	s.replicaService.DumpExec = "true"
+	err := s.replicaService.Dump(&openapi.ReplicationRequest{Host: "127.0.0.1"}, "seed.sql")
+	require.NoError(s.T(), err)
+}
+
+func (s *ReplicaSuite) TestFailedDump() {
+	s.replicaService.DumpExec = "false"
+	err := s.replicaService.Dump(&openapi.ReplicationRequest{Host: "127.0.0.1"}, "seed.sql")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), "exit status 1", err.Error())
+}
+
+func (s *ReplicaSuite) TestLoad() {
+	f, err := ioutil.TempFile("", "seed")
+	require.NoError(s.T(), err)
+	defer os.Remove(f.Name())
+	f.Close()
+
+	s.replicaService.LoadExec = "true"
+	err = s.replicaService.Load(f.Name())
+	require.NoError(s.T(), err)
+}
+
+func (s *ReplicaSuite) TestLoadMissingFile() {
+	s.replicaService.LoadExec = "true"
+	err := s.replicaService.Load("missing.sql")
+	require.Error(s.T(), err)
+}
+
+func TestReplicaSuite(t *testing.T) {
+	suite.Run(t, &ReplicaSuite{})
+}
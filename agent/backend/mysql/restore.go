@@ -0,0 +1,43 @@
+package mysql
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Restore can be used to load database backups in the running server
+type Restore struct {
+	Exec        string
+	Credentials Credentials
+}
+
+// NewRestore instanciate a restore interface
+func NewRestore(credentials Credentials) *Restore {
+	return &Restore{
+		Exec:        "mysql",
+		Credentials: credentials,
+	}
+}
+
+// Run streams the filename to the mysql client connected to the server
+func (m *Restore) Run(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.Stream(f)
+}
+
+// Stream loads a dump from r with the mysql client connected to the server.
+// It fails when r cannot be read to the end
+func (m *Restore) Stream(r io.Reader) error {
+	cmd := exec.Command(
+		m.Exec,
+		"--host=127.0.0.1",
+	)
+	setCredentials(cmd, m.Credentials)
+	cmd.Stdin = r
+	return cmd.Run()
+}
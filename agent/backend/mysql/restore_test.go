@@ -0,0 +1,51 @@
+package mysql
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RestoreSuite struct {
+	suite.Suite
+	restoreService *Restore
+	filename       string
+}
+
+func (s *RestoreSuite) SetupSuite() {
+	s.restoreService = &Restore{}
+	f, err := ioutil.TempFile("", "restore")
+	require.NoError(s.T(), err)
+	f.Close()
+	s.filename = f.Name()
+}
+
+func (s *RestoreSuite) TearDownSuite() {
+	os.Remove(s.filename)
+}
+
+func (s *RestoreSuite) TestRestore() {
+	s.restoreService.Exec = "true"
+	err := s.restoreService.Run(s.filename)
+	require.NoError(s.T(), err)
+}
+
+func (s *RestoreSuite) TestFailedRestore() {
+	s.restoreService.Exec = "false"
+	err := s.restoreService.Run(s.filename)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), "exit status 1", err.Error())
+}
+
+func (s *RestoreSuite) TestRestoreMissingFile() {
+	s.restoreService.Exec = "true"
+	err := s.restoreService.Run("missing.sql")
+	require.Error(s.T(), err)
+}
+
+func TestRestoreSuite(t *testing.T) {
+	suite.Run(t, &RestoreSuite{})
+}
@@ -0,0 +1,158 @@
+package mysql
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+const (
+	// defaultDatadir is the data directory of the server, it is shared with
+	// the agent container
+	defaultDatadir = "/var/lib/mysql"
+
+	// stagingDir is where a physical backup is extracted and prepared before
+	// it is moved to the data directory. Like the server internal files, it
+	// starts with # so that it cannot be a database
+	stagingDir = "#blaqkube-restore"
+
+	// xtrabackupInfo is the metadata file of a backup, XtraBackup writes a
+	// copy in the --extra-lsndir directory
+	xtrabackupInfo = "xtrabackup_info"
+)
+
+// binlogPos matches the binary log position and the GTID set of the
+// binlog_pos line of the metadata, the GTID set is missing when the server
+// does not use GTIDs
+var binlogPos = regexp.MustCompile(`(?m)^binlog_pos = filename '([^']*)', position '(\d+)'(?:, GTID of the last change '([^']*)')?`)
+
+// Xtrabackup can be used to take hot physical backups with Percona XtraBackup
+// and to restore them
+type Xtrabackup struct {
+	Exec        string
+	StreamExec  string
+	Credentials Credentials
+	Datadir     string
+}
+
+// NewXtrabackup instanciate a physical backup interface with XtraBackup
+func NewXtrabackup(credentials Credentials) *Xtrabackup {
+	return &Xtrabackup{
+		Exec:        "xtrabackup",
+		StreamExec:  "xbstream",
+		Credentials: credentials,
+		Datadir:     defaultDatadir,
+	}
+}
+
+// Run runs a physical backup and store it as the filename
+func (m *Xtrabackup) Run(filename string, request *openapi.BackupRequest) error {
+	return writeFile(filename, func(w io.Writer) error {
+		_, err := m.Stream(w, request)
+		return err
+	})
+}
+
+// Stream runs a physical backup of the server and writes it to w in the
+// xbstream format, compressed with the codec. The server keeps accepting
+// writes while the backup runs. It returns the coordinates recorded by
+// XtraBackup in the metadata of the backup
+func (m *Xtrabackup) Stream(w io.Writer, request *openapi.BackupRequest) (backend.Coordinates, error) {
+	cw, err := compress.NewWriter(w, request.Compression)
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	// XtraBackup requires a target directory for its temporary files
+	target, err := ioutil.TempDir("", "xtrabackup")
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	defer os.RemoveAll(target)
+	cmd := exec.Command(
+		m.Exec,
+		"--backup",
+		"--stream=xbstream",
+		fmt.Sprintf("--target-dir=%s", target),
+		fmt.Sprintf("--extra-lsndir=%s", target),
+		fmt.Sprintf("--datadir=%s", m.Datadir),
+		"--host=127.0.0.1",
+	)
+	setCredentials(cmd, m.Credentials)
+	cmd.Stdout = cw
+	if err := cmd.Run(); err != nil {
+		cw.Close()
+		return backend.Coordinates{}, err
+	}
+	if err := cw.Close(); err != nil {
+		return backend.Coordinates{}, err
+	}
+	info, err := ioutil.ReadFile(filepath.Join(target, xtrabackupInfo))
+	if os.IsNotExist(err) {
+		return backend.Coordinates{}, nil
+	}
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	return xtrabackupCoordinates(string(info)), nil
+}
+
+// xtrabackupCoordinates returns the coordinates of the binlog_pos line of the
+// metadata, the GTID set spans several lines when the server has several
+// UUIDs
+func xtrabackupCoordinates(info string) backend.Coordinates {
+	match := binlogPos.FindStringSubmatch(info)
+	if match == nil {
+		return backend.Coordinates{}
+	}
+	position, _ := strconv.ParseInt(match[2], 10, 64)
+	return backend.Coordinates{
+		GTIDExecuted:   strings.ReplaceAll(match[3], "\n", ""),
+		BinlogFile:     match[1],
+		BinlogPosition: position,
+	}
+}
+
+// CopyBack extracts the backup in a staging directory of the data volume,
+// prepares it and moves the files to the data directory. The server must be
+// stopped
+func (m *Xtrabackup) CopyBack(r io.Reader, datadir string) error {
+	staging := filepath.Join(datadir, stagingDir)
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(staging, 0750); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	extract := exec.Command(m.StreamExec, "-x", "-C", staging)
+	extract.Stdin = r
+	if err := extract.Run(); err != nil {
+		return fmt.Errorf("extract: %v", err)
+	}
+	prepare := exec.Command(m.Exec, "--prepare", fmt.Sprintf("--target-dir=%s", staging))
+	if err := prepare.Run(); err != nil {
+		return fmt.Errorf("prepare: %v", err)
+	}
+	// the staging directory is part of the data directory
+	moveBack := exec.Command(
+		m.Exec,
+		"--move-back",
+		"--force-non-empty-directories",
+		fmt.Sprintf("--target-dir=%s", staging),
+		fmt.Sprintf("--datadir=%s", datadir),
+	)
+	if err := moveBack.Run(); err != nil {
+		return fmt.Errorf("move-back: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type XtrabackupSuite struct {
+	suite.Suite
+	xtrabackup *Xtrabackup
+}
+
+func (s *XtrabackupSuite) SetupTest() {
+	s.xtrabackup = NewXtrabackup(nil)
+}
+
+func (s *XtrabackupSuite) TestStream() {
+	s.xtrabackup.Exec = "echo"
+	buffer := &bytes.Buffer{}
+	coordinates, err := s.xtrabackup.Stream(buffer, &openapi.BackupRequest{Compression: compress.None})
+	require.NoError(s.T(), err)
+	require.Regexp(s.T(), "^--backup --stream=xbstream --target-dir=(.*) --extra-lsndir=.* --datadir=/var/lib/mysql --host=127.0.0.1\n$", buffer.String())
+	require.Equal(s.T(), backend.Coordinates{}, coordinates)
+}
+
+func (s *XtrabackupSuite) TestCoordinates() {
+	info := "uuid = 5a9c0b4e-8c6b-11eb-9a1f-0242ac110002\n" +
+		"binlog_pos = filename 'binlog.000003', position '156', GTID of the last change '3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5,\n" +
+		"8e11fa47-71ca-11e1-9e33-c80aa9429562:1-2'\n" +
+		"innodb_from_lsn = 0\n"
+	require.Equal(s.T(), backend.Coordinates{
+		GTIDExecuted:   "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5,8e11fa47-71ca-11e1-9e33-c80aa9429562:1-2",
+		BinlogFile:     "binlog.000003",
+		BinlogPosition: 156,
+	}, xtrabackupCoordinates(info))
+	require.Equal(s.T(), backend.Coordinates{BinlogFile: "binlog.000003", BinlogPosition: 156},
+		xtrabackupCoordinates("binlog_pos = filename 'binlog.000003', position '156'\n"))
+	require.Equal(s.T(), backend.Coordinates{}, xtrabackupCoordinates("innodb_from_lsn = 0\n"))
+}
+
+func (s *XtrabackupSuite) TestFailedStream() {
+	s.xtrabackup.Exec = "false"
+	_, err := s.xtrabackup.Stream(ioutil.Discard, &openapi.BackupRequest{Compression: compress.None})
+	require.Error(s.T(), err)
+	require.Equal(s.T(), "exit status 1", err.Error())
+}
+
+func (s *XtrabackupSuite) TestCopyBack() {
+	datadir, err := ioutil.TempDir("", "datadir")
+	require.NoError(s.T(), err)
+	defer os.RemoveAll(datadir)
+	s.xtrabackup.Exec = "true"
+	s.xtrabackup.StreamExec = "true"
+	err = s.xtrabackup.CopyBack(strings.NewReader("xbstream"), datadir)
+	require.NoError(s.T(), err)
+	_, err = os.Stat(filepath.Join(datadir, stagingDir))
+	require.True(s.T(), os.IsNotExist(err))
+
+	s.xtrabackup.Exec = "false"
+	err = s.xtrabackup.CopyBack(strings.NewReader("xbstream"), datadir)
+	require.Equal(s.T(), "prepare: exit status 1", err.Error())
+}
+
+func TestXtrabackupSuite(t *testing.T) {
+	suite.Run(t, &XtrabackupSuite{})
+}
@@ -1,15 +1,20 @@
 package s3
 
 import (
-	"bytes"
+	"bufio"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 )
 
@@ -22,49 +27,98 @@ func NewStorage() *Storage {
 type Storage struct {
 }
 
-// Push pushes a file to S3
-func (s *Storage) Push(request *openapi.BackupRequest, filename string) error {
+// partSize is the size of the parts of the multipart uploads. With at most
+// 10000 parts, it allows objects up to 160GiB
+const partSize = 16 * 1024 * 1024
+
+// newSession creates a session with the credentials of the request
+// environment variables and its S3 options, they configure the S3 compatible
+// stores. The process environment is left unchanged so that concurrent
+// requests on stores with different credentials do not mix them up; without
+// keys in the request, the session uses the default credential chain.
+func newSession(request *openapi.BackupRequest) (*session.Session, error) {
+	envs := map[string]string{}
 	for _, v := range request.Envs {
-		os.Setenv(v.Name, v.Value)
+		envs[v.Name] = v.Value
 	}
-	sess, err := session.NewSession()
-	if err != nil {
-		log.Printf("Could not open session, error: %v", err)
-		return err
+	options := session.Options{}
+	if envs["AWS_ACCESS_KEY_ID"] != "" {
+		options.Config.Credentials = credentials.NewStaticCredentials(
+			envs["AWS_ACCESS_KEY_ID"],
+			envs["AWS_SECRET_ACCESS_KEY"],
+			envs["AWS_SESSION_TOKEN"],
+		)
 	}
+	if envs["AWS_REGION"] != "" {
+		options.Config.Region = aws.String(envs["AWS_REGION"])
+	}
+	if o := request.S3; o != nil {
+		if o.Endpoint != "" {
+			options.Config.Endpoint = aws.String(o.Endpoint)
+		}
+		if o.Region != "" {
+			options.Config.Region = aws.String(o.Region)
+		}
+		options.Config.S3ForcePathStyle = aws.Bool(o.ForcePathStyle)
+		if o.CaBundle != "" {
+			options.CustomCABundle = strings.NewReader(o.CaBundle)
+		}
+	}
+	return session.NewSessionWithOptions(options)
+}
+
+// Push pushes a file to S3
+func (s *Storage) Push(request *openapi.BackupRequest, filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		log.Printf("Could not open file %s, error: %v", filename, err)
 		return err
 	}
 	defer file.Close()
+	return s.PushStream(request, file)
+}
 
-	fileInfo, _ := file.Stat()
-	var size int64 = fileInfo.Size()
-	buffer := make([]byte, size)
-	file.Read(buffer)
+// PushStream uploads a stream to S3. Objects larger than a part are sent
+// with a multipart upload so that the stream is never buffered entirely
+func (s *Storage) PushStream(request *openapi.BackupRequest, r io.Reader) error {
+	sess, err := newSession(request)
+	if err != nil {
+		log.Printf("Could not open session, error: %v", err)
+		return err
+	}
+	buffered := bufio.NewReader(r)
+	contentType := compress.ContentType(request.Compression)
+	if contentType == "" {
+		head, _ := buffered.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+	var metadata map[string]*string
+	if request.Encryption != nil {
+		contentType = "application/octet-stream"
+		metadata = map[string]*string{encrypt.MetadataKeyID: aws.String(request.Encryption.KeyId)}
+	}
 
-	_, err = s3.New(sess).PutObject(&s3.PutObjectInput{
+	uploader := s3manager.NewUploader(sess, func(u *s3manager.Uploader) {
+		u.PartSize = partSize
+	})
+	_, err = uploader.Upload(&s3manager.UploadInput{
 		Bucket:             aws.String(request.Bucket),
 		Key:                aws.String(request.Location),
 		ACL:                aws.String("private"),
-		Body:               bytes.NewReader(buffer),
-		ContentLength:      aws.Int64(size),
-		ContentType:        aws.String(http.DetectContentType(buffer)),
+		Body:               buffered,
+		ContentType:        aws.String(contentType),
 		ContentDisposition: aws.String("attachment"),
+		Metadata:           metadata,
 	})
 	if err != nil {
-		log.Printf("Error pushing %s to %s:%s, error: %v", filename, request.Bucket, request.Location, err)
+		log.Printf("Error pushing to %s:%s, error: %v", request.Bucket, request.Location, err)
 	}
 	return err
 }
 
 // Pull pull a file from S3, using a different location if necessary
 func (s *Storage) Pull(request *openapi.BackupRequest, filename string) error {
-	for _, v := range request.Envs {
-		os.Setenv(v.Name, v.Value)
-	}
-	sess, err := session.NewSession()
+	sess, err := newSession(request)
 	if err != nil {
 		return err
 	}
@@ -83,22 +137,56 @@ func (s *Storage) Pull(request *openapi.BackupRequest, filename string) error {
 	return err
 }
 
-// Delete deletes a file from S3
-func (s *Storage) Delete(request *openapi.BackupRequest) error {
-	for _, v := range request.Envs {
-		os.Setenv(v.Name, v.Value)
+// PullStream copies an object from S3 to a stream
+func (s *Storage) PullStream(request *openapi.BackupRequest, w io.Writer) error {
+	sess, err := newSession(request)
+	if err != nil {
+		return err
 	}
-	sess, err := session.NewSession()
+	output, err := s3.New(sess).GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(request.Bucket),
+		Key:    aws.String(request.Location),
+	})
 	if err != nil {
 		return err
 	}
-	objectsToDelete := []*s3.ObjectIdentifier{
-		{Key: aws.String(request.Location)},
+	defer output.Body.Close()
+	_, err = io.Copy(w, output.Body)
+	return err
+}
+
+// Delete deletes a file from S3
+func (s *Storage) Delete(request *openapi.BackupRequest) error {
+	sess, err := newSession(request)
+	if err != nil {
+		return err
 	}
-	deleteArray := s3.Delete{Objects: objectsToDelete}
-	_, err = s3.New(sess).DeleteObjects(&s3.DeleteObjectsInput{
+	_, err = s3.New(sess).DeleteObject(&s3.DeleteObjectInput{
 		Bucket: aws.String(request.Bucket),
-		Delete: &deleteArray,
+		Key:    aws.String(request.Location),
 	})
 	return err
 }
+
+// List returns the keys of the bucket that start with the prefix, the keys
+// are the locations of the objects
+func (s *Storage) List(request *openapi.BackupRequest, prefix string) ([]string, error) {
+	sess, err := newSession(request)
+	if err != nil {
+		return nil, err
+	}
+	locations := []string{}
+	err = s3.New(sess).ListObjectsV2Pages(
+		&s3.ListObjectsV2Input{
+			Bucket: aws.String(request.Bucket),
+			Prefix: aws.String(prefix),
+		},
+		func(page *s3.ListObjectsV2Output, last bool) bool {
+			for _, object := range page.Contents {
+				locations = append(locations, aws.StringValue(object.Key))
+			}
+			return true
+		},
+	)
+	return locations, err
+}
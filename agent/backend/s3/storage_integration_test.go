@@ -3,6 +3,7 @@
 package s3
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -98,6 +99,46 @@ func (s *StorageSuite) TestS3Success() {
 
 }
 
+func (s *StorageSuite) TestS3Stream() {
+	_ = godotenv.Load()
+
+	b := openapi.BackupRequest{
+		Bucket:   os.Getenv("BACKUP_BUCKET"),
+		Location: os.Getenv("BACKUP_LOCATION"),
+		Envs: []openapi.EnvVar{
+			{
+				Name:  "AWS_ACCESS_KEY_ID",
+				Value: os.Getenv("BACKUP_AWS_ACCESS_KEY_ID"),
+			},
+			{
+				Name:  "AWS_SECRET_ACCESS_KEY",
+				Value: os.Getenv("BACKUP_AWS_SECRET_ACCESS_KEY"),
+			},
+			{
+				Name:  "AWS_REGION",
+				Value: os.Getenv("BACKUP_AWS_REGION"),
+			},
+		},
+	}
+
+	// the object is larger than a part to use a multipart upload
+	content := bytes.Repeat([]byte("0123456789abcdef"), partSize/8)
+	err := s.Storage.PushStream(&b, bytes.NewReader(content))
+	assert.NoError(s.T(), err, "No Error")
+
+	buffer := &bytes.Buffer{}
+	err = s.Storage.PullStream(&b, buffer)
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), content, buffer.Bytes())
+
+	locations, err := s.Storage.List(&b, b.Location)
+	assert.NoError(s.T(), err, "No Error")
+	assert.Contains(s.T(), locations, b.Location)
+
+	err = s.Storage.Delete(&b)
+	assert.NoError(s.T(), err, "No Error")
+}
+
 func (s *StorageSuite) TestFailed() {
 	_ = godotenv.Load()
 
@@ -0,0 +1,141 @@
+// +build integration
+
+package s3
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+const (
+	// the default credentials and endpoint of a local MinIO server
+	minioEndpoint  = "http://127.0.0.1:9000"
+	minioAccessKey = "minioadmin"
+	minioSecretKey = "minioadmin"
+)
+
+type CompatibleSuite struct {
+	suite.Suite
+	Storage *Storage
+}
+
+func getenv(key, value string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return value
+}
+
+func compatibleRequest(bucket string) openapi.BackupRequest {
+	return openapi.BackupRequest{
+		Bucket:   bucket,
+		Location: getenv("BACKUP_LOCATION", "backups/demo.txt"),
+		Envs: []openapi.EnvVar{
+			{
+				Name:  "AWS_ACCESS_KEY_ID",
+				Value: getenv("BACKUP_S3_ACCESS_KEY_ID", minioAccessKey),
+			},
+			{
+				Name:  "AWS_SECRET_ACCESS_KEY",
+				Value: getenv("BACKUP_S3_SECRET_ACCESS_KEY", minioSecretKey),
+			},
+		},
+		S3: &openapi.S3Options{
+			Endpoint:       getenv("BACKUP_S3_ENDPOINT", minioEndpoint),
+			Region:         getenv("BACKUP_S3_REGION", "us-east-1"),
+			ForcePathStyle: true,
+		},
+	}
+}
+
+func (s *CompatibleSuite) SetupSuite() {
+	_ = godotenv.Load()
+	s.Storage = NewStorage()
+
+	b := compatibleRequest(getenv("BACKUP_S3_BUCKET", "backups"))
+	sess, err := newSession(&b)
+	if err != nil {
+		s.T().Fatalf("Cannot create session, error: %v", err)
+	}
+	_, err = s3.New(sess).CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(b.Bucket)})
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case s3.ErrCodeBucketAlreadyExists, s3.ErrCodeBucketAlreadyOwnedByYou:
+			err = nil
+		}
+	}
+	if err != nil {
+		s.T().Fatalf("Cannot create bucket %s, error: %v", b.Bucket, err)
+	}
+}
+
+func (s *CompatibleSuite) TestCompatibleSuccess() {
+	b := compatibleRequest(getenv("BACKUP_S3_BUCKET", "backups"))
+
+	err := initFile("test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = s.Storage.Push(&b, "test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = deleteFile("test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = s.Storage.Pull(&b, "test2.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	locations, err := s.Storage.List(&b, "backups/")
+	assert.NoError(s.T(), err, "No Error")
+	assert.Contains(s.T(), locations, b.Location)
+
+	err = s.Storage.Delete(&b)
+	assert.NoError(s.T(), err, "No Error")
+
+	err = validFile("test2.txt", "test.txt")
+	assert.NoError(s.T(), err, "No Error")
+
+	err = deleteFile("test2.txt")
+	assert.NoError(s.T(), err, "No Error")
+}
+
+func (s *CompatibleSuite) TestCompatibleStream() {
+	b := compatibleRequest(getenv("BACKUP_S3_BUCKET", "backups"))
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), partSize/8)
+	err := s.Storage.PushStream(&b, bytes.NewReader(content))
+	assert.NoError(s.T(), err, "No Error")
+
+	buffer := &bytes.Buffer{}
+	err = s.Storage.PullStream(&b, buffer)
+	assert.NoError(s.T(), err, "No Error")
+	assert.Equal(s.T(), content, buffer.Bytes())
+
+	err = s.Storage.Delete(&b)
+	assert.NoError(s.T(), err, "No Error")
+}
+
+func (s *CompatibleSuite) TestCompatibleFailed() {
+	b := compatibleRequest("missing")
+
+	err := s.Storage.PushStream(&b, bytes.NewReader([]byte("test")))
+	assert.Error(s.T(), err, "Error")
+	assert.Regexp(s.T(), "NoSuchBucket.*", err.Error(), "NoSuchBucket")
+
+	b.S3.CaBundle = "not a certificate"
+	err = s.Storage.PushStream(&b, bytes.NewReader([]byte("test")))
+	assert.Error(s.T(), err, "Error")
+}
+
+func TestCompatibleSuite(t *testing.T) {
+	suite.Run(t, &CompatibleSuite{})
+}
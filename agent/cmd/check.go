@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+
+	"github.com/spf13/cobra"
+)
+
+// checkLocation is the object written to check the access to a store
+const checkLocation = "/blaqkube/.mysql-agent.check"
+
+// checkStore writes an object to a store, reads it back and deletes it
+func checkStore(storage, bucket string) error {
+	s, ok := resources.Storages[storage]
+	if !ok {
+		return fmt.Errorf("unknown backend %s", storage)
+	}
+	content := []byte("[blaqkube]")
+	file, err := ioutil.TempFile("", "check")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	request := &openapi.BackupRequest{Backend: storage, Bucket: bucket, Location: checkLocation}
+	if err := s.Push(request, file.Name()); err != nil {
+		return fmt.Errorf("cannot write to %s:%s: %v", bucket, checkLocation, err)
+	}
+	buffer := &bytes.Buffer{}
+	if err := s.PullStream(request, buffer); err != nil {
+		return fmt.Errorf("cannot read %s:%s: %v", bucket, checkLocation, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), content) {
+		return fmt.Errorf("%s:%s has been read back with a different content", bucket, checkLocation)
+	}
+	if err := s.Delete(request); err != nil {
+		return fmt.Errorf("cannot delete %s:%s: %v", bucket, checkLocation, err)
+	}
+	return nil
+}
+
+// checkCmd checks the access to a store, it is run by the operator for the
+// stores it cannot access itself, like the filesystem stores
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "check the access to a store",
+	Long:  `check writes a file to a store, reads it back and deletes it`,
+	Run: func(cmd *cobra.Command, args []string) {
+		storage, bucket := storeFlags(cmd)
+		if err := checkStore(storage, bucket); err != nil {
+			log.Printf("Store check failed, error: %v", err)
+			os.Exit(1)
+		}
+		log.Printf("Store %s:%s checked with success", storage, bucket)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().StringP("bucket", "b", "", "bucket to check")
+	checkCmd.Flags().StringP("type", "t", "", "type of backend (s3, gcp, azure, filesystem, blackhole)")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/filesystem"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "stores")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	resources = &Backend{
+		Storages: map[string]backend.Storage{"filesystem": filesystem.NewStorage(root)},
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "backups"), 0755))
+
+	err = checkStore("filesystem", "backups")
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(root, "backups", "blaqkube", ".mysql-agent.check"))
+	assert.True(t, os.IsNotExist(err), "the check file is deleted")
+
+	err = checkStore("s3", "backups")
+	assert.Error(t, err)
+
+}
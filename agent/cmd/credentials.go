@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/spf13/viper"
+
+	"github.com/blaqkube/mysql-operator/agent/backend/mysql"
+	"github.com/blaqkube/mysql-operator/agent/service/credential"
+)
+
+const (
+	// defaultUsername is used when no agent account is configured
+	defaultUsername = "root"
+
+	// errAccessDenied is ER_ACCESS_DENIED_ERROR
+	errAccessDenied = 1045
+)
+
+// credentials keeps the account the agent connects with. They are loaded
+// from viper when the configuration is read or changes
+type credentials struct {
+	sync.RWMutex
+	username string
+	password string
+}
+
+var agentCredentials = &credentials{}
+
+// load reads agent_username and agent_password
+func (c *credentials) load() {
+	c.Lock()
+	defer c.Unlock()
+	c.username = viper.GetString("agent_username")
+	c.password = viper.GetString("agent_password")
+	if c.username == "" {
+		c.username = defaultUsername
+	}
+}
+
+// Credentials returns the account the agent connects to MySQL with
+func Credentials() (string, string) {
+	agentCredentials.RLock()
+	defer agentCredentials.RUnlock()
+	return agentCredentials.username, agentCredentials.password
+}
+
+// bootstrapAgentUser creates the agent account on an instance that has been
+// initialized without it, and sets the root password when root still has an
+// empty one. It connects as root without password, like the agent did before
+// it had its own account, and runs the bootstrap file and the ALTER USER
+// statements without logging them to the binlog, so that every server of the
+// instance does it and the replicas do not get errant transactions
+func bootstrapAgentUser(filename, rootPasswordFile string) error {
+	if filename == "" && rootPasswordFile == "" {
+		return nil
+	}
+	var mysqlErr *gomysql.MySQLError
+	agentErr := errors.New("server not available")
+	for i := 0; i < numberOfDBChecks; i++ {
+		agentErr = resources.DB.Ping()
+		if agentErr == nil || errors.As(agentErr, &mysqlErr) {
+			break
+		}
+		time.Sleep(mysql.DefaultDelay)
+	}
+	if agentErr != nil && mysqlErr == nil {
+		return agentErr
+	}
+	db, err := sql.Open("mysql", "root@tcp(127.0.0.1:3306)/?multiStatements=true")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		if agentErr != nil {
+			return err
+		}
+		// root has a password, there is nothing to bootstrap
+		return nil
+	}
+	defer conn.Close()
+
+	statements := []string{}
+	if agentErr != nil && mysqlErr.Number == errAccessDenied && filename != "" {
+		log.Printf("Agent cannot connect, bootstrapping the account from %s", filename)
+		init, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		statements = append(statements, string(init))
+	}
+	if rootPasswordFile != "" {
+		password, err := ioutil.ReadFile(rootPasswordFile)
+		if err != nil {
+			return err
+		}
+		hosts, err := emptyPasswordHosts(ctx, conn, defaultUsername)
+		if err != nil {
+			return err
+		}
+		statements = append(statements, setPasswordStatements(defaultUsername, hosts, strings.TrimSpace(string(password)))...)
+	}
+	if len(statements) == 0 {
+		return nil
+	}
+	var superReadOnly bool
+	if err := conn.QueryRowContext(ctx, "SELECT @@GLOBAL.super_read_only").Scan(&superReadOnly); err != nil {
+		return err
+	}
+	statements = append([]string{"SET SESSION sql_log_bin = 0"}, statements...)
+	if superReadOnly {
+		statements = append([]string{"SET GLOBAL super_read_only = OFF"}, statements...)
+		defer conn.ExecContext(ctx, "SET GLOBAL super_read_only = ON")
+	}
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			log.Printf("Error bootstrapping the accounts: %v", err)
+			return err
+		}
+	}
+	log.Printf("Accounts bootstrapped")
+	return nil
+}
+
+// emptyPasswordHosts returns the hosts of the accounts of a user that do not
+// have a password
+func emptyPasswordHosts(ctx context.Context, conn *sql.Conn, username string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT host FROM mysql.user WHERE user = ? AND authentication_string = ''", username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hosts := []string{}
+	for rows.Next() {
+		var host string
+		if err := rows.Scan(&host); err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, rows.Err()
+}
+
+// setPasswordStatements returns the statements that set the password of the
+// accounts of a user
+func setPasswordStatements(username string, hosts []string, password string) []string {
+	statements := []string{}
+	for _, host := range hosts {
+		statements = append(statements, fmt.Sprintf("ALTER USER %s@%s IDENTIFIED BY %s", credential.Quote(username), credential.Quote(host), credential.Quote(password)))
+	}
+	return statements
+}
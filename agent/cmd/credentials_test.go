@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Credentials(t *testing.T) {
+	viper.Set("agent_username", "")
+	viper.Set("agent_password", "")
+	agentCredentials.load()
+	username, password := Credentials()
+	assert.Equal(t, "root", username, "Should default to root")
+	assert.Equal(t, "", password, "Should not have a password")
+
+	viper.Set("agent_username", "agent")
+	viper.Set("agent_password", "changeme")
+	agentCredentials.load()
+	username, password = Credentials()
+	assert.Equal(t, "agent", username, "Should use the agent account")
+	assert.Equal(t, "changeme", password, "Should use the agent password")
+}
+
+func Test_BootstrapWithoutFile(t *testing.T) {
+	assert.NoError(t, bootstrapAgentUser("", ""), "Should skip the bootstrap")
+}
+
+func Test_SetPasswordStatements(t *testing.T) {
+	statements := setPasswordStatements("root", []string{"%", "localhost"}, "it's")
+	assert.Equal(t, []string{
+		`ALTER USER 'root'@'%' IDENTIFIED BY 'it\'s'`,
+		`ALTER USER 'root'@'localhost' IDENTIFIED BY 'it\'s'`,
+	}, statements)
+	assert.Empty(t, setPasswordStatements("root", []string{}, "changeme"), "Should not change a password")
+}
@@ -2,22 +2,176 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	"github.com/blaqkube/mysql-operator/agent/backend/manifest"
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/blaqkube/mysql-operator/agent/service/binlog"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+const (
+	// binlogDir is where the archived binary logs are downloaded, the MySQL
+	// entrypoint does not read subdirectories
+	binlogDir = "blaqkube-binlog"
+
+	// pitrScript is the script that replays the binary logs, it is sorted
+	// after the dump by the MySQL entrypoint
+	pitrScript = "zz-blaqkube-pitr.sql"
+)
+
+// s3Options returns the options of a S3 compatible store, they are set with
+// the AGT_S3_* variables of the init container
+func s3Options() *openapi.S3Options {
+	options := &openapi.S3Options{
+		Endpoint:       viper.GetString("s3_endpoint"),
+		Region:         viper.GetString("s3_region"),
+		ForcePathStyle: viper.GetBool("s3_force_path_style"),
+		CaBundle:       viper.GetString("s3_ca_bundle"),
+	}
+	if *options == (openapi.S3Options{}) {
+		return nil
+	}
+	return options
+}
+
+// restoreBinlogs pulls the binary logs archived before the target, decrypts
+// them and converts them into a script the MySQL entrypoint runs after the
+// dump
+func restoreBinlogs(request openapi.BackupRequest, stopDatetime, includeGTIDs string, keys map[string][]byte, allowUnencrypted bool) error {
+	stop := time.Time{}
+	if stopDatetime != "" {
+		t, err := time.Parse(time.RFC3339, stopDatetime)
+		if err != nil {
+			return err
+		}
+		stop = t.UTC()
+	}
+	if err := os.MkdirAll(binlogDir, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(binlogDir)
+	storage := resources.Storages[request.Backend]
+	indexRequest := request
+	indexRequest.Location = binlog.IndexLocation(request.Location)
+	localIndex := filepath.Join(binlogDir, "index.json")
+	if err := binlog.Pull(storage, &indexRequest, localIndex, keys, allowUnencrypted); err != nil {
+		return err
+	}
+	index, err := binlog.ReadIndex(localIndex)
+	if err != nil {
+		return err
+	}
+	files := []string{}
+	for _, entry := range index.Select(stop) {
+		binlogRequest := request
+		binlogRequest.Location = entry.Location
+		localfile := filepath.Join(binlogDir, entry.ServerUUID+"-"+entry.Name)
+		if err := binlog.Pull(storage, &binlogRequest, localfile, keys, allowUnencrypted); err != nil {
+			return err
+		}
+		files = append(files, localfile)
+	}
+	if len(files) == 0 {
+		log.Printf("No binary log archived in %s", request.Location)
+		return nil
+	}
+	datetime := ""
+	if !stop.IsZero() {
+		datetime = stop.Format("2006-01-02 15:04:05")
+	}
+	log.Printf("Replay %d binary logs from %s", len(files), request.Location)
+	return resources.Binlog.Extract(files, datetime, includeGTIDs, pitrScript)
+}
+
+// restorePhysical streams a physical backup from the store, decrypts and
+// decompresses it and restores it in the data directory. The MySQL entrypoint
+// does not initialize a data directory that is not empty
+func restorePhysical(request *openapi.BackupRequest, method, datadir string, keys map[string][]byte, allowUnencrypted bool) error {
+	_, err := os.Stat(filepath.Join(datadir, "mysql.ibd"))
+	if err == nil {
+		log.Printf("data directory %s already restored", datadir)
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	physical, ok := resources.Backups[method].(backend.Physical)
+	if !ok {
+		return fmt.Errorf("method %s is not a physical backup", method)
+	}
+	storage := resources.Storages[request.Backend]
+	m := manifest.Lookup(storage, request)
+	digest := manifest.NewDigest()
+	pr, pw := io.Pipe()
+	// unblocks the pull when the restore stops reading
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(storage.PullStream(request, pw))
+	}()
+	tee := io.TeeReader(pr, digest)
+	r, keyID, err := encrypt.NewReader(tee, keys, allowUnencrypted)
+	if err != nil {
+		return fmt.Errorf("decrypting with key %q: %v", keyID, err)
+	}
+	reader, codec, err := compress.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err := physical.CopyBack(reader, datadir); err != nil {
+		return err
+	}
+	if m != nil {
+		// the checksum is computed on the whole object
+		if _, err := io.Copy(ioutil.Discard, tee); err != nil {
+			return err
+		}
+		if err := digest.Check(m); err != nil {
+			// the data directory is restored again on the next start
+			if err := emptyDir(datadir); err != nil {
+				log.Printf("error cleaning %s: %v", datadir, err)
+			}
+			return fmt.Errorf("checksum of %s failed: %v", request.Location, err)
+		}
+	}
+	log.Printf("Backup %s restored in %s with success, method: %s, compression: %s, key: %q", request.Location, datadir, method, codec, keyID)
+	return nil
+}
+
+// emptyDir removes the content of a directory
+func emptyDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.RemoveAll(filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "initialization steps and stops",
 	Long: `initialization consists in
-   - recovery a backup when specified
+   - recovery a backup when specified, a physical backup is restored in the
+     data directory
+   - replay the archived binary logs up to a time or a GTID set
    - create a user for the api commands`,
 	Run: func(cmd *cobra.Command, args []string) {
 		workdir, err := cmd.Flags().GetString("workdir")
@@ -61,7 +215,51 @@ var initCmd = &cobra.Command{
 			fmt.Printf("Missing LOCATION, value: %s", location)
 			os.Exit(1)
 		}
-		fpath := strings.Split(location, string(os.PathSeparator))
+		keysDir, err := cmd.Flags().GetString("keys-dir")
+		if err != nil || keysDir == "" {
+			keysDir = viper.GetString("keys_dir")
+		}
+		keys := map[string][]byte{}
+		if keysDir != "" {
+			keys, err = encrypt.LoadKeys(keysDir)
+			if err != nil {
+				log.Printf("error loading keys from %s: %v", keysDir, err)
+				os.Exit(1)
+			}
+		}
+		allowUnencrypted, _ := cmd.Flags().GetBool("allow-unencrypted")
+		if !allowUnencrypted {
+			allowUnencrypted = viper.GetBool("allow_unencrypted")
+		}
+		method, err := cmd.Flags().GetString("method")
+		if err != nil || method == "" {
+			method = viper.GetString("method")
+		}
+		if method != "" && method != backend.MethodMysqldump {
+			datadir, err := cmd.Flags().GetString("datadir")
+			if err != nil || datadir == "" {
+				datadir = viper.GetString("datadir")
+			}
+			if datadir == "" {
+				datadir = "/var/lib/mysql"
+			}
+			payload := &openapi.BackupRequest{
+				Backend:  storage,
+				Bucket:   bucket,
+				Location: location,
+				S3:       s3Options(),
+			}
+			if err := restorePhysical(payload, method, datadir, keys, allowUnencrypted); err != nil {
+				log.Printf("error restoring %s backup %s: %v", method, location, err)
+				os.Exit(1)
+			}
+			if viper.GetString("binlog_location") != "" {
+				log.Printf("binary logs are not replayed after a %s backup", method)
+			}
+			return
+		}
+		// a compressed dump is unpacked so that the server loads it
+		fpath := strings.Split(compress.Trim(location), string(os.PathSeparator))
 		localfile := fpath[len(fpath)-1]
 		_, err = os.Stat(localfile)
 		if err == nil {
@@ -76,13 +274,61 @@ var initCmd = &cobra.Command{
 			Backend:  storage,
 			Bucket:   bucket,
 			Location: location,
+			S3:       s3Options(),
 		}
-		err = resources.Storages[payload.Backend].Pull(payload, localfile)
+		download := fmt.Sprintf("%s.download", localfile)
+		err = resources.Storages[payload.Backend].Pull(payload, download)
 		if err != nil {
 			log.Printf("error pulling %s: %v", localfile, err)
+			os.Remove(download)
+			os.Exit(1)
+		}
+		if err := manifest.Verify(resources.Storages[payload.Backend], payload, download); err != nil {
+			log.Printf("error verifying %s: %v", localfile, err)
+			os.Remove(download)
+			os.Exit(1)
+		}
+		packed := fmt.Sprintf("%s.packed", localfile)
+		keyID, err := encrypt.Unseal(download, packed, keys, allowUnencrypted)
+		if err != nil {
+			log.Printf("error decrypting %s with key %q: %v", localfile, keyID, err)
+			os.Remove(download)
+			os.Remove(packed)
+			os.Exit(1)
+		}
+		codec, err := compress.Unpack(packed, localfile)
+		if err != nil {
+			log.Printf("error unpacking %s: %v", localfile, err)
+			os.Remove(packed)
+			os.Remove(localfile)
+			os.Exit(1)
+		}
+		log.Printf("File %s loaded in %s with success, compression: %s, key: %q", localfile, workdir, codec, keyID)
+
+		binlogLocation, err := cmd.Flags().GetString("binlog-location")
+		if err != nil || binlogLocation == "" {
+			binlogLocation = viper.GetString("binlog_location")
+		}
+		if binlogLocation == "" {
+			return
+		}
+		stopDatetime, err := cmd.Flags().GetString("stop-datetime")
+		if err != nil || stopDatetime == "" {
+			stopDatetime = viper.GetString("stop_datetime")
+		}
+		includeGTIDs, err := cmd.Flags().GetString("include-gtids")
+		if err != nil || includeGTIDs == "" {
+			includeGTIDs = viper.GetString("include_gtids")
+		}
+		payload.Location = binlogLocation
+		if err := restoreBinlogs(*payload, stopDatetime, includeGTIDs, keys, allowUnencrypted); err != nil {
+			log.Printf("error restoring binary logs from %s: %v", binlogLocation, err)
+			// the dump is pulled again with the binary logs on the next start
+			os.Remove(localfile)
+			os.Remove(pitrScript)
 			os.Exit(1)
 		}
-		log.Printf("File %s loaded in %s with success", localfile, workdir)
+		log.Printf("Binary logs from %s replayed in %s with success", binlogLocation, pitrScript)
 		return
 	},
 }
@@ -93,5 +339,12 @@ func init() {
 	initCmd.Flags().StringP("location", "l", "", "file location on bucket")
 	initCmd.Flags().StringP("bucket", "b", "", "dump file bucket")
 	initCmd.Flags().StringP("workdir", "w", "", "working directory")
-	initCmd.Flags().StringP("type", "t", "", "type of backend (s3, gcp, blackhole)")
+	initCmd.Flags().StringP("type", "t", "", "type of backend (s3, gcp, azure, filesystem, blackhole)")
+	initCmd.Flags().String("binlog-location", "", "archived binary logs location on bucket")
+	initCmd.Flags().String("stop-datetime", "", "replay the binary logs up to a RFC3339 time")
+	initCmd.Flags().String("include-gtids", "", "replay the transactions of a GTID set only")
+	initCmd.Flags().String("keys-dir", "", "directory with the keys that decrypt the backup")
+	initCmd.Flags().Bool("allow-unencrypted", false, "restore a backup that is not encrypted while keys are set")
+	initCmd.Flags().String("method", "", "method of the backup (mysqldump, xtrabackup, clone)")
+	initCmd.Flags().String("datadir", "", "data directory a physical backup is restored in")
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/mock"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RestorePhysical(t *testing.T) {
+	datadir, err := ioutil.TempDir("", "datadir")
+	assert.NoError(t, err)
+	defer os.RemoveAll(datadir)
+	resources = &Backend{
+		Backups: map[string]backend.Backup{
+			"mysqldump":  mock.NewBackup(),
+			"xtrabackup": mock.NewBackup(),
+		},
+		Storages: map[string]backend.Storage{"s3": mock.NewStorage()},
+	}
+	request := &openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/red.xbstream"}
+	err = restorePhysical(request, "xtrabackup", datadir, nil, false)
+	assert.NoError(t, err)
+
+	err = restorePhysical(request, "clone", datadir, nil, false)
+	assert.Error(t, err)
+
+	// a data directory that has been restored is kept
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(datadir, "mysql.ibd"), []byte{}, 0600))
+	err = restorePhysical(request, "clone", datadir, nil, false)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// deleteObjects deletes objects from a store. The objects that do not exist
+// are ignored so that a deletion that has been interrupted can be run again
+func deleteObjects(storage, bucket string, locations []string) error {
+	s, ok := resources.Storages[storage]
+	if !ok {
+		return fmt.Errorf("unknown backend %s", storage)
+	}
+	for _, location := range locations {
+		request := &openapi.BackupRequest{Backend: storage, Bucket: bucket, Location: location}
+		if err := s.Delete(request); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot delete %s:%s: %v", bucket, location, err)
+		}
+	}
+	return nil
+}
+
+// listObjects writes the locations of the objects of a store that start with
+// the prefix, one per line
+func listObjects(w io.Writer, storage, bucket, prefix string) error {
+	s, ok := resources.Storages[storage]
+	if !ok {
+		return fmt.Errorf("unknown backend %s", storage)
+	}
+	locations, err := s.List(&openapi.BackupRequest{Backend: storage, Bucket: bucket}, prefix)
+	if err != nil {
+		return fmt.Errorf("cannot list %s:%s: %v", bucket, prefix, err)
+	}
+	for _, location := range locations {
+		fmt.Fprintln(w, location)
+	}
+	return nil
+}
+
+// storeFlags returns the backend and the bucket of the store commands
+func storeFlags(cmd *cobra.Command) (string, string) {
+	storage, err := cmd.Flags().GetString("type")
+	if err != nil || storage == "" {
+		storage = viper.GetString("type")
+		if storage == "" {
+			storage = "s3"
+		}
+	}
+	bucket, err := cmd.Flags().GetString("bucket")
+	if err != nil || bucket == "" {
+		bucket = viper.GetString("bucket")
+	}
+	if bucket == "" {
+		fmt.Printf("Missing BUCKET, value: %s", bucket)
+		os.Exit(1)
+	}
+	return storage, bucket
+}
+
+// deleteCmd deletes objects from a store, it is run by the operator for the
+// stores it cannot access itself, like the filesystem stores
+var deleteCmd = &cobra.Command{
+	Use:   "delete [location]...",
+	Short: "delete objects from a store",
+	Long:  `delete removes the objects at the locations from a store`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		storage, bucket := storeFlags(cmd)
+		if err := deleteObjects(storage, bucket, args); err != nil {
+			log.Printf("Store delete failed, error: %v", err)
+			os.Exit(1)
+		}
+		log.Printf("%d object(s) deleted from %s:%s", len(args), storage, bucket)
+	},
+}
+
+// listCmd lists the objects of a store, it is run by the operator for the
+// stores it cannot access itself. The output only contains the locations so
+// that the operator can read them from the pod logs
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the objects of a store",
+	Long:  `list writes the locations of the objects of a store that start with the prefix`,
+	Run: func(cmd *cobra.Command, args []string) {
+		storage, bucket := storeFlags(cmd)
+		prefix, _ := cmd.Flags().GetString("prefix")
+		log.SetOutput(ioutil.Discard)
+		if err := listObjects(os.Stdout, storage, bucket, prefix); err != nil {
+			fmt.Fprintf(os.Stderr, "Store list failed, error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.Flags().StringP("bucket", "b", "", "bucket of the objects")
+	deleteCmd.Flags().StringP("type", "t", "", "type of backend (s3, gcp, azure, filesystem, blackhole)")
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringP("bucket", "b", "", "bucket to list")
+	listCmd.Flags().StringP("type", "t", "", "type of backend (s3, gcp, azure, filesystem, blackhole)")
+	listCmd.Flags().StringP("prefix", "p", "", "prefix of the locations")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/filesystem"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ListAndDeleteObjects(t *testing.T) {
+	root, err := ioutil.TempDir("", "stores")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	resources = &Backend{
+		Storages: map[string]backend.Storage{"filesystem": filesystem.NewStorage(root)},
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "backups", "daily"), 0755))
+	for _, name := range []string{"blue-20210301-000000.sql", "blue-20210301-000000.sql.manifest.json"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "backups", "daily", name), []byte("[blaqkube]"), 0644))
+	}
+
+	buffer := &bytes.Buffer{}
+	err = listObjects(buffer, "filesystem", "backups", "/daily")
+	assert.NoError(t, err)
+	assert.Equal(t, "/daily/blue-20210301-000000.sql\n/daily/blue-20210301-000000.sql.manifest.json\n", buffer.String())
+
+	err = deleteObjects("filesystem", "backups", []string{"/daily/blue-20210301-000000.sql", "/daily/blue-20210301-000000.sql.manifest.json"})
+	assert.NoError(t, err)
+	err = deleteObjects("filesystem", "backups", []string{"/daily/blue-20210301-000000.sql"})
+	assert.NoError(t, err, "a missing object is ignored")
+	buffer.Reset()
+	err = listObjects(buffer, "filesystem", "backups", "/daily")
+	assert.NoError(t, err)
+	assert.Equal(t, "", buffer.String())
+
+	err = listObjects(buffer, "filesystem", "missing", "/")
+	assert.Error(t, err)
+	err = deleteObjects("s3", "backups", []string{"/daily/blue.sql"})
+	assert.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 
 	"github.com/blaqkube/mysql-operator/agent/backend"
@@ -25,26 +26,35 @@ var rootCmd = &cobra.Command{
 
 // Backend is a type used to store backend resources
 type Backend struct {
-	Backup   backend.Backup
+	Backups  map[string]backend.Backup
+	Binlog   backend.Binlog
 	DB       *sql.DB
 	Instance backend.Instance
-	Storages  map[string]backend.Storage
+	Replica  backend.Replica
+	Restore  backend.Restore
+	Storages map[string]backend.Storage
 }
 
 var resources *Backend
 
 // Execute start the agent with the various attributes
 func Execute(
-	backup backend.Backup,
+	backups map[string]backend.Backup,
+	binlog backend.Binlog,
 	db *sql.DB,
 	instance backend.Instance,
+	replica backend.Replica,
+	restore backend.Restore,
 	storages map[string]backend.Storage,
 ) {
 	resources = &Backend{
-		Backup:   backup,
+		Backups:  backups,
+		Binlog:   binlog,
 		DB:       db,
 		Instance: instance,
-		Storages:  storages,
+		Replica:  replica,
+		Restore:  restore,
+		Storages: storages,
 	}
 
 	if err := rootCmd.Execute(); err != nil {
@@ -55,6 +65,7 @@ func Execute(
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.agent.yaml)")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -82,4 +93,14 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+	agentCredentials.load()
+
+	// The credentials are changed in place when they are rotated
+	if cfgFile != "" {
+		viper.OnConfigChange(func(e fsnotify.Event) {
+			fmt.Println("Config file changed:", e.Name)
+			agentCredentials.load()
+		})
+		viper.WatchConfig()
+	}
 }
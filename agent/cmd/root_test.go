@@ -12,7 +12,10 @@ import (
 func Test_ExecuteCommand(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	storages := map[string]backend.Storage{"s3": mock.NewStorage()}
-	backup := mock.NewBackup()
+	backups := map[string]backend.Backup{"mysqldump": mock.NewBackup()}
+	binlog := mock.NewBinlog()
 	instance := mock.NewInstance()
-	Execute(backup, db, instance, storages)
+	replica := mock.NewReplica()
+	restore := mock.NewRestore()
+	Execute(backups, binlog, db, instance, replica, restore, storages)
 }
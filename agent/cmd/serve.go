@@ -74,6 +74,10 @@ var serveCmd = &cobra.Command{
 			}
 		}
 
+		if err := bootstrapAgentUser(viper.GetString("bootstrap_file"), viper.GetString("root_password_file")); err != nil {
+			log.Printf("Could not bootstrap the agent account: %v", err)
+		}
+
 		expUsername := viper.GetString("exporter_username")
 		expPassword := viper.GetString("exporter_password")
 		createExporterUser(expUsername, expPassword)
@@ -81,7 +85,7 @@ var serveCmd = &cobra.Command{
 		log.Fatal(
 			http.ListenAndServe(
 				fmt.Sprintf(":%d", port),
-				openapi.NewRouter(service.NewMysqlAPIController(resources.DB, resources.Backup, resources.Storages)),
+				openapi.NewRouter(service.NewMysqlAPIController(resources.DB, resources.Backups, resources.Binlog, resources.Replica, resources.Restore, resources.Storages)),
 			),
 		)
 	},
@@ -14,10 +14,10 @@ func Test_CreateExporterUser(t *testing.T) {
 	db, sqlMock, err := sqlmock.New()
 	assert.NoError(t, err)
 	storages := map[string]backend.Storage{"s3": mock.NewStorage()}
-	backup := mock.NewBackup()
+	backups := map[string]backend.Backup{"mysqldump": mock.NewBackup()}
 	instance := mock.NewInstance()
 	resources = &Backend{
-		Backup:   backup,
+		Backups:  backups,
 		DB:       db,
 		Instance: instance,
 		Storages: storages,
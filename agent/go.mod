@@ -6,9 +6,10 @@ require (
 	cloud.google.com/go v0.78.0 // indirect
 	cloud.google.com/go/datastore v1.1.0
 	cloud.google.com/go/storage v1.14.0
+	github.com/Azure/azure-storage-blob-go v0.13.0
 	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/aws/aws-sdk-go v1.37.25
-	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/gobuffalo/packr/v2 v2.8.1
 	github.com/gorilla/mux v1.8.0
@@ -16,6 +17,7 @@ require (
 	github.com/hashicorp/golang-lru v0.5.4 // indirect
 	github.com/joho/godotenv v1.3.0
 	github.com/karrick/godirwalk v1.16.1 // indirect
+	github.com/klauspost/compress v1.13.6
 	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
 	github.com/magefile/mage v1.11.0 // indirect
 	github.com/magiconair/properties v1.8.4 // indirect
@@ -12,10 +12,18 @@ type Backup struct {
 
 	Location string `json:"location"`
 
+	// codec of the dump
+	Compression string `json:"compression,omitempty"`
+
+	// method of the backup, mysqldump, xtrabackup or clone
+	Method string `json:"method,omitempty"`
+
 	StartTime time.Time `json:"start_time"`
 
 	EndTime *time.Time `json:"end_time,omitempty"`
 
 	// backup status
 	Status string `json:"status"`
+
+	Manifest *Manifest `json:"manifest,omitempty"`
 }
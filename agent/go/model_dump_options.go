@@ -0,0 +1,29 @@
+package openapi
+
+// DumpOptions - options of a logical backup, they build the mysqldump invocation
+type DumpOptions struct {
+
+	// single-transaction takes a consistent snapshot of the InnoDB tables, lock-all locks every table for the dump. It defaults to lock-all
+	Consistency string `json:"consistency,omitempty"`
+
+	// databases to dump, every database is dumped when it is empty
+	Databases []string `json:"databases,omitempty"`
+
+	// databases not to dump, it cannot be used with databases
+	ExcludeDatabases []string `json:"exclude_databases,omitempty"`
+
+	// tables to dump as database.table, they must belong to the same database
+	Tables []string `json:"tables,omitempty"`
+
+	// tables not to dump as database.table
+	ExcludeTables []string `json:"exclude_tables,omitempty"`
+
+	// dump the stored procedures and functions
+	Routines bool `json:"routines,omitempty"`
+
+	// dump the triggers, it defaults to true
+	Triggers *bool `json:"triggers,omitempty"`
+
+	// dump the events of the scheduler
+	Events bool `json:"events,omitempty"`
+}
@@ -0,0 +1,14 @@
+package openapi
+
+// Encryption - keys that encrypt the backups before they are pushed to the store
+type Encryption struct {
+
+	// id of the key that encrypts new backups
+	KeyId string `json:"key_id"`
+
+	// every key of the store, older keys decrypt the backups made before a rotation
+	Keys []EncryptionKey `json:"keys"`
+
+	// restores backups that are not encrypted, they are rejected by default
+	AllowUnencrypted bool `json:"allow_unencrypted,omitempty"`
+}
@@ -0,0 +1,7 @@
+package openapi
+
+type ListVariables struct {
+	Size int32 `json:"size,omitempty"`
+
+	Items []Variable `json:"items,omitempty"`
+}
@@ -0,0 +1,26 @@
+package openapi
+
+// Manifest - manifest written next to a backup, it describes the object and the server it comes from
+type Manifest struct {
+
+	// sha256 of the object in the store, hex encoded
+	Sha256 string `json:"sha256"`
+
+	// size of the object in the store, in bytes
+	Size int64 `json:"size"`
+
+	// version of the server
+	MysqlVersion string `json:"mysql_version,omitempty"`
+
+	// GTID set the backup is consistent with
+	GtidExecuted string `json:"gtid_executed,omitempty"`
+
+	// binary log the backup is consistent with
+	BinlogFile string `json:"binlog_file,omitempty"`
+
+	// position in the binary log the backup is consistent with
+	BinlogPosition int64 `json:"binlog_position,omitempty"`
+
+	// databases included in the backup
+	Databases []string `json:"databases,omitempty"`
+}
@@ -0,0 +1,9 @@
+package openapi
+
+type PromoteRequest struct {
+	// GTID set to apply before the promotion, it defaults to the retrieved GTID set
+	GtidSet string `json:"gtid_set,omitempty"`
+
+	// timeout in seconds to apply the GTID set
+	Timeout int32 `json:"timeout,omitempty"`
+}
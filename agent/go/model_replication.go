@@ -0,0 +1,23 @@
+package openapi
+
+// Replication - replication role and status of an instance
+type Replication struct {
+	Role string `json:"role"`
+
+	// replication state
+	State string `json:"state"`
+
+	Source string `json:"source,omitempty"`
+
+	IoRunning bool `json:"io_running,omitempty"`
+
+	SqlRunning bool `json:"sql_running,omitempty"`
+
+	SecondsBehindSource *int32 `json:"seconds_behind_source,omitempty"`
+
+	LastError string `json:"last_error,omitempty"`
+
+	GtidExecuted string `json:"gtid_executed,omitempty"`
+
+	GtidRetrieved string `json:"gtid_retrieved,omitempty"`
+}
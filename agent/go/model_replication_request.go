@@ -0,0 +1,17 @@
+package openapi
+
+type ReplicationRequest struct {
+	Host string `json:"host"`
+
+	Port int32 `json:"port,omitempty"`
+
+	Username string `json:"username"`
+
+	Password string `json:"password"`
+
+	ServerId int32 `json:"server_id"`
+
+	Seed string `json:"seed,omitempty"`
+
+	Backup *BackupRequest `json:"backup,omitempty"`
+}
@@ -0,0 +1,27 @@
+package openapi
+
+import (
+	"time"
+)
+
+// Restore - output for a restore request
+type Restore struct {
+	Identifier string `json:"identifier"`
+
+	Bucket string `json:"bucket"`
+
+	Location string `json:"location"`
+
+	StartTime time.Time `json:"start_time"`
+
+	EndTime *time.Time `json:"end_time,omitempty"`
+
+	// restore status
+	Status string `json:"status"`
+
+	// step of a running restore, the dump is pulled from the store and then loaded in the server
+	Step string `json:"step,omitempty"`
+
+	// reason of a failed restore
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,17 @@
+package openapi
+
+// S3Options - options of the S3 compatible stores, like MinIO or Ceph
+type S3Options struct {
+
+	// URL of the S3 API, it defaults to the AWS endpoint of the region
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// region of the bucket
+	Region string `json:"region,omitempty"`
+
+	// addresses the bucket in the path of the URL instead of the host name
+	ForcePathStyle bool `json:"force_path_style,omitempty"`
+
+	// PEM encoded certificates of the authorities that sign the endpoint certificate
+	CaBundle string `json:"ca_bundle,omitempty"`
+}
@@ -2,30 +2,36 @@ package main
 
 import (
 	"database/sql"
-	"log"
 
 	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/azure"
 	"github.com/blaqkube/mysql-operator/agent/backend/blackhole"
+	"github.com/blaqkube/mysql-operator/agent/backend/filesystem"
 	"github.com/blaqkube/mysql-operator/agent/backend/gcp"
 	"github.com/blaqkube/mysql-operator/agent/backend/mysql"
 	"github.com/blaqkube/mysql-operator/agent/backend/s3"
 	"github.com/blaqkube/mysql-operator/agent/cmd"
-	_ "github.com/go-sql-driver/mysql"
 )
 
 func main() {
-	db, err := sql.Open("mysql", "root@tcp(localhost:3306)/")
-	if err != nil {
-		log.Printf("Error opening database: %v", err)
-	}
+	db := sql.OpenDB(mysql.NewConnector("127.0.0.1:3306", cmd.Credentials))
 	instance := mysql.NewInstance(db)
-	backup := mysql.NewBackup()
+	backups := map[string]backend.Backup{
+		backend.MethodMysqldump:  mysql.NewBackup(db, cmd.Credentials),
+		backend.MethodXtrabackup: mysql.NewXtrabackup(cmd.Credentials),
+		backend.MethodClone:      mysql.NewClone(db),
+	}
+	binlog := mysql.NewBinlog(db)
+	replica := mysql.NewReplica(cmd.Credentials)
+	restore := mysql.NewRestore(cmd.Credentials)
 
 	storages := map[string]backend.Storage{
-		"s3":        s3.NewStorage(),
-		"blackhole": blackhole.NewStorage(),
-		"gcp":       gcp.NewStorage(),
+		"s3":         s3.NewStorage(),
+		"azure":      azure.NewStorage(),
+		"blackhole":  blackhole.NewStorage(),
+		"filesystem": filesystem.NewStorage(filesystem.DefaultRoot),
+		"gcp":        gcp.NewStorage(),
 	}
 
-	cmd.Execute(backup, db, instance, storages)
+	cmd.Execute(backups, binlog, db, instance, replica, restore, storages)
 }
@@ -7,34 +7,57 @@ import (
 	"github.com/blaqkube/mysql-operator/agent/backend"
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	"github.com/blaqkube/mysql-operator/agent/service/backup"
+	"github.com/blaqkube/mysql-operator/agent/service/binlog"
+	"github.com/blaqkube/mysql-operator/agent/service/credential"
 	"github.com/blaqkube/mysql-operator/agent/service/database"
 	"github.com/blaqkube/mysql-operator/agent/service/grant"
+	"github.com/blaqkube/mysql-operator/agent/service/replication"
+	"github.com/blaqkube/mysql-operator/agent/service/restore"
 	"github.com/blaqkube/mysql-operator/agent/service/user"
+	"github.com/blaqkube/mysql-operator/agent/service/variable"
 )
 
 // A MysqlAPIController binds http requests to an api service and writes the service results to the http response
 type MysqlAPIController struct {
-	backup   backup.Router
-	database database.MysqlDatabaseRouter
-	user     user.MysqlUserRouter
-	grant    grant.MysqlGrantRouter
+	backup      backup.Router
+	binlog      binlog.Router
+	database    database.MysqlDatabaseRouter
+	user        user.MysqlUserRouter
+	grant       grant.MysqlGrantRouter
+	replication replication.Router
+	restore     restore.Router
+	variable    variable.Router
+	credential  credential.Router
 }
 
 // NewMysqlAPIController creates a default api controller
 func NewMysqlAPIController(
 	db *sql.DB,
-	bck backend.Backup,
+	bcks map[string]backend.Backup,
+	bnl backend.Binlog,
+	rpl backend.Replica,
+	rst backend.Restore,
 	strs map[string]backend.Storage,
 ) Router {
-	b := backup.NewService(bck, strs)
+	b := backup.NewService(db, bcks, strs)
+	l := binlog.NewService(bnl, strs)
 	d := database.NewMysqlDatabaseService(db)
 	u := user.NewMysqlUserService(db)
 	g := grant.NewMysqlGrantService(db)
+	r := replication.NewService(db, rpl, strs)
+	s := restore.NewService(rst, strs)
+	v := variable.NewService(db)
+	c := credential.NewService(db)
 	return &MysqlAPIController{
-		backup:   backup.NewController(b),
-		database: database.NewMysqlDatabaseController(d),
-		user:     user.NewMysqlUserController(u),
-		grant:    grant.NewMysqlGrantController(g),
+		backup:      backup.NewController(b),
+		binlog:      binlog.NewController(l),
+		database:    database.NewMysqlDatabaseController(d),
+		user:        user.NewMysqlUserController(u),
+		grant:       grant.NewMysqlGrantController(g),
+		replication: replication.NewController(r),
+		restore:     restore.NewController(s),
+		variable:    variable.NewController(v),
+		credential:  credential.NewController(c),
 	}
 }
 
@@ -42,8 +65,13 @@ func NewMysqlAPIController(
 func (c *MysqlAPIController) Routes() openapi.Routes {
 	routes := openapi.Routes{}
 	routes = append(routes, c.backup.Routes()...)
+	routes = append(routes, c.binlog.Routes()...)
 	routes = append(routes, c.database.Routes()...)
 	routes = append(routes, c.user.Routes()...)
 	routes = append(routes, c.grant.Routes()...)
+	routes = append(routes, c.replication.Routes()...)
+	routes = append(routes, c.restore.Routes()...)
+	routes = append(routes, c.variable.Routes()...)
+	routes = append(routes, c.credential.Routes()...)
 	return routes
 }
@@ -29,10 +29,13 @@ func (s *Suite) SetupSuite() {
 		"gcp":        bmock.NewStorage(),
 		"s3":        bmock.NewStorage(),
 	}
-	backup := bmock.NewBackup()
+	backups := map[string]backend.Backup{"mysqldump": bmock.NewBackup()}
+	binlog := bmock.NewBinlog()
+	replica := bmock.NewReplica()
+	restore := bmock.NewRestore()
 	require.NoError(s.T(), err)
 
-	s.testService = NewMysqlAPIController(s.db, backup, storages)
+	s.testService = NewMysqlAPIController(s.db, backups, binlog, replica, restore, storages)
 }
 
 func (s *Suite) Test_Routes() {
@@ -44,6 +47,14 @@ func (s *Suite) Test_Routes() {
 	assert.Equal(s.T(), nil, err, "Should succeed")
 	assert.Equal(s.T(), []string{"POST"}, m, "Should succeed")
 
+	p, err = next.GetRoute("StartBinlogArchive").GetPathRegexp()
+	assert.Equal(s.T(), nil, err, "Should succeed")
+	assert.Equal(s.T(), "^/binlog[/]?$", p, "Should succeed")
+
+	p, err = next.GetRoute("CreateRestore").GetPathRegexp()
+	assert.Equal(s.T(), nil, err, "Should succeed")
+	assert.Equal(s.T(), "^/restore[/]?$", p, "Should succeed")
+
 	p, err = next.GetRoute("CreateDatabase").GetPathRegexp()
 	assert.Equal(s.T(), nil, err, "Should succeed")
 	assert.Equal(s.T(), "^/database[/]?$", p, "Should succeed")
@@ -72,6 +83,27 @@ func (s *Suite) Test_Routes() {
 	assert.Equal(s.T(), nil, err, "Should succeed")
 	assert.Equal(s.T(), []string{"GET"}, m, "Should succeed")
 
+	p, err = next.GetRoute("CreateReplication").GetPathRegexp()
+	assert.Equal(s.T(), nil, err, "Should succeed")
+	assert.Equal(s.T(), "^/replication[/]?$", p, "Should succeed")
+	m, err = next.GetRoute("CreateReplication").GetMethods()
+	assert.Equal(s.T(), nil, err, "Should succeed")
+	assert.Equal(s.T(), []string{"POST"}, m, "Should succeed")
+
+	p, err = next.GetRoute("PersistVariables").GetPathRegexp()
+	assert.Equal(s.T(), nil, err, "Should succeed")
+	assert.Equal(s.T(), "^/variable[/]?$", p, "Should succeed")
+	m, err = next.GetRoute("PersistVariables").GetMethods()
+	assert.Equal(s.T(), nil, err, "Should succeed")
+	assert.Equal(s.T(), []string{"POST"}, m, "Should succeed")
+
+	p, err = next.GetRoute("RotateCredential").GetPathRegexp()
+	assert.Equal(s.T(), nil, err, "Should succeed")
+	assert.Equal(s.T(), "^/credential[/]?$", p, "Should succeed")
+	m, err = next.GetRoute("RotateCredential").GetMethods()
+	assert.Equal(s.T(), nil, err, "Should succeed")
+	assert.Equal(s.T(), []string{"POST"}, m, "Should succeed")
+
 }
 
 func TestSuite(t *testing.T) {
@@ -1,12 +1,19 @@
 package backup
 
 import (
+	"database/sql"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/dump"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	"github.com/blaqkube/mysql-operator/agent/backend/manifest"
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	uuid "github.com/hashicorp/go-uuid"
 )
@@ -32,8 +39,9 @@ const (
 // This service should implement the business logic for every endpoint for the MysqlBackup API.
 // Include any external packages or services that will be required by this service.
 type Service struct {
-	Backup    backend.Backup
+	Backups   map[string]backend.Backup
 	CurrState string
+	DB        *sql.DB
 	LastState string
 	M         sync.Mutex
 	States    map[string]openapi.Backup
@@ -41,10 +49,11 @@ type Service struct {
 	Storages  map[string]backend.Storage
 }
 
-// NewService creates a backup service
-func NewService(backup backend.Backup, storages map[string]backend.Storage) *Service {
+// NewService creates a backup service with the backups by method
+func NewService(db *sql.DB, backups map[string]backend.Backup, storages map[string]backend.Storage) *Service {
 	return &Service{
-		Backup:   backup,
+		Backups:  backups,
+		DB:       db,
 		Status:   StatusWaiting,
 		States:   map[string]openapi.Backup{},
 		Storages: storages,
@@ -58,17 +67,41 @@ func (s *Service) CreateBackup(request openapi.BackupRequest, apiKey string) (in
 	if s.Status != StatusWaiting {
 		return &openapi.Backup{}, http.StatusConflict, fmt.Errorf("State %s", s.Status)
 	}
+	if err := compress.Validate(request.Compression); err != nil {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("compression %s is not supported", request.Compression)}, http.StatusBadRequest, nil
+	}
+	if _, _, err := encrypt.FromRequest(request.Encryption); err != nil {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("encryption keys are not valid: %v", err)}, http.StatusBadRequest, nil
+	}
+	if request.Method == "" {
+		request.Method = backend.MethodMysqldump
+	}
+	if _, ok := s.Backups[request.Method]; !ok {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("method %s is not supported", request.Method)}, http.StatusBadRequest, nil
+	}
+	if request.Dump != nil && request.Method != backend.MethodMysqldump {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("dump options cannot be used with the %s method", request.Method)}, http.StatusBadRequest, nil
+	}
+	if err := dump.Validate(request.Dump); err != nil {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: err.Error()}, http.StatusBadRequest, nil
+	}
+	if request.Compression == "" {
+		request.Compression = compress.None
+	}
+	request.Location = compress.Location(request.Location, request.Compression)
 	id, err := uuid.GenerateUUID()
 	if err != nil {
 		return &openapi.Backup{}, http.StatusInternalServerError, err
 	}
 	s.LastState = s.CurrState
 	backup := openapi.Backup{
-		Identifier: id,
-		Bucket:     request.Bucket,
-		Location:   request.Location,
-		Status:     StatusWaiting,
-		StartTime:  time.Now(),
+		Identifier:  id,
+		Bucket:      request.Bucket,
+		Location:    request.Location,
+		Compression: request.Compression,
+		Method:      request.Method,
+		Status:      StatusWaiting,
+		StartTime:   time.Now(),
 	}
 	s.States[id] = backup
 	go runBackup(s, request, s.States[id])
@@ -99,25 +132,117 @@ func (s *Service) GetBackups(apikey string) (interface{}, int, error) {
 		size++
 	}
 	return &openapi.BackupList{
-		Size: size,
+		Size:  size,
 		Items: backups,
 	}, http.StatusOK, nil
 }
 
-// runBackup is the routine that runs the backup
+// runBackup is the routine that runs the backup. The dump is piped to the
+// storage so that it is never written on the local volume, its manifest is
+// pushed next to it once it is complete
 func runBackup(b *Service, request openapi.BackupRequest, backup openapi.Backup) {
-	b.Backup.Run(fmt.Sprintf("%s.dmp", backup.Identifier))
-	st := request.Backend
-	if st == "" {
-		st = "s3"
-	}
-	b.Storages[request.Backend].Push(&request, fmt.Sprintf("%s.dmp", backup.Identifier))
+	m, err := push(b, request)
 	b.M.Lock()
 	defer b.M.Unlock()
 	b.Status = StatusWaiting
 	s := b.States[backup.Identifier]
 	s.Status = StatusSucceeded
+	s.Manifest = m
+	if err != nil {
+		log.Printf("Backup %s failed: %v", backup.Identifier, err)
+		s.Status = StatusFailed
+	}
 	t := time.Now()
 	s.EndTime = &t
 	b.States[backup.Identifier] = s
 }
+
+// push streams the backup to the storage and writes its manifest. The
+// coordinates of the manifest are the ones of the backup
+func push(b *Service, request openapi.BackupRequest) (*openapi.Manifest, error) {
+	st := request.Backend
+	if st == "" {
+		st = "s3"
+	}
+	m := inspect(b.DB, &request)
+	digest := manifest.NewDigest()
+	pr, pw := io.Pipe()
+	coordinates := make(chan backend.Coordinates, 1)
+	go func() {
+		c, err := stream(b.Backups[request.Method], request, pw)
+		coordinates <- c
+		pw.CloseWithError(err)
+	}()
+	err := b.Storages[st].PushStream(&request, io.TeeReader(pr, digest))
+	// unblocks the dump when the storage stops reading
+	pr.CloseWithError(err)
+	if err != nil {
+		return nil, err
+	}
+	c := <-coordinates
+	m.GtidExecuted = c.GTIDExecuted
+	m.BinlogFile = c.BinlogFile
+	m.BinlogPosition = c.BinlogPosition
+	m.Sha256 = digest.Sum()
+	m.Size = digest.Size()
+	if err := manifest.Write(b.Storages[st], &request, m); err != nil {
+		return nil, fmt.Errorf("manifest: %v", err)
+	}
+	return m, nil
+}
+
+// inspect returns the manifest of a backup with the server version and the
+// databases at the start of the backup. The metadata that cannot be read is
+// left empty
+func inspect(db *sql.DB, request *openapi.BackupRequest) *openapi.Manifest {
+	m := &openapi.Manifest{}
+	if err := db.QueryRow("SELECT VERSION()").Scan(&m.MysqlVersion); err != nil {
+		log.Printf("Cannot read the server version: %v", err)
+	}
+	databases, err := showDatabases(db)
+	if err != nil {
+		log.Printf("Cannot list the databases: %v", err)
+		return m
+	}
+	m.Databases = dump.Databases(request.Dump, databases)
+	return m
+}
+
+// showDatabases returns the databases of the server
+func showDatabases(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SHOW DATABASES")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	databases := []string{}
+	for rows.Next() {
+		database := ""
+		if err := rows.Scan(&database); err != nil {
+			return nil, err
+		}
+		databases = append(databases, database)
+	}
+	return databases, rows.Err()
+}
+
+// stream writes the backup to w, encrypted with the key of the request when
+// there is one. It returns the coordinates of the backup
+func stream(backup backend.Backup, request openapi.BackupRequest, w io.Writer) (backend.Coordinates, error) {
+	if request.Encryption == nil {
+		return backup.Stream(w, &request)
+	}
+	keyID, keys, err := encrypt.FromRequest(request.Encryption)
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	ew, err := encrypt.NewWriter(w, keyID, keys[keyID])
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	coordinates, err := backup.Stream(ew, &request)
+	if err != nil {
+		return backend.Coordinates{}, err
+	}
+	return coordinates, ew.Close()
+}
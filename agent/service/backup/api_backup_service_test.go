@@ -1,12 +1,21 @@
 package backup
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	"github.com/blaqkube/mysql-operator/agent/backend/manifest"
 	"github.com/blaqkube/mysql-operator/agent/backend/mock"
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	_ "github.com/go-sql-driver/mysql"
@@ -18,16 +27,21 @@ import (
 type BackupServiceSuite struct {
 	suite.Suite
 	Service *Service
+	db      *sql.DB
+	mock    sqlmock.Sqlmock
 }
 
 func (s *BackupServiceSuite) SetupSuite() {
-	backup := mock.NewBackup()
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+	backups := map[string]backend.Backup{"mysqldump": mock.NewBackup()}
 	storages := map[string]backend.Storage{
 		"blackhole": mock.NewStorage(),
 		"gcp":       mock.NewStorage(),
 		"s3":        mock.NewStorage(),
 	}
-	s.Service = NewService(backup, storages)
+	s.Service = NewService(s.db, backups, storages)
 	s.Service.M.Lock()
 	defer s.Service.M.Unlock()
 	key := "abcd"
@@ -96,6 +110,151 @@ func (s *BackupServiceSuite) Test_CreateBackup() {
 	}
 }
 
+func (s *BackupServiceSuite) Test_CreateCompressedBackup() {
+	service := NewService(s.db, map[string]backend.Backup{"mysqldump": mock.NewBackup()}, map[string]backend.Storage{"s3": mock.NewStorage()})
+	b, code, err := service.CreateBackup(
+		openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/red.sql", Compression: "xz"},
+		"apikey",
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+	require.IsType(s.T(), openapi.Message{}, b)
+
+	b, code, err = service.CreateBackup(
+		openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/red.sql", Compression: "zstd"},
+		"apikey",
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	switch v := b.(type) {
+	case *openapi.Backup:
+		require.Equal(s.T(), "/red.sql.zst", v.Location)
+		require.Equal(s.T(), "zstd", v.Compression)
+	default:
+		require.Equal(s.T(), fmt.Sprintf("%T", b), "unknown type")
+	}
+}
+
+func (s *BackupServiceSuite) Test_CreatePhysicalBackup() {
+	service := NewService(s.db, map[string]backend.Backup{"mysqldump": mock.NewBackup(), "xtrabackup": mock.NewBackup()}, map[string]backend.Storage{"s3": mock.NewStorage()})
+	b, code, err := service.CreateBackup(
+		openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/red.tar", Method: "clone"},
+		"apikey",
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+	require.IsType(s.T(), openapi.Message{}, b)
+
+	b, code, err = service.CreateBackup(
+		openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/red.xbstream", Method: "xtrabackup"},
+		"apikey",
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	switch v := b.(type) {
+	case *openapi.Backup:
+		require.Equal(s.T(), "xtrabackup", v.Method)
+	default:
+		require.Equal(s.T(), fmt.Sprintf("%T", b), "unknown type")
+	}
+}
+
+func (s *BackupServiceSuite) Test_CreateBackupInvalidDump() {
+	service := NewService(s.db, map[string]backend.Backup{"mysqldump": mock.NewBackup(), "xtrabackup": mock.NewBackup()}, map[string]backend.Storage{"s3": mock.NewStorage()})
+	for _, request := range []openapi.BackupRequest{
+		{Backend: "s3", Bucket: "bucket", Location: "/red.sql", Dump: &openapi.DumpOptions{Consistency: "snapshot"}},
+		{Backend: "s3", Bucket: "bucket", Location: "/red.sql", Dump: &openapi.DumpOptions{Tables: []string{"users"}}},
+		{Backend: "s3", Bucket: "bucket", Location: "/red.xbstream", Method: "xtrabackup", Dump: &openapi.DumpOptions{Routines: true}},
+	} {
+		b, code, err := service.CreateBackup(request, "apikey")
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), http.StatusBadRequest, code)
+		require.IsType(s.T(), openapi.Message{}, b)
+	}
+
+	b, code, err := service.CreateBackup(
+		openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/red.sql", Dump: &openapi.DumpOptions{Consistency: "single-transaction", Databases: []string{"blue"}}},
+		"apikey",
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	require.IsType(s.T(), &openapi.Backup{}, b)
+}
+
+func (s *BackupServiceSuite) Test_CreateBackupInvalidEncryption() {
+	service := NewService(s.db, map[string]backend.Backup{"mysqldump": mock.NewBackup()}, map[string]backend.Storage{"s3": mock.NewStorage()})
+	b, code, err := service.CreateBackup(
+		openapi.BackupRequest{
+			Backend:    "s3",
+			Bucket:     "bucket",
+			Location:   "/red.sql",
+			Encryption: &openapi.Encryption{KeyId: "2021-02", Keys: []openapi.EncryptionKey{{Id: "2021-01", Key: "short"}}},
+		},
+		"apikey",
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+	require.IsType(s.T(), openapi.Message{}, b)
+}
+
+func (s *BackupServiceSuite) Test_StreamEncrypted() {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, encrypt.KeySize))
+	request := openapi.BackupRequest{
+		Encryption: &openapi.Encryption{KeyId: "2021-01", Keys: []openapi.EncryptionKey{{Id: "2021-01", Key: key}}},
+	}
+	buffer := &bytes.Buffer{}
+	_, err := stream(mock.NewBackup(), request, buffer)
+	require.NoError(s.T(), err)
+	_, keyID, err := encrypt.NewReader(buffer, map[string][]byte{"2021-01": bytes.Repeat([]byte{1}, encrypt.KeySize)}, false)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "2021-01", keyID)
+}
+
+// objects is a storage that keeps the objects it receives
+type objects struct {
+	*mock.Storage
+	content map[string][]byte
+}
+
+func (o *objects) PushStream(backup *openapi.BackupRequest, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	o.content[backup.Location] = content
+	return err
+}
+
+func (s *BackupServiceSuite) Test_Manifest() {
+	db, dbmock, err := sqlmock.New()
+	require.NoError(s.T(), err)
+	defer db.Close()
+	dbmock.ExpectQuery("SELECT VERSION()").
+		WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.23"))
+	dbmock.ExpectQuery("SHOW DATABASES").
+		WillReturnRows(sqlmock.NewRows([]string{"Database"}).AddRow("blue").AddRow("mysql").AddRow("sys"))
+	storage := &objects{Storage: mock.NewStorage(), content: map[string][]byte{}}
+	backup := mock.NewBackup()
+	backup.Coordinates = backend.Coordinates{
+		GTIDExecuted:   "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5",
+		BinlogFile:     "binlog.000003",
+		BinlogPosition: 156,
+	}
+	service := NewService(db, map[string]backend.Backup{"mysqldump": backup}, map[string]backend.Storage{"s3": storage})
+
+	m, err := push(service, openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/red.sql", Method: "mysqldump"})
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), dbmock.ExpectationsWereMet())
+	require.Equal(s.T(), "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", m.Sha256)
+	require.Equal(s.T(), int64(0), m.Size)
+	require.Equal(s.T(), "8.0.23", m.MysqlVersion)
+	require.Equal(s.T(), "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5", m.GtidExecuted)
+	require.Equal(s.T(), "binlog.000003", m.BinlogFile)
+	require.Equal(s.T(), int64(156), m.BinlogPosition)
+	require.Equal(s.T(), []string{"blue", "mysql"}, m.Databases)
+
+	written := &openapi.Manifest{}
+	require.NoError(s.T(), json.Unmarshal(storage.content[manifest.Location("/red.sql")], written))
+	require.Equal(s.T(), m, written)
+}
+
 func TestBackupSuite(t *testing.T) {
 	suite.Run(t, &BackupServiceSuite{})
 }
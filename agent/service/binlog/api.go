@@ -0,0 +1,27 @@
+package binlog
+
+import (
+	"net/http"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Router defines the required methods for binding the api requests to a responses for the MysqlBinlog
+// The Router implementation should parse necessary information from the http request,
+// pass the data to a Servicer to perform the required actions, then write the service results to the http response.
+type Router interface {
+	Routes() openapi.Routes
+	GetBinlogArchive(http.ResponseWriter, *http.Request)
+	StartBinlogArchive(http.ResponseWriter, *http.Request)
+	StopBinlogArchive(http.ResponseWriter, *http.Request)
+}
+
+// Servicer defines the api actions for the Binlog service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type Servicer interface {
+	GetBinlogArchive(string) (interface{}, int, error)
+	StartBinlogArchive(openapi.BinlogArchive, string) (interface{}, int, error)
+	StopBinlogArchive(string) (interface{}, int, error)
+}
@@ -0,0 +1,82 @@
+package binlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Controller binds http requests to an api service and writes the service results to the http response
+type Controller struct {
+	service Servicer
+}
+
+// NewController creates a default api controller
+func NewController(s Servicer) Router {
+	return &Controller{service: s}
+}
+
+// Routes returns all of the api route for the Controller
+func (c *Controller) Routes() openapi.Routes {
+	return openapi.Routes{
+		{
+			Name:        "GetBinlogArchive",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/binlog",
+			HandlerFunc: c.GetBinlogArchive,
+		},
+		{
+			Name:        "StartBinlogArchive",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/binlog",
+			HandlerFunc: c.StartBinlogArchive,
+		},
+		{
+			Name:        "StopBinlogArchive",
+			Method:      strings.ToUpper("Delete"),
+			Pattern:     "/binlog",
+			HandlerFunc: c.StopBinlogArchive,
+		},
+	}
+}
+
+// GetBinlogArchive - get the binary log archive status
+func (c *Controller) GetBinlogArchive(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.GetBinlogArchive(apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// StartBinlogArchive - archive the binary logs to a store
+func (c *Controller) StartBinlogArchive(w http.ResponseWriter, r *http.Request) {
+	archive := &openapi.BinlogArchive{}
+	if err := json.NewDecoder(r.Body).Decode(&archive); err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.StartBinlogArchive(*archive, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// StopBinlogArchive - stop archiving the binary logs
+func (c *Controller) StopBinlogArchive(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.StopBinlogArchive(apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
@@ -0,0 +1,328 @@
+package binlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+const (
+	// StatusRunning defines the status of an archive that ships the binary logs
+	StatusRunning = "Running"
+
+	// StatusStopped defines the status when no binary log is archived
+	StatusStopped = "Stopped"
+
+	// localIndexFile is the copy of the index in the working directory
+	localIndexFile = "binlog-index.json"
+)
+
+var (
+	// ErrInvalidRequest is reported when the archive request is not valid
+	ErrInvalidRequest = errors.New("InvalidRequest")
+
+	// archivePollInterval is how often the binary logs are checked
+	archivePollInterval = 10 * time.Second
+)
+
+// Service is a service that implements the logic for the Servicer
+// This service should implement the business logic for every endpoint for the MysqlBinlog API.
+// Include any external packages or services that will be required by this service.
+type Service struct {
+	Binlog   backend.Binlog
+	Storages map[string]backend.Storage
+	M        sync.Mutex
+	Archive  openapi.BinlogArchive
+	archiver *archiver
+	// step prevents 2 archivers from writing the index at the same time
+	step sync.Mutex
+}
+
+// NewService creates a binlog service
+func NewService(binlog backend.Binlog, storages map[string]backend.Storage) *Service {
+	return &Service{
+		Binlog:   binlog,
+		Storages: storages,
+		Archive:  openapi.BinlogArchive{Status: StatusStopped},
+	}
+}
+
+// GetBinlogArchive - get the binary log archive status
+func (s *Service) GetBinlogArchive(apiKey string) (interface{}, int, error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	return s.status(), http.StatusOK, nil
+}
+
+// StartBinlogArchive - archive the binary logs to a store. The archive
+// keeps running when the request does not change
+func (s *Service) StartBinlogArchive(request openapi.BinlogArchive, apiKey string) (interface{}, int, error) {
+	if request.Bucket == "" || request.Location == "" {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: "bucket and location are required"}, http.StatusBadRequest, ErrInvalidRequest
+	}
+	if _, ok := s.Storages[request.Backend]; !ok {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("unknown backend %s", request.Backend)}, http.StatusBadRequest, ErrInvalidRequest
+	}
+	if _, _, err := encrypt.FromRequest(request.Encryption); err != nil {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("invalid encryption: %v", err)}, http.StatusBadRequest, ErrInvalidRequest
+	}
+	s.M.Lock()
+	defer s.M.Unlock()
+	if s.archiver != nil && reflect.DeepEqual(s.archiver.request, request) {
+		return s.status(), http.StatusOK, nil
+	}
+	if s.archiver != nil {
+		close(s.archiver.stop)
+	}
+	s.archiver = &archiver{
+		service: s,
+		request: request,
+		stop:    make(chan struct{}),
+	}
+	s.Archive = openapi.BinlogArchive{
+		Backend:       request.Backend,
+		Bucket:        request.Bucket,
+		Location:      request.Location,
+		FlushInterval: request.FlushInterval,
+		Status:        StatusRunning,
+	}
+	go s.archiver.run()
+	return s.status(), http.StatusCreated, nil
+}
+
+// StopBinlogArchive - stop archiving the binary logs
+func (s *Service) StopBinlogArchive(apiKey string) (interface{}, int, error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	if s.archiver != nil {
+		close(s.archiver.stop)
+		s.archiver = nil
+	}
+	s.Archive.Status = StatusStopped
+	return s.status(), http.StatusOK, nil
+}
+
+// status returns the archive without the store credentials
+func (s *Service) status() *openapi.BinlogArchive {
+	archive := s.Archive
+	archive.Envs = nil
+	return &archive
+}
+
+// update records the result of an archiver step unless it has been replaced
+func (s *Service) update(a *archiver, name string, archiveTime *time.Time, err error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	if s.archiver != a {
+		return
+	}
+	if name != "" {
+		s.Archive.LastBinlog = name
+		s.Archive.LastArchiveTime = archiveTime
+	}
+	s.Archive.LastError = ""
+	if err != nil {
+		s.Archive.LastError = err.Error()
+	}
+}
+
+// archiver ships the closed binary logs of the server to a store location
+// with an index of the archived files
+type archiver struct {
+	service *Service
+	request openapi.BinlogArchive
+	stop    chan struct{}
+	index   *Index
+	// current is the binary log in use when it was first seen
+	current backend.BinaryLog
+	since   time.Time
+}
+
+// run is the routine that archives the binary logs until it is stopped
+func (a *archiver) run() {
+	ticker := time.NewTicker(archivePollInterval)
+	defer ticker.Stop()
+	for {
+		a.service.step.Lock()
+		if !a.stopped() {
+			if err := a.archive(); err != nil {
+				log.Printf("Binary logs archive failed: %v", err)
+				a.service.update(a, "", nil, err)
+			}
+		}
+		a.service.step.Unlock()
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *archiver) stopped() bool {
+	select {
+	case <-a.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// archive pushes the binary logs that are not in the index yet and flushes
+// the one in use when it has been open for longer than the flush interval
+func (a *archiver) archive() error {
+	storage := a.service.Storages[a.request.Backend]
+	if a.index == nil {
+		index, err := a.pullIndex(storage)
+		if err != nil {
+			return err
+		}
+		a.index = index
+	}
+	logs, err := a.service.Binlog.List()
+	if err != nil {
+		return err
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	uuid, err := a.service.Binlog.ServerUUID()
+	if err != nil {
+		return err
+	}
+	for _, binaryLog := range logs[:len(logs)-1] {
+		if a.stopped() {
+			return nil
+		}
+		if a.index.Contains(uuid, binaryLog.Name) {
+			continue
+		}
+		path, err := a.service.Binlog.Path(binaryLog.Name)
+		if err != nil {
+			return err
+		}
+		entry := IndexEntry{
+			Name:       binaryLog.Name,
+			ServerUUID: uuid,
+			Location:   fmt.Sprintf("%s/%s/%s", a.request.Location, uuid, binaryLog.Name),
+		}
+		if err := a.push(storage, entry.Location, path); err != nil {
+			return err
+		}
+		entry.ArchiveTime = time.Now().UTC()
+		a.index.Binlogs = append(a.index.Binlogs, entry)
+		if err := a.index.Write(localIndexFile); err != nil {
+			return err
+		}
+		if err := a.push(storage, IndexLocation(a.request.Location), localIndexFile); err != nil {
+			return err
+		}
+		a.service.update(a, entry.Name, &entry.ArchiveTime, nil)
+	}
+	return a.flush(logs[len(logs)-1])
+}
+
+// flush closes the binary log in use when it has changed and has been open
+// for longer than the flush interval so that the archive does not lag
+func (a *archiver) flush(current backend.BinaryLog) error {
+	if a.request.FlushInterval <= 0 {
+		return nil
+	}
+	if current.Name != a.current.Name {
+		a.current = current
+		a.since = time.Now()
+		return nil
+	}
+	if current.Size <= a.current.Size || time.Since(a.since) < time.Duration(a.request.FlushInterval)*time.Second {
+		return nil
+	}
+	return a.service.Binlog.Flush()
+}
+
+// pullIndex gets the index of the archive. A new archive starts without
+// index, any other error is reported so that the archive is retried and the
+// index is not replaced
+func (a *archiver) pullIndex(storage backend.Storage) (*Index, error) {
+	location := IndexLocation(a.request.Location)
+	locations, err := storage.List(a.newRequest(location), location)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, l := range locations {
+		if l == location {
+			found = true
+		}
+	}
+	if !found {
+		log.Printf("Binary log index not found in %s, starting a new one", a.request.Location)
+		return &Index{}, nil
+	}
+	_, keys, err := encrypt.FromRequest(a.request.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	if err := Pull(storage, a.newRequest(location), localIndexFile, keys, encrypt.AllowUnencrypted(a.request.Encryption)); err != nil {
+		return nil, err
+	}
+	return ReadIndex(localIndexFile)
+}
+
+// push pushes a file to the archive, encrypted with the key of the request
+// when there is one
+func (a *archiver) push(storage backend.Storage, location, filename string) error {
+	request := a.newRequest(location)
+	if request.Encryption == nil {
+		return storage.Push(request, filename)
+	}
+	keyID, keys, err := encrypt.FromRequest(request.Encryption)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ew, err := encrypt.NewWriter(pw, keyID, keys[keyID])
+		if err == nil {
+			_, err = io.Copy(ew, f)
+		}
+		if err == nil {
+			err = ew.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	err = storage.PushStream(request, pr)
+	// unblocks the encryption when the storage stops reading
+	pr.CloseWithError(err)
+	<-done
+	return err
+}
+
+// newRequest returns the request of a file of the archive. The storage
+// records the key ID in the metadata of an encrypted file
+func (a *archiver) newRequest(location string) *openapi.BackupRequest {
+	return &openapi.BackupRequest{
+		Backend:    a.request.Backend,
+		Bucket:     a.request.Bucket,
+		Location:   location,
+		Envs:       a.request.Envs,
+		S3:         a.request.S3,
+		Encryption: a.request.Encryption,
+	}
+}
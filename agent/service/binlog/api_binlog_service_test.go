@@ -0,0 +1,250 @@
+package binlog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	"github.com/blaqkube/mysql-operator/agent/backend/mock"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type BinlogServiceSuite struct {
+	suite.Suite
+	binlog  *mock.Binlog
+	Service *Service
+}
+
+func (s *BinlogServiceSuite) SetupTest() {
+	s.binlog = mock.NewBinlog()
+	storages := map[string]backend.Storage{
+		"s3": mock.NewStorage(),
+	}
+	s.Service = NewService(s.binlog, storages)
+}
+
+func (s *BinlogServiceSuite) TearDownTest() {
+	s.Service.StopBinlogArchive("apikey")
+	os.Remove(localIndexFile)
+}
+
+func request() openapi.BinlogArchive {
+	return openapi.BinlogArchive{
+		Backend:       "s3",
+		Bucket:        "bucket",
+		Location:      "/blue-binlog",
+		FlushInterval: 1,
+		Envs:          []openapi.EnvVar{{Name: "AWS_REGION", Value: "eu-west-1"}},
+	}
+}
+
+func (s *BinlogServiceSuite) Test_StartBinlogArchive() {
+	r, code, err := s.Service.StartBinlogArchive(request(), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	archive := r.(*openapi.BinlogArchive)
+	require.Equal(s.T(), StatusRunning, archive.Status)
+	require.Empty(s.T(), archive.Envs, "Expected the credentials not to be returned")
+
+	_, code, err = s.Service.StartBinlogArchive(request(), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, code, "Expected the same archive to keep running")
+
+	r, code, err = s.Service.StopBinlogArchive("apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, code)
+	require.Equal(s.T(), StatusStopped, r.(*openapi.BinlogArchive).Status)
+}
+
+func (s *BinlogServiceSuite) Test_StartBinlogArchiveInvalid() {
+	invalid := request()
+	invalid.Location = ""
+	_, code, err := s.Service.StartBinlogArchive(invalid, "apikey")
+	require.Equal(s.T(), ErrInvalidRequest, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+
+	invalid = request()
+	invalid.Backend = "unknown"
+	_, code, err = s.Service.StartBinlogArchive(invalid, "apikey")
+	require.Equal(s.T(), ErrInvalidRequest, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func (s *BinlogServiceSuite) Test_Archive() {
+	a := &archiver{service: s.Service, request: request(), stop: make(chan struct{})}
+	s.Service.archiver = a
+	s.binlog.Logs = []backend.BinaryLog{
+		{Name: "binlog.000001", Size: 2048},
+		{Name: "binlog.000002", Size: 156},
+	}
+	require.NoError(s.T(), a.archive())
+	require.True(s.T(), a.index.Contains(s.binlog.UUID, "binlog.000001"))
+	require.False(s.T(), a.index.Contains(s.binlog.UUID, "binlog.000002"), "Expected the binlog in use not to be archived")
+	require.Equal(s.T(), "/blue-binlog/"+s.binlog.UUID+"/binlog.000001", a.index.Binlogs[0].Location)
+	require.Equal(s.T(), "binlog.000001", s.Service.Archive.LastBinlog)
+
+	require.NoError(s.T(), a.archive())
+	require.Len(s.T(), a.index.Binlogs, 1, "Expected a binlog to be archived once")
+	require.Equal(s.T(), 0, s.binlog.Flushed, "Expected the binlog in use to be kept without change")
+
+	a.since = time.Now().Add(-2 * time.Second)
+	s.binlog.Logs[1].Size = 4096
+	require.NoError(s.T(), a.archive())
+	require.Equal(s.T(), 1, s.binlog.Flushed, "Expected the binlog in use to be flushed after the interval")
+}
+
+// indexStorage is a storage that contains an index that cannot be read
+type indexStorage struct {
+	*mock.Storage
+}
+
+// List returns the index of the archive
+func (s *indexStorage) List(backup *openapi.BackupRequest, prefix string) ([]string, error) {
+	return []string{prefix}, nil
+}
+
+func (s *BinlogServiceSuite) Test_ArchiveKeepsTheIndex() {
+	s.Service.Storages["s3"] = &indexStorage{Storage: mock.NewStorage()}
+	a := &archiver{service: s.Service, request: request(), stop: make(chan struct{})}
+	s.Service.archiver = a
+	s.binlog.Logs = []backend.BinaryLog{
+		{Name: "binlog.000001", Size: 2048},
+		{Name: "binlog.000002", Size: 156},
+	}
+	require.Error(s.T(), a.archive(), "Expected the archive to fail when the index cannot be read")
+	require.Nil(s.T(), a.index, "Expected the index to be pulled again")
+}
+
+// objectStorage is a storage that keeps the objects in memory
+type objectStorage struct {
+	*mock.Storage
+	objects map[string][]byte
+}
+
+func (s *objectStorage) Push(backup *openapi.BackupRequest, filename string) error {
+	content, err := ioutil.ReadFile(filename)
+	s.objects[backup.Location] = content
+	return err
+}
+
+func (s *objectStorage) PushStream(backup *openapi.BackupRequest, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	s.objects[backup.Location] = content
+	return err
+}
+
+func (s *objectStorage) Pull(backup *openapi.BackupRequest, filename string) error {
+	return ioutil.WriteFile(filename, s.objects[backup.Location], 0644)
+}
+
+func (s *objectStorage) List(backup *openapi.BackupRequest, prefix string) ([]string, error) {
+	locations := []string{}
+	for location := range s.objects {
+		if strings.HasPrefix(location, prefix) {
+			locations = append(locations, location)
+		}
+	}
+	return locations, nil
+}
+
+func (s *BinlogServiceSuite) Test_ArchiveEncrypted() {
+	key := bytes.Repeat([]byte{1}, encrypt.KeySize)
+	keys := map[string][]byte{"2021-01": key}
+	storage := &objectStorage{Storage: mock.NewStorage(), objects: map[string][]byte{}}
+	s.Service.Storages["s3"] = storage
+	encrypted := request()
+	encrypted.Encryption = &openapi.Encryption{
+		KeyId: "2021-01",
+		Keys:  []openapi.EncryptionKey{{Id: "2021-01", Key: base64.StdEncoding.EncodeToString(key)}},
+	}
+	require.NoError(s.T(), ioutil.WriteFile("binlog.000001", []byte("binlog"), 0644))
+	defer os.Remove("binlog.000001")
+	s.binlog.Logs = []backend.BinaryLog{
+		{Name: "binlog.000001", Size: 2048},
+		{Name: "binlog.000002", Size: 156},
+	}
+	a := &archiver{service: s.Service, request: encrypted, stop: make(chan struct{})}
+	s.Service.archiver = a
+	require.NoError(s.T(), a.archive())
+
+	for _, location := range []string{"/blue-binlog/" + s.binlog.UUID + "/binlog.000001", IndexLocation("/blue-binlog")} {
+		r, keyID, err := encrypt.NewReader(bytes.NewReader(storage.objects[location]), keys, false)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), "2021-01", keyID, "Expected %s to be encrypted", location)
+		_, err = ioutil.ReadAll(r)
+		require.NoError(s.T(), err)
+	}
+
+	// a new archiver decrypts the index
+	a = &archiver{service: s.Service, request: encrypted, stop: make(chan struct{})}
+	index, err := a.pullIndex(storage)
+	require.NoError(s.T(), err)
+	require.True(s.T(), index.Contains(s.binlog.UUID, "binlog.000001"))
+
+	// an index that is not encrypted is only read when it is allowed
+	storage.objects[IndexLocation("/blue-binlog")] = []byte(`{"binlogs":[]}`)
+	_, err = a.pullIndex(storage)
+	require.Equal(s.T(), encrypt.ErrNotEncrypted, err)
+	a.request.Encryption.AllowUnencrypted = true
+	_, err = a.pullIndex(storage)
+	require.NoError(s.T(), err)
+}
+
+func (s *BinlogServiceSuite) Test_IndexSelect() {
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	index := &Index{
+		Binlogs: []IndexEntry{
+			{Name: "binlog.000009", ServerUUID: "b", ArchiveTime: start.Add(2 * time.Hour)},
+			{Name: "binlog.000010", ServerUUID: "b", ArchiveTime: start.Add(3 * time.Hour)},
+			{Name: "binlog.000008", ServerUUID: "b", ArchiveTime: start.Add(time.Hour)},
+		},
+	}
+	require.Len(s.T(), index.Select(time.Time{}), 3)
+	selected := index.Select(start.Add(30 * time.Minute))
+	require.Len(s.T(), selected, 2)
+	require.Equal(s.T(), "binlog.000008", selected[0].Name, "Expected the binlog that contains the target")
+	require.Equal(s.T(), "binlog.000009", selected[1].Name, "Expected the first binlog that starts after the target")
+	require.Len(s.T(), index.Select(start.Add(90*time.Minute)), 3)
+	require.Len(s.T(), index.Select(start.Add(4*time.Hour)), 3)
+}
+
+func (s *BinlogServiceSuite) Test_IndexSelectServers() {
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	index := &Index{
+		Binlogs: []IndexEntry{
+			{Name: "binlog.000001", ServerUUID: "a", ArchiveTime: start.Add(3 * time.Hour)},
+			{Name: "binlog.000002", ServerUUID: "b", ArchiveTime: start.Add(time.Hour)},
+			{Name: "binlog.000003", ServerUUID: "b", ArchiveTime: start.Add(2 * time.Hour)},
+			{Name: "binlog.000002", ServerUUID: "a", ArchiveTime: start.Add(4 * time.Hour)},
+			{Name: "binlog.000003", ServerUUID: "a", ArchiveTime: start.Add(5 * time.Hour)},
+		},
+	}
+	selected := index.Select(start.Add(210 * time.Minute))
+	names := []string{}
+	for _, entry := range selected {
+		names = append(names, entry.ServerUUID+"/"+entry.Name)
+	}
+	require.Equal(s.T(), []string{
+		"b/binlog.000002",
+		"b/binlog.000003",
+		"a/binlog.000001",
+		"a/binlog.000002",
+		"a/binlog.000003",
+	}, names, "Expected the binlogs of the former primary first")
+	require.Len(s.T(), index.Select(start.Add(150*time.Minute)), 4, "Expected the binlogs of the new primary after the target to be skipped")
+}
+
+func TestBinlogServiceSuite(t *testing.T) {
+	suite.Run(t, &BinlogServiceSuite{})
+}
@@ -0,0 +1,107 @@
+package binlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBinlogArchiveSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("GET", "/binlog", nil)
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.BinlogArchive{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusOK, response.StatusCode, "result should succeed")
+	assert.Equal(t, "binlog.000012", u.LastBinlog, "Should report the last binlog")
+}
+
+func TestGetBinlogArchiveFailure(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("GET", "/binlog", nil)
+
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode, "result should fail")
+}
+
+func TestStartBinlogArchiveSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.BinlogArchive{
+		Backend:  "s3",
+		Bucket:   "bucket",
+		Location: "/blue-binlog",
+	})
+	r := httptest.NewRequest("POST", "/binlog", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.BinlogArchive{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+	assert.Equal(t, StatusRunning, u.Status, "Should be running")
+}
+
+func TestStartBinlogArchiveFailure(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.BinlogArchive{Backend: "s3"})
+	r := httptest.NewRequest("POST", "/binlog", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode, "result should fail")
+}
+
+func TestStopBinlogArchiveSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("DELETE", "/binlog", nil)
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusOK, response.StatusCode, "result should succeed")
+}
@@ -0,0 +1,140 @@
+package binlog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// indexFile is the name of the index in the archive location
+const indexFile = "index.json"
+
+// Index lists the archived binary logs. It is stored with them so that a
+// restore can find the ones to replay
+type Index struct {
+	Binlogs []IndexEntry `json:"binlogs"`
+}
+
+// IndexEntry is an archived binary log
+type IndexEntry struct {
+	Name        string    `json:"name"`
+	ServerUUID  string    `json:"server_uuid"`
+	Location    string    `json:"location"`
+	ArchiveTime time.Time `json:"archive_time"`
+}
+
+// IndexLocation returns the location of the index of an archive
+func IndexLocation(location string) string {
+	return location + "/" + indexFile
+}
+
+// ReadIndex reads an index from a file
+func ReadIndex(filename string) (*Index, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	index := &Index{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// Pull pulls a file of an archive, the index or a binary log, and decrypts
+// it with the keys. A file that is not encrypted is rejected when there are
+// keys, unless allowUnencrypted is set
+func Pull(storage backend.Storage, request *openapi.BackupRequest, filename string, keys map[string][]byte, allowUnencrypted bool) error {
+	download := filename + ".download"
+	defer os.Remove(download)
+	if err := storage.Pull(request, download); err != nil {
+		return err
+	}
+	_, err := encrypt.Unseal(download, filename, keys, allowUnencrypted)
+	return err
+}
+
+// Write writes the index to a file
+func (i *Index) Write(filename string) error {
+	data, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// Contains checks a binary log of a server has been archived
+func (i *Index) Contains(serverUUID, name string) bool {
+	for _, entry := range i.Binlogs {
+		if entry.ServerUUID == serverUUID && entry.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the binary logs to replay, ordered by server and by binary
+// log sequence. The servers are ordered by the time their first binary log
+// has been archived, e.g. the former primary and then the new one after a
+// failover. A binary log starts when the previous one of its server has been
+// archived: every binary log of a server is kept up to and including the
+// first one that starts after stop, mysqlbinlog --stop-datetime cuts the
+// events after stop. All of them are included when stop is zero. The
+// transactions that are already in the restored dump are skipped by MySQL
+// because of their GTID.
+func (i *Index) Select(stop time.Time) []IndexEntry {
+	entries := append([]IndexEntry{}, i.Binlogs...)
+	first := map[string]time.Time{}
+	for _, entry := range entries {
+		if t, ok := first[entry.ServerUUID]; !ok || entry.ArchiveTime.Before(t) {
+			first[entry.ServerUUID] = entry.ArchiveTime
+		}
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		ea, eb := entries[a], entries[b]
+		if ea.ServerUUID != eb.ServerUUID {
+			if !first[ea.ServerUUID].Equal(first[eb.ServerUUID]) {
+				return first[ea.ServerUUID].Before(first[eb.ServerUUID])
+			}
+			return ea.ServerUUID < eb.ServerUUID
+		}
+		return sequence(ea.Name) < sequence(eb.Name)
+	})
+	if stop.IsZero() {
+		return entries
+	}
+	selected := []IndexEntry{}
+	server := ""
+	start := time.Time{}
+	done := false
+	for _, entry := range entries {
+		if entry.ServerUUID != server {
+			server, start, done = entry.ServerUUID, time.Time{}, false
+		}
+		if done {
+			continue
+		}
+		selected = append(selected, entry)
+		done = start.After(stop)
+		start = entry.ArchiveTime
+	}
+	return selected
+}
+
+// sequence returns the number of a binary log from its extension, e.g. 12
+// for binlog.000012
+func sequence(name string) int {
+	n, err := strconv.Atoi(name[strings.LastIndex(name, ".")+1:])
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,42 @@
+package binlog
+
+import (
+	"errors"
+	"net/http"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+type mockService struct{}
+
+func (s *mockService) GetBinlogArchive(apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return &openapi.BinlogArchive{
+			Backend:    "s3",
+			Bucket:     "bucket",
+			Location:   "/blue-binlog",
+			Status:     StatusRunning,
+			LastBinlog: "binlog.000012",
+		}, http.StatusOK, nil
+	}
+	return nil, 0, errors.New("failed")
+}
+
+func (s *mockService) StartBinlogArchive(o openapi.BinlogArchive, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return &openapi.BinlogArchive{
+			Backend:  o.Backend,
+			Bucket:   o.Bucket,
+			Location: o.Location,
+			Status:   StatusRunning,
+		}, http.StatusCreated, nil
+	}
+	return openapi.Message{Code: int32(http.StatusBadRequest), Message: "bucket and location are required"}, http.StatusBadRequest, ErrInvalidRequest
+}
+
+func (s *mockService) StopBinlogArchive(apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return &openapi.BinlogArchive{Status: StatusStopped}, http.StatusOK, nil
+	}
+	return nil, 0, errors.New("failed")
+}
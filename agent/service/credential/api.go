@@ -0,0 +1,25 @@
+package credential
+
+import (
+	"net/http"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Router defines the required methods for binding the api requests to a responses for the MysqlCredential
+// The Router implementation should parse necessary information from the http request,
+// pass the data to a Servicer to perform the required actions, then write the service results to the http response.
+type Router interface {
+	Routes() openapi.Routes
+	RotateCredential(http.ResponseWriter, *http.Request)
+	DiscardCredential(http.ResponseWriter, *http.Request)
+}
+
+// Servicer defines the api actions for the Credential service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type Servicer interface {
+	RotateCredential(openapi.User, string) (interface{}, int, error)
+	DiscardCredential(openapi.User, string) (interface{}, int, error)
+}
@@ -0,0 +1,71 @@
+package credential
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Controller binds http requests to an api service and writes the service results to the http response
+type Controller struct {
+	service Servicer
+}
+
+// NewController creates a default api controller
+func NewController(s Servicer) Router {
+	return &Controller{service: s}
+}
+
+// Routes returns all of the api route for the Controller
+func (c *Controller) Routes() openapi.Routes {
+	return openapi.Routes{
+		{
+			Name:        "RotateCredential",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/credential",
+			HandlerFunc: c.RotateCredential,
+		},
+		{
+			Name:        "DiscardCredential",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/credential/discard",
+			HandlerFunc: c.DiscardCredential,
+		},
+	}
+}
+
+// RotateCredential - change the password of a user and keep the current one
+func (c *Controller) RotateCredential(w http.ResponseWriter, r *http.Request) {
+	user := &openapi.User{}
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.RotateCredential(*user, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// DiscardCredential - discard the password retained by the last rotation
+func (c *Controller) DiscardCredential(w http.ResponseWriter, r *http.Request) {
+	user := &openapi.User{}
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.DiscardCredential(*user, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
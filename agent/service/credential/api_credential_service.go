@@ -0,0 +1,116 @@
+package credential
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+const (
+	// errEmptySecondPassword is ER_SECOND_PASSWORD_CANNOT_BE_EMPTY, reported
+	// when an account without password is asked to retain it
+	errEmptySecondPassword = 3878
+)
+
+var (
+	// ErrUserNotFound is reported when the user does not exist
+	ErrUserNotFound = errors.New("UserNotFound")
+
+	// ErrInvalidCredential is reported when the username or password is missing
+	ErrInvalidCredential = errors.New("InvalidCredential")
+)
+
+// Service is a service that implements the logic for the Servicer
+// This service should implement the business logic for every endpoint for the MysqlCredential API.
+// Include any external packages or services that will be required by this service.
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a credential service
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		DB: db,
+	}
+}
+
+// RotateCredential - change the password of every account of a user and
+// retain the current one, so that clients that have not reloaded their
+// credentials yet can still connect. The retained password is replaced by
+// the next rotation or removed by DiscardCredential.
+func (s *Service) RotateCredential(user openapi.User, apiKey string) (interface{}, int, error) {
+	if user.Username == "" || user.Password == "" {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: "username and password are required"}, http.StatusBadRequest, ErrInvalidCredential
+	}
+	hosts, err := s.hosts(user.Username)
+	if err != nil {
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	if len(hosts) == 0 {
+		return openapi.Message{Code: int32(http.StatusNotFound), Message: fmt.Sprintf("user %q not found", user.Username)}, http.StatusNotFound, ErrUserNotFound
+	}
+	for _, host := range hosts {
+		account := fmt.Sprintf("%s@%s", Quote(user.Username), Quote(host))
+		_, err := s.DB.Exec(fmt.Sprintf("ALTER USER %s IDENTIFIED BY %s RETAIN CURRENT PASSWORD", account, Quote(user.Password)))
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errEmptySecondPassword {
+			_, err = s.DB.Exec(fmt.Sprintf("ALTER USER %s IDENTIFIED BY %s", account, Quote(user.Password)))
+		}
+		if err != nil {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+	}
+	return openapi.User{Username: user.Username}, http.StatusCreated, nil
+}
+
+// DiscardCredential - discard the password retained by the last rotation of
+// every account of a user, once every client uses the new password
+func (s *Service) DiscardCredential(user openapi.User, apiKey string) (interface{}, int, error) {
+	if user.Username == "" {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: "username is required"}, http.StatusBadRequest, ErrInvalidCredential
+	}
+	hosts, err := s.hosts(user.Username)
+	if err != nil {
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	if len(hosts) == 0 {
+		return openapi.Message{Code: int32(http.StatusNotFound), Message: fmt.Sprintf("user %q not found", user.Username)}, http.StatusNotFound, ErrUserNotFound
+	}
+	for _, host := range hosts {
+		account := fmt.Sprintf("%s@%s", Quote(user.Username), Quote(host))
+		if _, err := s.DB.Exec(fmt.Sprintf("ALTER USER %s DISCARD OLD PASSWORD", account)); err != nil {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+	}
+	return openapi.User{Username: user.Username}, http.StatusCreated, nil
+}
+
+// hosts returns the hosts of the accounts of a user
+func (s *Service) hosts(username string) ([]string, error) {
+	rows, err := s.DB.Query("SELECT host FROM mysql.user WHERE user = ?", username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hosts := []string{}
+	for rows.Next() {
+		var host string
+		if err := rows.Scan(&host); err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, rows.Err()
+}
+
+// Quote renders a string literal for statements that do not support
+// placeholders
+func Quote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
@@ -0,0 +1,108 @@
+package credential
+
+import (
+	"database/sql"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CredentialServiceSuite struct {
+	suite.Suite
+	db      *sql.DB
+	mock    sqlmock.Sqlmock
+	Service *Service
+}
+
+func (s *CredentialServiceSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+	s.Service = NewService(s.db)
+}
+
+func (s *CredentialServiceSuite) TearDownTest() {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+	s.db.Close()
+}
+
+func (s *CredentialServiceSuite) Test_RotateCredential() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT host FROM mysql.user WHERE user = ?")).
+		WithArgs("root").
+		WillReturnRows(sqlmock.NewRows([]string{"host"}).AddRow("%").AddRow("localhost"))
+	s.mock.ExpectExec(regexp.QuoteMeta("ALTER USER 'root'@'%' IDENTIFIED BY 'new\\'password' RETAIN CURRENT PASSWORD")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("ALTER USER 'root'@'localhost' IDENTIFIED BY 'new\\'password' RETAIN CURRENT PASSWORD")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r, code, err := s.Service.RotateCredential(openapi.User{Username: "root", Password: "new'password"}, "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	require.Equal(s.T(), openapi.User{Username: "root"}, r)
+}
+
+func (s *CredentialServiceSuite) Test_RotateEmptyPassword() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT host FROM mysql.user WHERE user = ?")).
+		WithArgs("root").
+		WillReturnRows(sqlmock.NewRows([]string{"host"}).AddRow("%"))
+	s.mock.ExpectExec(regexp.QuoteMeta("ALTER USER 'root'@'%' IDENTIFIED BY 'changeme' RETAIN CURRENT PASSWORD")).
+		WillReturnError(&mysql.MySQLError{Number: errEmptySecondPassword, Message: "Empty password can not be retained as second password"})
+	s.mock.ExpectExec(regexp.QuoteMeta("ALTER USER 'root'@'%' IDENTIFIED BY 'changeme'")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, code, err := s.Service.RotateCredential(openapi.User{Username: "root", Password: "changeme"}, "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+}
+
+func (s *CredentialServiceSuite) Test_DiscardCredential() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT host FROM mysql.user WHERE user = ?")).
+		WithArgs("root").
+		WillReturnRows(sqlmock.NewRows([]string{"host"}).AddRow("%").AddRow("localhost"))
+	s.mock.ExpectExec(regexp.QuoteMeta("ALTER USER 'root'@'%' DISCARD OLD PASSWORD")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("ALTER USER 'root'@'localhost' DISCARD OLD PASSWORD")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r, code, err := s.Service.DiscardCredential(openapi.User{Username: "root"}, "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	require.Equal(s.T(), openapi.User{Username: "root"}, r)
+}
+
+func (s *CredentialServiceSuite) Test_DiscardUnknownUser() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT host FROM mysql.user WHERE user = ?")).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"host"}))
+
+	_, code, err := s.Service.DiscardCredential(openapi.User{Username: "unknown"}, "apikey")
+	require.Equal(s.T(), ErrUserNotFound, err)
+	require.Equal(s.T(), http.StatusNotFound, code)
+}
+
+func (s *CredentialServiceSuite) Test_RotateUnknownUser() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT host FROM mysql.user WHERE user = ?")).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"host"}))
+
+	_, code, err := s.Service.RotateCredential(openapi.User{Username: "unknown", Password: "changeme"}, "apikey")
+	require.Equal(s.T(), ErrUserNotFound, err)
+	require.Equal(s.T(), http.StatusNotFound, code)
+}
+
+func (s *CredentialServiceSuite) Test_RotateInvalidCredential() {
+	_, code, err := s.Service.RotateCredential(openapi.User{Username: "agent"}, "apikey")
+	require.Equal(s.T(), ErrInvalidCredential, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func TestCredentialServiceSuite(t *testing.T) {
+	suite.Run(t, &CredentialServiceSuite{})
+}
@@ -0,0 +1,69 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateCredentialSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.User{Username: "agent", Password: "changeme"})
+	r := httptest.NewRequest("POST", "/credential", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.User{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+	assert.Equal(t, "agent", u.Username, "Should return the user")
+	assert.Equal(t, "", u.Password, "Should not return the password")
+}
+
+func TestRotateCredentialFailure(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.User{Username: "unknown", Password: "changeme"})
+	r := httptest.NewRequest("POST", "/credential", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusNotFound, response.StatusCode, "result should fail")
+}
+
+func TestDiscardCredentialSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.User{Username: "agent"})
+	r := httptest.NewRequest("POST", "/credential/discard", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+}
@@ -0,0 +1,23 @@
+package credential
+
+import (
+	"net/http"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+type mockService struct{}
+
+func (s *mockService) RotateCredential(u openapi.User, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return openapi.User{Username: u.Username}, http.StatusCreated, nil
+	}
+	return openapi.Message{Code: int32(http.StatusNotFound), Message: "user not found"}, http.StatusNotFound, ErrUserNotFound
+}
+
+func (s *mockService) DiscardCredential(u openapi.User, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return openapi.User{Username: u.Username}, http.StatusCreated, nil
+	}
+	return openapi.Message{Code: int32(http.StatusNotFound), Message: "user not found"}, http.StatusNotFound, ErrUserNotFound
+}
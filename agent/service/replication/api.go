@@ -0,0 +1,31 @@
+package replication
+
+import (
+	"net/http"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Router defines the required methods for binding the api requests to a responses for the MysqlReplication
+// The Router implementation should parse necessary information from the http request,
+// pass the data to a Servicer to perform the required actions, then write the service results to the http response.
+type Router interface {
+	Routes() openapi.Routes
+	CreateReplication(http.ResponseWriter, *http.Request)
+	CreateReplicationUser(http.ResponseWriter, *http.Request)
+	DemotePrimary(http.ResponseWriter, *http.Request)
+	GetReplication(http.ResponseWriter, *http.Request)
+	PromoteReplica(http.ResponseWriter, *http.Request)
+}
+
+// Servicer defines the api actions for the Replication service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type Servicer interface {
+	CreateReplication(openapi.ReplicationRequest, string) (interface{}, int, error)
+	CreateReplicationUser(openapi.User, string) (interface{}, int, error)
+	DemotePrimary(string) (interface{}, int, error)
+	GetReplication(string) (interface{}, int, error)
+	PromoteReplica(openapi.PromoteRequest, string) (interface{}, int, error)
+}
@@ -0,0 +1,128 @@
+package replication
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Controller binds http requests to an api service and writes the service results to the http response
+type Controller struct {
+	service Servicer
+}
+
+// NewController creates a default api controller
+func NewController(s Servicer) Router {
+	return &Controller{service: s}
+}
+
+// Routes returns all of the api route for the Controller
+func (c *Controller) Routes() openapi.Routes {
+	return openapi.Routes{
+		{
+			Name:        "CreateReplication",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/replication",
+			HandlerFunc: c.CreateReplication,
+		},
+		{
+			Name:        "GetReplication",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/replication",
+			HandlerFunc: c.GetReplication,
+		},
+		{
+			Name:        "CreateReplicationUser",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/replication/user",
+			HandlerFunc: c.CreateReplicationUser,
+		},
+		{
+			Name:        "DemotePrimary",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/replication/demote",
+			HandlerFunc: c.DemotePrimary,
+		},
+		{
+			Name:        "PromoteReplica",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/replication/promote",
+			HandlerFunc: c.PromoteReplica,
+		},
+	}
+}
+
+// CreateReplication - seed the instance and start the replication
+func (c *Controller) CreateReplication(w http.ResponseWriter, r *http.Request) {
+	request := &openapi.ReplicationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.CreateReplication(*request, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// CreateReplicationUser - create the user replicas connect with
+func (c *Controller) CreateReplicationUser(w http.ResponseWriter, r *http.Request) {
+	user := &openapi.User{}
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.CreateReplicationUser(*user, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// GetReplication - get the replication status
+func (c *Controller) GetReplication(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.GetReplication(apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// DemotePrimary - make the primary read-only before a switchover
+func (c *Controller) DemotePrimary(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.DemotePrimary(apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// PromoteReplica - apply a GTID set, stop the replication and accept writes
+func (c *Controller) PromoteReplica(w http.ResponseWriter, r *http.Request) {
+	request := &openapi.PromoteRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.PromoteReplica(*request, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
@@ -0,0 +1,393 @@
+package replication
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+const (
+	// RolePrimary is the role of an instance that does not replicate from a source
+	RolePrimary = "primary"
+
+	// RoleReplica is the role of an instance that replicates from a source
+	RoleReplica = "replica"
+
+	// StateSeeding defines the state of a replica that is being loaded
+	StateSeeding = "Seeding"
+
+	// StateRunning defines the state of a replica with both threads running
+	StateRunning = "Running"
+
+	// StateStopped defines the state of a replica with a stopped thread
+	StateStopped = "Stopped"
+
+	// StateFailed defines the state of a replica that has failed
+	StateFailed = "Failed"
+
+	// SeedNone starts the replication without loading data first
+	SeedNone = "none"
+
+	// SeedDump loads a fresh dump of the source before starting the replication
+	SeedDump = "dump"
+
+	// SeedBackup loads a backup from a store before starting the replication
+	SeedBackup = "backup"
+
+	defaultSourcePort     = 3306
+	defaultPromoteTimeout = 60
+	seedFile              = "seed.sql"
+)
+
+var (
+	// ErrSeedInProgress is reported when a replica is already being seeded
+	ErrSeedInProgress = errors.New("SeedInProgress")
+
+	// ErrInvalidRequest is reported when the replication request is not valid
+	ErrInvalidRequest = errors.New("InvalidRequest")
+
+	// ErrPromoteTimeout is reported when a replica could not apply the GTID set before its promotion
+	ErrPromoteTimeout = errors.New("PromoteTimeout")
+)
+
+// Service is a service that implements the logic for the Servicer
+// This service should implement the business logic for every endpoint for the MysqlReplication API.
+// Include any external packages or services that will be required by this service.
+type Service struct {
+	DB        *sql.DB
+	Replica   backend.Replica
+	Storages  map[string]backend.Storage
+	M         sync.Mutex
+	State     string
+	LastError string
+}
+
+// NewService creates a replication service
+func NewService(db *sql.DB, replica backend.Replica, storages map[string]backend.Storage) *Service {
+	return &Service{
+		DB:       db,
+		Replica:  replica,
+		Storages: storages,
+	}
+}
+
+// CreateReplication - seed the instance and start the replication
+func (s *Service) CreateReplication(request openapi.ReplicationRequest, apiKey string) (interface{}, int, error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	if s.State == StateSeeding {
+		return openapi.Message{Code: int32(http.StatusConflict), Message: "replica is being seeded"}, http.StatusConflict, ErrSeedInProgress
+	}
+	if err := s.validate(request); err != nil {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: err.Error()}, http.StatusBadRequest, ErrInvalidRequest
+	}
+	if request.Seed == "" || request.Seed == SeedNone {
+		if err := s.configure(request); err != nil {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+		s.State = ""
+		s.LastError = ""
+		replication, err := s.status()
+		if err != nil {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+		return replication, http.StatusCreated, nil
+	}
+	s.State = StateSeeding
+	s.LastError = ""
+	go runSeed(s, request)
+	return &openapi.Replication{
+		Role:   RoleReplica,
+		State:  StateSeeding,
+		Source: request.Host,
+	}, http.StatusCreated, nil
+}
+
+// CreateReplicationUser - create the user replicas connect with
+func (s *Service) CreateReplicationUser(user openapi.User, apiKey string) (interface{}, int, error) {
+	if user.Username == "" || user.Password == "" {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: "username and password are required"}, http.StatusBadRequest, ErrInvalidRequest
+	}
+	sqls := []string{
+		fmt.Sprintf("CREATE USER IF NOT EXISTS '%s'@'%%' IDENTIFIED BY '%s'", user.Username, user.Password),
+		fmt.Sprintf("ALTER USER '%s'@'%%' IDENTIFIED BY '%s'", user.Username, user.Password),
+		fmt.Sprintf("GRANT REPLICATION SLAVE, REPLICATION CLIENT, SELECT, RELOAD, LOCK TABLES, SHOW VIEW, EVENT, TRIGGER, PROCESS ON *.* TO '%s'@'%%'", user.Username),
+	}
+	for _, v := range sqls {
+		if _, err := s.DB.Exec(v); err != nil {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+	}
+	return openapi.User{Username: user.Username}, http.StatusCreated, nil
+}
+
+// GetReplication - get the replication status
+func (s *Service) GetReplication(apiKey string) (interface{}, int, error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	if s.State != "" {
+		return &openapi.Replication{
+			Role:      RoleReplica,
+			State:     s.State,
+			LastError: s.LastError,
+		}, http.StatusOK, nil
+	}
+	replication, err := s.status()
+	if err != nil {
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	return replication, http.StatusOK, nil
+}
+
+// DemotePrimary - make the primary read-only before a switchover
+func (s *Service) DemotePrimary(apiKey string) (interface{}, int, error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	if _, err := s.DB.Exec("SET PERSIST super_read_only = ON"); err != nil {
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	replication, err := s.status()
+	if err != nil {
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	return replication, http.StatusCreated, nil
+}
+
+// PromoteReplica - apply a GTID set, stop the replication and accept writes
+func (s *Service) PromoteReplica(request openapi.PromoteRequest, apiKey string) (interface{}, int, error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	if s.State == StateSeeding {
+		return openapi.Message{Code: int32(http.StatusConflict), Message: "replica is being seeded"}, http.StatusConflict, ErrSeedInProgress
+	}
+	gtidSet := request.GtidSet
+	if gtidSet == "" {
+		current, err := s.status()
+		if err != nil {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+		gtidSet = current.GtidRetrieved
+	}
+	timeout := request.Timeout
+	if timeout <= 0 {
+		timeout = defaultPromoteTimeout
+	}
+	if gtidSet != "" {
+		var result sql.NullInt64
+		if err := s.DB.QueryRow("SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", gtidSet, timeout).Scan(&result); err != nil {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+		if !result.Valid || result.Int64 != 0 {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: "GTID set not applied before the timeout"}, http.StatusInternalServerError, ErrPromoteTimeout
+		}
+	}
+	sqls := []string{
+		"STOP REPLICA",
+		"RESET REPLICA ALL",
+		"SET PERSIST super_read_only = OFF",
+		"SET PERSIST read_only = OFF",
+	}
+	for _, v := range sqls {
+		if _, err := s.DB.Exec(v); err != nil {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+	}
+	s.State = ""
+	s.LastError = ""
+	replication, err := s.status()
+	if err != nil {
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	return replication, http.StatusCreated, nil
+}
+
+func (s *Service) validate(request openapi.ReplicationRequest) error {
+	if request.Host == "" || request.Username == "" || request.Password == "" {
+		return errors.New("host, username and password are required")
+	}
+	if request.ServerId < 1 {
+		return fmt.Errorf("server_id must be positive, value: %d", request.ServerId)
+	}
+	switch request.Seed {
+	case "", SeedNone, SeedDump:
+		return nil
+	case SeedBackup:
+		if request.Backup == nil {
+			return errors.New("backup is required to seed from a backup")
+		}
+		if _, ok := s.Storages[request.Backup.Backend]; !ok {
+			return fmt.Errorf("unknown backend %s", request.Backup.Backend)
+		}
+		// a physical backup replaces the data directory while the server
+		// is stopped, it can only be loaded by the restore init container
+		if request.Backup.Method != "" && request.Backup.Method != backend.MethodMysqldump {
+			return fmt.Errorf("a replica cannot be seeded from a %s backup, only mysqldump backups are supported", request.Backup.Method)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown seed %s", request.Seed)
+}
+
+// configure points the instance to its source and starts the replication
+func (s *Service) configure(request openapi.ReplicationRequest) error {
+	port := request.Port
+	if port == 0 {
+		port = defaultSourcePort
+	}
+	sqls := []string{
+		"STOP REPLICA",
+		fmt.Sprintf("SET PERSIST server_id = %d", request.ServerId),
+		fmt.Sprintf(
+			"CHANGE REPLICATION SOURCE TO SOURCE_HOST='%s', SOURCE_PORT=%d, SOURCE_USER='%s', SOURCE_PASSWORD='%s', SOURCE_AUTO_POSITION=1, GET_SOURCE_PUBLIC_KEY=1",
+			request.Host,
+			port,
+			request.Username,
+			request.Password,
+		),
+		"START REPLICA",
+		"SET PERSIST super_read_only = ON",
+	}
+	for _, v := range sqls {
+		if _, err := s.DB.Exec(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// status reads the replication status from SHOW REPLICA STATUS and the
+// executed GTID set of a primary
+func (s *Service) status() (*openapi.Replication, error) {
+	fields, err := s.replicaStatus()
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		var executed string
+		if err := s.DB.QueryRow("SELECT @@GLOBAL.gtid_executed").Scan(&executed); err != nil {
+			return nil, err
+		}
+		return &openapi.Replication{Role: RolePrimary, State: StateRunning, GtidExecuted: executed}, nil
+	}
+	replication := &openapi.Replication{
+		Role:       RoleReplica,
+		State:      StateStopped,
+		Source:     fields["Source_Host"].String,
+		IoRunning:  fields["Replica_IO_Running"].String == "Yes",
+		SqlRunning: fields["Replica_SQL_Running"].String == "Yes",
+		LastError:  fields["Last_IO_Error"].String,
+
+		GtidExecuted:  fields["Executed_Gtid_Set"].String,
+		GtidRetrieved: fields["Retrieved_Gtid_Set"].String,
+	}
+	if replication.LastError == "" {
+		replication.LastError = fields["Last_SQL_Error"].String
+	}
+	if lag := fields["Seconds_Behind_Source"]; lag.Valid {
+		if v, err := strconv.ParseInt(lag.String, 10, 32); err == nil {
+			seconds := int32(v)
+			replication.SecondsBehindSource = &seconds
+		}
+	}
+	switch {
+	case replication.IoRunning && replication.SqlRunning:
+		replication.State = StateRunning
+	case replication.LastError != "":
+		replication.State = StateFailed
+	}
+	return replication, nil
+}
+
+// replicaStatus returns the SHOW REPLICA STATUS columns, or nil when the
+// instance does not replicate
+func (s *Service) replicaStatus() (map[string]sql.NullString, error) {
+	rows, err := s.DB.Query("SHOW REPLICA STATUS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	fields := map[string]sql.NullString{}
+	for i, column := range columns {
+		fields[column] = values[i]
+	}
+	return fields, nil
+}
+
+// runSeed is the routine that seeds the replica and starts the replication
+func runSeed(s *Service, request openapi.ReplicationRequest) {
+	err := seed(s, request)
+	s.M.Lock()
+	defer s.M.Unlock()
+	if err != nil {
+		s.State = StateFailed
+		s.LastError = err.Error()
+		return
+	}
+	s.State = ""
+}
+
+func seed(s *Service, request openapi.ReplicationRequest) error {
+	defer os.Remove(seedFile)
+	var err error
+	if request.Seed == SeedBackup {
+		download := fmt.Sprintf("%s.download", seedFile)
+		packed := fmt.Sprintf("%s.packed", seedFile)
+		defer os.Remove(download)
+		defer os.Remove(packed)
+		err = s.Storages[request.Backup.Backend].Pull(request.Backup, download)
+		var keys map[string][]byte
+		if err == nil {
+			_, keys, err = encrypt.FromRequest(request.Backup.Encryption)
+		}
+		if err == nil {
+			_, err = encrypt.Unseal(download, packed, keys, encrypt.AllowUnencrypted(request.Backup.Encryption))
+		}
+		if err == nil {
+			_, err = compress.Unpack(packed, seedFile)
+		}
+	} else {
+		err = s.Replica.Dump(&request, seedFile)
+	}
+	if err != nil {
+		return err
+	}
+	// The GTID set of the seed replaces the one of the replica
+	sqls := []string{
+		"STOP REPLICA",
+		"SET GLOBAL super_read_only = OFF",
+		"RESET MASTER",
+	}
+	for _, v := range sqls {
+		if _, err := s.DB.Exec(v); err != nil {
+			return err
+		}
+	}
+	if err := s.Replica.Load(seedFile); err != nil {
+		return err
+	}
+	return s.configure(request)
+}
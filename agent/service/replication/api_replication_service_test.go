@@ -0,0 +1,276 @@
+package replication
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/mock"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var replicaStatusColumns = []string{
+	"Source_Host",
+	"Replica_IO_Running",
+	"Replica_SQL_Running",
+	"Last_IO_Error",
+	"Last_SQL_Error",
+	"Seconds_Behind_Source",
+	"Retrieved_Gtid_Set",
+	"Executed_Gtid_Set",
+}
+
+type ReplicationServiceSuite struct {
+	suite.Suite
+	db      *sql.DB
+	mock    sqlmock.Sqlmock
+	Service *Service
+}
+
+func (s *ReplicationServiceSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+	storages := map[string]backend.Storage{
+		"s3": mock.NewStorage(),
+	}
+	s.Service = NewService(s.db, mock.NewReplica(), storages)
+}
+
+func (s *ReplicationServiceSuite) TearDownTest() {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+	s.db.Close()
+}
+
+func (s *ReplicationServiceSuite) expectConfigure() {
+	s.mock.ExpectExec(regexp.QuoteMeta("STOP REPLICA")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST server_id = 2")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(
+		"CHANGE REPLICATION SOURCE TO SOURCE_HOST='10.0.0.12', SOURCE_PORT=3306, SOURCE_USER='replication', SOURCE_PASSWORD='changeme', SOURCE_AUTO_POSITION=1, GET_SOURCE_PUBLIC_KEY=1")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("START REPLICA")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST super_read_only = ON")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func request(seed string) openapi.ReplicationRequest {
+	return openapi.ReplicationRequest{
+		Host:     "10.0.0.12",
+		Username: "replication",
+		Password: "changeme",
+		ServerId: 2,
+		Seed:     seed,
+	}
+}
+
+func (s *ReplicationServiceSuite) Test_CreateReplicationUser() {
+	s.mock.ExpectExec(regexp.QuoteMeta("CREATE USER IF NOT EXISTS 'replication'@'%' IDENTIFIED BY 'changeme'")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta("ALTER USER 'replication'@'%' IDENTIFIED BY 'changeme'")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("GRANT REPLICATION SLAVE, REPLICATION CLIENT")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, code, err := s.Service.CreateReplicationUser(openapi.User{Username: "replication", Password: "changeme"}, "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+}
+
+func (s *ReplicationServiceSuite) Test_CreateReplicationUserFailed() {
+	s.mock.ExpectExec(regexp.QuoteMeta("CREATE USER IF NOT EXISTS 'replication'@'%' IDENTIFIED BY 'changeme'")).
+		WillReturnError(errors.New("error"))
+
+	_, code, err := s.Service.CreateReplicationUser(openapi.User{Username: "replication", Password: "changeme"}, "apikey")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), http.StatusInternalServerError, code)
+}
+
+func (s *ReplicationServiceSuite) Test_CreateReplicationInvalid() {
+	r := request(SeedNone)
+	r.ServerId = 0
+	_, code, err := s.Service.CreateReplication(r, "apikey")
+	require.Equal(s.T(), ErrInvalidRequest, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+
+	r = request(SeedBackup)
+	r.Backup = &openapi.BackupRequest{Backend: "gcp", Bucket: "bucket", Location: "/backup.sql"}
+	_, code, err = s.Service.CreateReplication(r, "apikey")
+	require.Equal(s.T(), ErrInvalidRequest, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+
+	r.Backup = &openapi.BackupRequest{Backend: "s3", Bucket: "bucket", Location: "/backup.xbstream", Method: "xtrabackup"}
+	_, code, err = s.Service.CreateReplication(r, "apikey")
+	require.Equal(s.T(), ErrInvalidRequest, err, "Expected a physical backup to be rejected")
+	require.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func (s *ReplicationServiceSuite) Test_CreateReplicationWhileSeeding() {
+	s.Service.State = StateSeeding
+	_, code, err := s.Service.CreateReplication(request(SeedNone), "apikey")
+	require.Equal(s.T(), ErrSeedInProgress, err)
+	require.Equal(s.T(), http.StatusConflict, code)
+}
+
+func (s *ReplicationServiceSuite) Test_CreateReplicationWithoutSeed() {
+	s.expectConfigure()
+	s.mock.ExpectQuery(regexp.QuoteMeta("SHOW REPLICA STATUS")).
+		WillReturnRows(sqlmock.NewRows(replicaStatusColumns).
+			AddRow("10.0.0.12", "Yes", "Yes", "", "", "0", "uuid:1-10", "uuid:1-10"))
+
+	r, code, err := s.Service.CreateReplication(request(SeedNone), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	replication := r.(*openapi.Replication)
+	require.Equal(s.T(), RoleReplica, replication.Role)
+	require.Equal(s.T(), StateRunning, replication.State)
+	require.Equal(s.T(), int32(0), *replication.SecondsBehindSource)
+}
+
+func (s *ReplicationServiceSuite) Test_CreateReplicationWithDump() {
+	s.mock.ExpectExec(regexp.QuoteMeta("STOP REPLICA")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("SET GLOBAL super_read_only = OFF")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("RESET MASTER")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.expectConfigure()
+
+	r, code, err := s.Service.CreateReplication(request(SeedDump), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	require.Equal(s.T(), StateSeeding, r.(*openapi.Replication).State)
+
+	for i := 0; i < 50; i++ {
+		s.Service.M.Lock()
+		state := s.Service.State
+		s.Service.M.Unlock()
+		if state != StateSeeding {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(s.T(), "", s.Service.State)
+	require.Equal(s.T(), "", s.Service.LastError)
+}
+
+func (s *ReplicationServiceSuite) Test_GetReplicationPrimary() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SHOW REPLICA STATUS")).
+		WillReturnRows(sqlmock.NewRows(replicaStatusColumns))
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT @@GLOBAL.gtid_executed")).
+		WillReturnRows(sqlmock.NewRows([]string{"@@GLOBAL.gtid_executed"}).AddRow("uuid:1-42"))
+
+	r, code, err := s.Service.GetReplication("apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, code)
+	require.Equal(s.T(), RolePrimary, r.(*openapi.Replication).Role)
+	require.Equal(s.T(), "uuid:1-42", r.(*openapi.Replication).GtidExecuted)
+}
+
+func (s *ReplicationServiceSuite) Test_GetReplicationFailed() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SHOW REPLICA STATUS")).
+		WillReturnRows(sqlmock.NewRows(replicaStatusColumns).
+			AddRow("10.0.0.12", "Connecting", "Yes", "error connecting to source", "", nil, "", ""))
+
+	r, code, err := s.Service.GetReplication("apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, code)
+	replication := r.(*openapi.Replication)
+	require.Equal(s.T(), StateFailed, replication.State)
+	require.Equal(s.T(), "error connecting to source", replication.LastError)
+	require.Nil(s.T(), replication.SecondsBehindSource)
+}
+
+func (s *ReplicationServiceSuite) Test_GetReplicationSeeding() {
+	s.Service.State = StateSeeding
+	r, code, err := s.Service.GetReplication("apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, code)
+	require.Equal(s.T(), StateSeeding, r.(*openapi.Replication).State)
+}
+
+func (s *ReplicationServiceSuite) Test_GetReplicationError() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SHOW REPLICA STATUS")).
+		WillReturnError(errors.New("error"))
+
+	_, code, err := s.Service.GetReplication("apikey")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), http.StatusInternalServerError, code)
+}
+
+func (s *ReplicationServiceSuite) expectPrimaryStatus() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SHOW REPLICA STATUS")).
+		WillReturnRows(sqlmock.NewRows(replicaStatusColumns))
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT @@GLOBAL.gtid_executed")).
+		WillReturnRows(sqlmock.NewRows([]string{"@@GLOBAL.gtid_executed"}).AddRow("uuid:1-10"))
+}
+
+func (s *ReplicationServiceSuite) Test_DemotePrimary() {
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST super_read_only = ON")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.expectPrimaryStatus()
+
+	r, code, err := s.Service.DemotePrimary("apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	require.Equal(s.T(), "uuid:1-10", r.(*openapi.Replication).GtidExecuted)
+}
+
+func (s *ReplicationServiceSuite) Test_DemotePrimaryFailed() {
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST super_read_only = ON")).
+		WillReturnError(errors.New("error"))
+
+	_, code, err := s.Service.DemotePrimary("apikey")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), http.StatusInternalServerError, code)
+}
+
+func (s *ReplicationServiceSuite) Test_PromoteReplica() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SHOW REPLICA STATUS")).
+		WillReturnRows(sqlmock.NewRows(replicaStatusColumns).
+			AddRow("10.0.0.12", "No", "Yes", "", "", "0", "uuid:1-10", "uuid:1-9"))
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)")).
+		WithArgs("uuid:1-10", 60).
+		WillReturnRows(sqlmock.NewRows([]string{"result"}).AddRow(0))
+	for _, v := range []string{"STOP REPLICA", "RESET REPLICA ALL", "SET PERSIST super_read_only = OFF", "SET PERSIST read_only = OFF"} {
+		s.mock.ExpectExec(regexp.QuoteMeta(v)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	s.expectPrimaryStatus()
+
+	r, code, err := s.Service.PromoteReplica(openapi.PromoteRequest{}, "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	require.Equal(s.T(), RolePrimary, r.(*openapi.Replication).Role)
+}
+
+func (s *ReplicationServiceSuite) Test_PromoteReplicaTimeout() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)")).
+		WithArgs("uuid:1-12", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"result"}).AddRow(1))
+
+	_, code, err := s.Service.PromoteReplica(openapi.PromoteRequest{GtidSet: "uuid:1-12", Timeout: 5}, "apikey")
+	require.Equal(s.T(), ErrPromoteTimeout, err)
+	require.Equal(s.T(), http.StatusInternalServerError, code)
+}
+
+func (s *ReplicationServiceSuite) Test_PromoteReplicaWhileSeeding() {
+	s.Service.State = StateSeeding
+	_, code, err := s.Service.PromoteReplica(openapi.PromoteRequest{}, "apikey")
+	require.Equal(s.T(), ErrSeedInProgress, err)
+	require.Equal(s.T(), http.StatusConflict, code)
+}
+
+func TestReplicationServiceSuite(t *testing.T) {
+	suite.Run(t, &ReplicationServiceSuite{})
+}
@@ -0,0 +1,213 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReplicationSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("GET", "/replication", nil)
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.Replication{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusOK, response.StatusCode, "result should succeed")
+	assert.Equal(t, RoleReplica, u.Role, "Should be a replica")
+	assert.Equal(t, int32(3), *u.SecondsBehindSource, "Should report the lag")
+}
+
+func TestGetReplicationFailure(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("GET", "/replication", nil)
+
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode, "result should fail")
+}
+
+func TestCreateReplicationSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.ReplicationRequest{
+		Host:     "10.0.0.12",
+		Username: "replication",
+		Password: "changeme",
+		ServerId: 2,
+		Seed:     SeedDump,
+	})
+	r := httptest.NewRequest("POST", "/replication", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.Replication{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+	assert.Equal(t, StateSeeding, u.State, "Should be seeding")
+	assert.Equal(t, "10.0.0.12", u.Source, "Should return the source")
+}
+
+func TestCreateReplicationConflict(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.ReplicationRequest{Host: "10.0.0.12"})
+	r := httptest.NewRequest("POST", "/replication", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusConflict, response.StatusCode, "result should conflict")
+}
+
+func TestCreateReplicationBadPayload(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("POST", "/replication", bytes.NewReader([]byte("{")))
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode, "result should fail")
+}
+
+func TestCreateReplicationUser(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.User{Username: "replication", Password: "changeme"})
+	r := httptest.NewRequest("POST", "/replication/user", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+
+	r = httptest.NewRequest("POST", "/replication/user", bytes.NewReader(body))
+	r.Header.Set("apiKey", "test2")
+	w = httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response = w.Result()
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode, "result should fail")
+}
+
+func TestDemotePrimarySuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("POST", "/replication/demote", nil)
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.Replication{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+	assert.Equal(t, "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5", u.GtidExecuted, "Should report the GTID set")
+}
+
+func TestDemotePrimaryFailure(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("POST", "/replication/demote", nil)
+
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode, "result should fail")
+}
+
+func TestPromoteReplicaSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.PromoteRequest{
+		GtidSet: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5",
+		Timeout: 10,
+	})
+	r := httptest.NewRequest("POST", "/replication/promote", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.Replication{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+	assert.Equal(t, RolePrimary, u.Role, "Should be the primary")
+}
+
+func TestPromoteReplicaFailure(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.PromoteRequest{})
+	r := httptest.NewRequest("POST", "/replication/promote", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode, "result should fail")
+}
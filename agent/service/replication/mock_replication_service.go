@@ -0,0 +1,65 @@
+package replication
+
+import (
+	"errors"
+	"net/http"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+type mockService struct{}
+
+func (s *mockService) CreateReplication(o openapi.ReplicationRequest, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return &openapi.Replication{
+			Role:   RoleReplica,
+			State:  StateSeeding,
+			Source: o.Host,
+		}, http.StatusCreated, nil
+	}
+	return openapi.Message{Code: int32(http.StatusConflict), Message: "replica is being seeded"}, http.StatusConflict, errors.New("replication failed")
+}
+
+func (s *mockService) CreateReplicationUser(o openapi.User, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return openapi.User{Username: o.Username}, http.StatusCreated, nil
+	}
+	return nil, 0, errors.New("user failed")
+}
+
+func (s *mockService) GetReplication(apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		lag := int32(3)
+		return &openapi.Replication{
+			Role:                RoleReplica,
+			State:               StateRunning,
+			Source:              "10.0.0.12",
+			IoRunning:           true,
+			SqlRunning:          true,
+			SecondsBehindSource: &lag,
+		}, http.StatusOK, nil
+	}
+	return nil, 0, errors.New("failed")
+}
+
+func (s *mockService) DemotePrimary(apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return &openapi.Replication{
+			Role:         RolePrimary,
+			State:        StateRunning,
+			GtidExecuted: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5",
+		}, http.StatusCreated, nil
+	}
+	return nil, 0, errors.New("failed")
+}
+
+func (s *mockService) PromoteReplica(o openapi.PromoteRequest, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return &openapi.Replication{
+			Role:         RolePrimary,
+			State:        StateRunning,
+			GtidExecuted: o.GtidSet,
+		}, http.StatusCreated, nil
+	}
+	return openapi.Message{Code: int32(http.StatusInternalServerError), Message: "timeout"}, http.StatusInternalServerError, errors.New("failed")
+}
@@ -0,0 +1,68 @@
+package restore
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/gorilla/mux"
+)
+
+// Controller binds http requests to an api service and writes the service results to the http response
+type Controller struct {
+	service Servicer
+}
+
+// NewController creates a default api controller
+func NewController(s Servicer) Router {
+	return &Controller{service: s}
+}
+
+// Routes returns all of the api route for the Controller
+func (c *Controller) Routes() openapi.Routes {
+	return openapi.Routes{
+		{
+			Name:        "CreateRestore",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/restore",
+			HandlerFunc: c.CreateRestore,
+		},
+		{
+			Name:        "GetRestoreByID",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/restore/{uuid}",
+			HandlerFunc: c.GetRestoreByID,
+		},
+	}
+}
+
+// CreateRestore - restore a backup in the running server
+func (c *Controller) CreateRestore(w http.ResponseWriter, r *http.Request) {
+	request := &openapi.BackupRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.CreateRestore(*request, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// GetRestoreByID - Get the progress of a restore
+func (c *Controller) GetRestoreByID(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Header.Get("apiKey")
+	params := mux.Vars(r)
+	uuid := params["uuid"]
+	result, code, err := c.service.GetRestoreByID(uuid, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
@@ -0,0 +1,185 @@
+package restore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/compress"
+	"github.com/blaqkube/mysql-operator/agent/backend/encrypt"
+	"github.com/blaqkube/mysql-operator/agent/backend/manifest"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	uuid "github.com/hashicorp/go-uuid"
+)
+
+const (
+	// StatusRunning defines the status of a restore that is running
+	StatusRunning = "Running"
+
+	// StatusFailed defines the status of a restore that has failed
+	StatusFailed = "Failed"
+
+	// StatusSucceeded defines the status of a restore that has succeeded
+	StatusSucceeded = "Succeeded"
+
+	// StepPulling is the step that downloads the dump from the store
+	StepPulling = "Pulling"
+
+	// StepLoading is the step that loads the dump in the server
+	StepLoading = "Loading"
+)
+
+var (
+	// ErrInvalidRequest is reported when the restore request is not valid
+	ErrInvalidRequest = errors.New("InvalidRequest")
+
+	// ErrRestoreRunning is reported when a restore is already running
+	ErrRestoreRunning = errors.New("RestoreRunning")
+)
+
+// Service is a service that implements the logic for the Servicer
+// This service should implement the business logic for every endpoint for the MysqlRestore API.
+// Include any external packages or services that will be required by this service.
+type Service struct {
+	Restore  backend.Restore
+	Storages map[string]backend.Storage
+	M        sync.Mutex
+	States   map[string]openapi.Restore
+	// Current is the identifier of the running restore
+	Current string
+}
+
+// NewService creates a restore service
+func NewService(restore backend.Restore, storages map[string]backend.Storage) *Service {
+	return &Service{
+		Restore:  restore,
+		Storages: storages,
+		States:   map[string]openapi.Restore{},
+	}
+}
+
+// CreateRestore - pull a dump from a store and load it in the running server
+func (s *Service) CreateRestore(request openapi.BackupRequest, apiKey string) (interface{}, int, error) {
+	if request.Bucket == "" || request.Location == "" {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: "bucket and location are required"}, http.StatusBadRequest, ErrInvalidRequest
+	}
+	if _, ok := s.Storages[request.Backend]; !ok {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("unknown backend %s", request.Backend)}, http.StatusBadRequest, ErrInvalidRequest
+	}
+	if request.Method != "" && request.Method != backend.MethodMysqldump {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("%s backups can only be restored when an instance is created", request.Method)}, http.StatusBadRequest, ErrInvalidRequest
+	}
+	s.M.Lock()
+	defer s.M.Unlock()
+	if s.Current != "" {
+		return openapi.Message{Code: int32(http.StatusConflict), Message: fmt.Sprintf("restore %s is running", s.Current)}, http.StatusConflict, ErrRestoreRunning
+	}
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	restore := openapi.Restore{
+		Identifier: id,
+		Bucket:     request.Bucket,
+		Location:   request.Location,
+		Status:     StatusRunning,
+		Step:       StepPulling,
+		StartTime:  time.Now(),
+	}
+	s.States[id] = restore
+	s.Current = id
+	go s.run(request, id)
+	return &restore, http.StatusCreated, nil
+}
+
+// GetRestoreByID - Get the progress of a restore
+func (s *Service) GetRestoreByID(uuid, apiKey string) (interface{}, int, error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	restore, ok := s.States[uuid]
+	if ok {
+		return &restore, http.StatusOK, nil
+	}
+	return &openapi.Restore{}, http.StatusNotFound, nil
+}
+
+// run is the routine that pulls the dump and loads it in the server. The
+// dump is decrypted, decompressed and loaded while it is pulled, its checksum
+// is verified once it has been read
+func (s *Service) run(request openapi.BackupRequest, id string) {
+	storage := s.Storages[request.Backend]
+	m := manifest.Lookup(storage, &request)
+	_, keys, err := encrypt.FromRequest(request.Encryption)
+	if err != nil {
+		s.end(id, fmt.Errorf("decryption of %s failed: %v", request.Location, err))
+		return
+	}
+	digest := manifest.NewDigest()
+	pr, pw := io.Pipe()
+	// unblocks the pull when the load stops reading
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(storage.PullStream(&request, pw))
+	}()
+	tee := io.TeeReader(pr, digest)
+	r, _, err := encrypt.NewReader(tee, keys, encrypt.AllowUnencrypted(request.Encryption))
+	if err != nil {
+		s.end(id, fmt.Errorf("decryption of %s failed: %v", request.Location, err))
+		return
+	}
+	reader, _, err := compress.NewReader(r)
+	if err != nil {
+		s.end(id, fmt.Errorf("unpack of %s failed: %v", request.Location, err))
+		return
+	}
+	defer reader.Close()
+	s.step(id, StepLoading)
+	if err := s.Restore.Stream(reader); err != nil {
+		s.end(id, fmt.Errorf("load failed: %v", err))
+		return
+	}
+	if m != nil {
+		// the checksum is computed on the whole object
+		if _, err := io.Copy(ioutil.Discard, tee); err != nil {
+			s.end(id, fmt.Errorf("pull from %s failed: %v", request.Location, err))
+			return
+		}
+		if err := digest.Check(m); err != nil {
+			s.end(id, fmt.Errorf("checksum of %s failed: %v", request.Location, err))
+			return
+		}
+	}
+	s.end(id, nil)
+}
+
+func (s *Service) step(id, step string) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	restore := s.States[id]
+	restore.Step = step
+	s.States[id] = restore
+}
+
+// end records the result of the restore and allows a new one
+func (s *Service) end(id string, err error) {
+	s.M.Lock()
+	defer s.M.Unlock()
+	restore := s.States[id]
+	restore.Status = StatusSucceeded
+	restore.Step = ""
+	if err != nil {
+		log.Printf("Restore %s failed: %v", id, err)
+		restore.Status = StatusFailed
+		restore.Message = err.Error()
+	}
+	t := time.Now()
+	restore.EndTime = &t
+	s.States[id] = restore
+	s.Current = ""
+}
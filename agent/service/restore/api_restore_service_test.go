@@ -0,0 +1,150 @@
+package restore
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/agent/backend/mock"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RestoreServiceSuite struct {
+	suite.Suite
+	restore *mock.Restore
+	Service *Service
+}
+
+func (s *RestoreServiceSuite) SetupTest() {
+	s.restore = mock.NewRestore()
+	storages := map[string]backend.Storage{
+		"s3": mock.NewStorage(),
+	}
+	s.Service = NewService(s.restore, storages)
+}
+
+func request() openapi.BackupRequest {
+	return openapi.BackupRequest{
+		Backend:  "s3",
+		Bucket:   "bucket",
+		Location: "/blue/blue-20210101-000000.sql",
+	}
+}
+
+// wait returns the restore once it has ended
+func (s *RestoreServiceSuite) wait(id string) *openapi.Restore {
+	for i := 0; i < 50; i++ {
+		r, code, err := s.Service.GetRestoreByID(id, "apikey")
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), http.StatusOK, code)
+		restore := r.(*openapi.Restore)
+		if restore.Status != StatusRunning {
+			return restore
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.FailNow(s.T(), "restore has not ended")
+	return nil
+}
+
+func (s *RestoreServiceSuite) Test_CreateRestoreSucceed() {
+	r, code, err := s.Service.CreateRestore(request(), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	restore := r.(*openapi.Restore)
+	require.Equal(s.T(), StatusRunning, restore.Status)
+	require.Equal(s.T(), "bucket", restore.Bucket)
+
+	restore = s.wait(restore.Identifier)
+	require.Equal(s.T(), StatusSucceeded, restore.Status)
+	require.NotNil(s.T(), restore.EndTime)
+	require.Equal(s.T(), "", s.Service.Current, "Expected a new restore to be allowed")
+}
+
+func (s *RestoreServiceSuite) Test_CreateRestoreFailed() {
+	s.restore.Err = errors.New("exit status 1")
+	r, code, err := s.Service.CreateRestore(request(), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+
+	restore := s.wait(r.(*openapi.Restore).Identifier)
+	require.Equal(s.T(), StatusFailed, restore.Status)
+	require.Equal(s.T(), "load failed: exit status 1", restore.Message)
+}
+
+// manifested is a storage that returns an empty backup and a manifest
+type manifested struct {
+	*mock.Storage
+	manifest string
+}
+
+func (m *manifested) PullStream(backup *openapi.BackupRequest, w io.Writer) error {
+	if strings.HasSuffix(backup.Location, ".manifest.json") {
+		_, err := w.Write([]byte(m.manifest))
+		return err
+	}
+	return nil
+}
+
+func (s *RestoreServiceSuite) Test_CreateRestoreChecksum() {
+	storage := &manifested{Storage: mock.NewStorage(), manifest: `{"sha256": "0000", "size": 0}`}
+	s.Service.Storages["s3"] = storage
+	r, code, err := s.Service.CreateRestore(request(), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+
+	restore := s.wait(r.(*openapi.Restore).Identifier)
+	require.Equal(s.T(), StatusFailed, restore.Status)
+	require.Equal(s.T(), "checksum of /blue/blue-20210101-000000.sql failed: ChecksumMismatch", restore.Message)
+
+	// the sha256 of the empty file pulled by the mock
+	storage.manifest = `{"sha256": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "size": 0}`
+	r, _, err = s.Service.CreateRestore(request(), "apikey")
+	require.NoError(s.T(), err)
+	restore = s.wait(r.(*openapi.Restore).Identifier)
+	require.Equal(s.T(), StatusSucceeded, restore.Status)
+}
+
+func (s *RestoreServiceSuite) Test_CreateRestoreConflict() {
+	s.Service.Current = "abcd"
+	_, code, err := s.Service.CreateRestore(request(), "apikey")
+	require.Equal(s.T(), ErrRestoreRunning, err)
+	require.Equal(s.T(), http.StatusConflict, code)
+}
+
+func (s *RestoreServiceSuite) Test_CreateRestoreInvalidRequest() {
+	req := request()
+	req.Backend = "unknown"
+	_, code, err := s.Service.CreateRestore(req, "apikey")
+	require.Equal(s.T(), ErrInvalidRequest, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+
+	req = request()
+	req.Location = ""
+	_, code, err = s.Service.CreateRestore(req, "apikey")
+	require.Equal(s.T(), ErrInvalidRequest, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+
+	req = request()
+	req.Method = "xtrabackup"
+	_, code, err = s.Service.CreateRestore(req, "apikey")
+	require.Equal(s.T(), ErrInvalidRequest, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func (s *RestoreServiceSuite) Test_GetRestoreByIDNotFound() {
+	_, code, err := s.Service.GetRestoreByID("abce", "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusNotFound, code)
+}
+
+func TestRestoreServiceSuite(t *testing.T) {
+	suite.Run(t, &RestoreServiceSuite{})
+}
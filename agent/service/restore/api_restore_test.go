@@ -0,0 +1,105 @@
+package restore
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRestoreSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	b := openapi.BackupRequest{
+		Backend:  "s3",
+		Location: "/loc/backup-1.dmp",
+		Bucket:   "bucket",
+	}
+	data, err := json.Marshal(&b)
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest("POST", "/restore", bytes.NewReader(data))
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.Restore{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+	assert.Equal(t, "/loc/backup-1.dmp", u.Location, "Should return location")
+	assert.Equal(t, StepPulling, u.Step, "Should start pulling the dump")
+}
+
+func TestCreateRestoreConflict(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	data, err := json.Marshal(&openapi.BackupRequest{Backend: "s3", Location: "/loc/backup-1.dmp", Bucket: "bucket"})
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest("POST", "/restore", bytes.NewReader(data))
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	m := &openapi.Message{}
+	err = json.Unmarshal(bodyBytes, m)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusConflict, response.StatusCode, "result should be a conflict")
+	assert.Equal(t, int32(http.StatusConflict), m.Code, "message should report the conflict")
+}
+
+func TestGetRestoreByIDSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("GET", "/restore/abcd", nil)
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.Restore{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusOK, response.StatusCode, "result should succeed")
+	assert.Equal(t, "abcd", u.Identifier, "Should return the restore")
+}
+
+func TestGetRestoreByIDFailure(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("GET", "/restore/abcd", nil)
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode, "result should fail")
+}
@@ -0,0 +1,38 @@
+package restore
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+type mockService struct{}
+
+func (s *mockService) CreateRestore(o openapi.BackupRequest, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return &openapi.Restore{
+			Location:   o.Location,
+			Bucket:     o.Bucket,
+			Status:     StatusRunning,
+			Step:       StepPulling,
+			StartTime:  time.Now(),
+			Identifier: "abcd",
+		}, http.StatusCreated, nil
+	}
+	return openapi.Message{Code: int32(http.StatusConflict), Message: "restore abcd is running"}, http.StatusConflict, ErrRestoreRunning
+}
+
+func (s *mockService) GetRestoreByID(uuid, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		return &openapi.Restore{
+			Location:   "/loc/backup-1.dmp",
+			Bucket:     "bucket",
+			Status:     StatusSucceeded,
+			StartTime:  time.Now(),
+			Identifier: uuid,
+		}, http.StatusOK, nil
+	}
+	return nil, 0, errors.New("failed")
+}
@@ -0,0 +1,27 @@
+package variable
+
+import (
+	"net/http"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Router defines the required methods for binding the api requests to a responses for the MysqlVariable
+// The Router implementation should parse necessary information from the http request,
+// pass the data to a Servicer to perform the required actions, then write the service results to the http response.
+type Router interface {
+	Routes() openapi.Routes
+	GetVariableByName(http.ResponseWriter, *http.Request)
+	PersistVariables(http.ResponseWriter, *http.Request)
+	ResetVariable(http.ResponseWriter, *http.Request)
+}
+
+// Servicer defines the api actions for the Variable service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type Servicer interface {
+	GetVariableByName(string, string) (interface{}, int, error)
+	PersistVariables(openapi.ListVariables, string) (interface{}, int, error)
+	ResetVariable(string, string) (interface{}, int, error)
+}
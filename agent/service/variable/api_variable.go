@@ -0,0 +1,88 @@
+package variable
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+// Controller binds http requests to an api service and writes the service results to the http response
+type Controller struct {
+	service Servicer
+}
+
+// NewController creates a default api controller
+func NewController(s Servicer) Router {
+	return &Controller{service: s}
+}
+
+// Routes returns all of the api route for the Controller
+func (c *Controller) Routes() openapi.Routes {
+	return openapi.Routes{
+		{
+			Name:        "GetVariableByName",
+			Method:      strings.ToUpper("Get"),
+			Pattern:     "/variable/{variable}",
+			HandlerFunc: c.GetVariableByName,
+		},
+		{
+			Name:        "PersistVariables",
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/variable",
+			HandlerFunc: c.PersistVariables,
+		},
+		{
+			Name:        "ResetVariable",
+			Method:      strings.ToUpper("Delete"),
+			Pattern:     "/variable/{variable}",
+			HandlerFunc: c.ResetVariable,
+		},
+	}
+}
+
+// GetVariableByName - get the global value of a server variable
+func (c *Controller) GetVariableByName(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	variable := params["variable"]
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.GetVariableByName(variable, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// PersistVariables - persist server variables with SET PERSIST
+func (c *Controller) PersistVariables(w http.ResponseWriter, r *http.Request) {
+	variables := &openapi.ListVariables{}
+	if err := json.NewDecoder(r.Body).Decode(&variables); err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.PersistVariables(*variables, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
+
+// ResetVariable - reset a persisted variable with RESET PERSIST
+func (c *Controller) ResetVariable(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	variable := params["variable"]
+	apiKey := r.Header.Get("apiKey")
+	result, code, err := c.service.ResetVariable(variable, apiKey)
+	if err != nil && code == 0 {
+		w.WriteHeader(500)
+		return
+	}
+	openapi.EncodeJSONResponse(result, &code, w)
+}
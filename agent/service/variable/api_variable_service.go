@@ -0,0 +1,185 @@
+package variable
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+const (
+	// StatusApplied defines a variable that is used by the server
+	StatusApplied = "applied"
+
+	// StatusPending defines a variable that requires a restart to be used
+	StatusPending = "pending"
+
+	// errReadOnlyVariable is ER_INCORRECT_GLOBAL_LOCAL_VAR, reported when a
+	// variable cannot be changed while the server runs
+	errReadOnlyVariable = 1238
+
+	// errUnknownVariable is ER_UNKNOWN_SYSTEM_VARIABLE
+	errUnknownVariable = 1193
+)
+
+var (
+	// ErrInvalidVariable is reported when a variable name or value is not valid
+	ErrInvalidVariable = errors.New("InvalidVariable")
+
+	// ErrVariableNotFound is reported when the server does not know a variable
+	ErrVariableNotFound = errors.New("VariableNotFound")
+
+	variableName = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)?$`)
+	integerValue = regexp.MustCompile(`^-?[0-9]+$`)
+	sizeValue    = regexp.MustCompile(`^([0-9]+)([kmgt])$`)
+)
+
+// Service is a service that implements the logic for the Servicer
+// This service should implement the business logic for every endpoint for the MysqlVariable API.
+// Include any external packages or services that will be required by this service.
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a variable service
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		DB: db,
+	}
+}
+
+// GetVariableByName - get the global value of a server variable, for
+// instance the version after an upgrade
+func (s *Service) GetVariableByName(name string, apiKey string) (interface{}, int, error) {
+	if !variableName.MatchString(normalizeName(name)) {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("variable %q is not valid", name)}, http.StatusBadRequest, ErrInvalidVariable
+	}
+	value := ""
+	if err := s.DB.QueryRow(fmt.Sprintf("SELECT @@GLOBAL.%s", normalizeName(name))).Scan(&value); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errUnknownVariable {
+			return openapi.Message{Code: int32(http.StatusNotFound), Message: fmt.Sprintf("variable %q is unknown", name)}, http.StatusNotFound, ErrVariableNotFound
+		}
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	return openapi.Variable{Name: name, Value: value, Status: StatusApplied}, http.StatusOK, nil
+}
+
+// PersistVariables - persist server variables with SET PERSIST. A variable
+// that cannot be changed online is reported as pending until the server is
+// restarted with the value from its configuration file.
+func (s *Service) PersistVariables(variables openapi.ListVariables, apiKey string) (interface{}, int, error) {
+	for _, v := range variables.Items {
+		if !variableName.MatchString(normalizeName(v.Name)) || strings.ContainsAny(v.Value, "\n\r") {
+			return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("variable %q is not valid", v.Name)}, http.StatusBadRequest, ErrInvalidVariable
+		}
+	}
+	items := []openapi.Variable{}
+	for _, v := range variables.Items {
+		name := normalizeName(v.Name)
+		_, err := s.DB.Exec(fmt.Sprintf("SET PERSIST %s = %s", name, sqlValue(v.Value)))
+		status := StatusApplied
+		if err != nil {
+			var mysqlErr *mysql.MySQLError
+			if !errors.As(err, &mysqlErr) {
+				return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+			}
+			switch mysqlErr.Number {
+			case errUnknownVariable:
+				return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("variable %q is unknown", v.Name)}, http.StatusBadRequest, ErrInvalidVariable
+			case errReadOnlyVariable:
+				current := ""
+				if err := s.DB.QueryRow(fmt.Sprintf("SELECT @@GLOBAL.%s", name)).Scan(&current); err != nil {
+					return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+				}
+				if normalizeValue(current) != normalizeValue(v.Value) {
+					status = StatusPending
+				}
+			default:
+				return openapi.Message{Code: int32(http.StatusBadRequest), Message: mysqlErr.Message}, http.StatusBadRequest, ErrInvalidVariable
+			}
+		}
+		items = append(items, openapi.Variable{Name: v.Name, Value: v.Value, Status: status})
+	}
+	return openapi.ListVariables{Size: int32(len(items)), Items: items}, http.StatusCreated, nil
+}
+
+// ResetVariable - remove a variable from the persisted ones with RESET
+// PERSIST, so that the server does not use it after a restart, and set it
+// back to its default when it can be changed online. A variable that is not
+// persisted is reset too.
+func (s *Service) ResetVariable(name string, apiKey string) (interface{}, int, error) {
+	if !variableName.MatchString(normalizeName(name)) {
+		return openapi.Message{Code: int32(http.StatusBadRequest), Message: fmt.Sprintf("variable %q is not valid", name)}, http.StatusBadRequest, ErrInvalidVariable
+	}
+	if _, err := s.DB.Exec(fmt.Sprintf("RESET PERSIST IF EXISTS %s", normalizeName(name))); err != nil {
+		return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+	}
+	if _, err := s.DB.Exec(fmt.Sprintf("SET GLOBAL %s = DEFAULT", normalizeName(name))); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) || (mysqlErr.Number != errReadOnlyVariable && mysqlErr.Number != errUnknownVariable) {
+			return openapi.Message{Code: int32(http.StatusInternalServerError), Message: err.Error()}, http.StatusInternalServerError, err
+		}
+	}
+	return openapi.Variable{Name: name, Status: StatusApplied}, http.StatusOK, nil
+}
+
+// normalizeName uses the underscore form of a variable, my.cnf also accepts
+// dashes
+func normalizeName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
+
+// sqlValue renders a value for SET. Numbers are not quoted and sizes like
+// 128M, that are only supported by my.cnf, are converted to bytes
+func sqlValue(value string) string {
+	value = strings.TrimSpace(value)
+	if integerValue.MatchString(value) {
+		return value
+	}
+	if size, ok := parseSize(value); ok {
+		return strconv.FormatInt(size, 10)
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// normalizeValue makes a value from my.cnf comparable to the value
+// reported by the server
+func normalizeValue(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "on", "true", "yes":
+		return "1"
+	case "off", "false", "no":
+		return "0"
+	}
+	if size, ok := parseSize(value); ok {
+		return strconv.FormatInt(size, 10)
+	}
+	return value
+}
+
+func parseSize(value string) (int64, bool) {
+	m := sizeValue.FindStringSubmatch(strings.ToLower(value))
+	if m == nil {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	for _, unit := range "kmgt" {
+		size *= 1024
+		if string(unit) == m[2] {
+			break
+		}
+	}
+	return size, true
+}
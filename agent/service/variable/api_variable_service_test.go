@@ -0,0 +1,158 @@
+package variable
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type VariableServiceSuite struct {
+	suite.Suite
+	db      *sql.DB
+	mock    sqlmock.Sqlmock
+	Service *Service
+}
+
+func (s *VariableServiceSuite) SetupTest() {
+	var err error
+	s.db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+	s.Service = NewService(s.db)
+}
+
+func (s *VariableServiceSuite) TearDownTest() {
+	require.NoError(s.T(), s.mock.ExpectationsWereMet())
+	s.db.Close()
+}
+
+func variables(items ...openapi.Variable) openapi.ListVariables {
+	return openapi.ListVariables{Size: int32(len(items)), Items: items}
+}
+
+func (s *VariableServiceSuite) Test_PersistDynamicVariables() {
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST max_connections = 500")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST innodb_buffer_pool_size = 268435456")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST sql_mode = 'STRICT_ALL_TABLES'")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r, code, err := s.Service.PersistVariables(variables(
+		openapi.Variable{Name: "max_connections", Value: "500"},
+		openapi.Variable{Name: "innodb-buffer-pool-size", Value: "256M"},
+		openapi.Variable{Name: "sql_mode", Value: "STRICT_ALL_TABLES"},
+	), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	result := r.(openapi.ListVariables)
+	require.Equal(s.T(), int32(3), result.Size)
+	for _, v := range result.Items {
+		require.Equal(s.T(), StatusApplied, v.Status)
+	}
+}
+
+func (s *VariableServiceSuite) Test_PersistReadOnlyVariables() {
+	readOnly := &mysql.MySQLError{Number: errReadOnlyVariable, Message: "Variable is a read only variable"}
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST innodb_log_file_size = 268435456")).
+		WillReturnError(readOnly)
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT @@GLOBAL.innodb_log_file_size")).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("50331648"))
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST skip_name_resolve = 'ON'")).
+		WillReturnError(readOnly)
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT @@GLOBAL.skip_name_resolve")).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+
+	r, code, err := s.Service.PersistVariables(variables(
+		openapi.Variable{Name: "innodb_log_file_size", Value: "256M"},
+		openapi.Variable{Name: "skip_name_resolve", Value: "ON"},
+	), "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusCreated, code)
+	result := r.(openapi.ListVariables)
+	require.Equal(s.T(), StatusPending, result.Items[0].Status)
+	require.Equal(s.T(), StatusApplied, result.Items[1].Status)
+}
+
+func (s *VariableServiceSuite) Test_PersistInvalidVariables() {
+	_, code, err := s.Service.PersistVariables(variables(
+		openapi.Variable{Name: "max_connections; DROP DATABASE mysql", Value: "1"},
+	), "apikey")
+	require.Equal(s.T(), ErrInvalidVariable, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST unknown_variable = 1")).
+		WillReturnError(&mysql.MySQLError{Number: errUnknownVariable, Message: "Unknown system variable"})
+	_, code, err = s.Service.PersistVariables(variables(
+		openapi.Variable{Name: "unknown_variable", Value: "1"},
+	), "apikey")
+	require.Equal(s.T(), ErrInvalidVariable, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func (s *VariableServiceSuite) Test_PersistVariablesFailed() {
+	s.mock.ExpectExec(regexp.QuoteMeta("SET PERSIST max_connections = 500")).
+		WillReturnError(errors.New("error"))
+
+	_, code, err := s.Service.PersistVariables(variables(
+		openapi.Variable{Name: "max_connections", Value: "500"},
+	), "apikey")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), http.StatusInternalServerError, code)
+}
+
+func (s *VariableServiceSuite) Test_GetVariableByName() {
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT @@GLOBAL.version")).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("8.0.24"))
+
+	r, code, err := s.Service.GetVariableByName("version", "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, code)
+	require.Equal(s.T(), "8.0.24", r.(openapi.Variable).Value)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta("SELECT @@GLOBAL.unknown_variable")).
+		WillReturnError(&mysql.MySQLError{Number: errUnknownVariable, Message: "Unknown system variable"})
+	_, code, err = s.Service.GetVariableByName("unknown_variable", "apikey")
+	require.Equal(s.T(), ErrVariableNotFound, err)
+	require.Equal(s.T(), http.StatusNotFound, code)
+
+	_, code, err = s.Service.GetVariableByName("version; DROP DATABASE mysql", "apikey")
+	require.Equal(s.T(), ErrInvalidVariable, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func (s *VariableServiceSuite) Test_ResetVariable() {
+	s.mock.ExpectExec(regexp.QuoteMeta("RESET PERSIST IF EXISTS max_connections")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("SET GLOBAL max_connections = DEFAULT")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r, code, err := s.Service.ResetVariable("max-connections", "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, code)
+	require.Equal(s.T(), "max-connections", r.(openapi.Variable).Name)
+
+	s.mock.ExpectExec(regexp.QuoteMeta("RESET PERSIST IF EXISTS innodb_log_file_size")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta("SET GLOBAL innodb_log_file_size = DEFAULT")).
+		WillReturnError(&mysql.MySQLError{Number: errReadOnlyVariable, Message: "Variable 'innodb_log_file_size' is a read only variable"})
+	_, code, err = s.Service.ResetVariable("innodb_log_file_size", "apikey")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), http.StatusOK, code)
+
+	_, code, err = s.Service.ResetVariable("version; DROP DATABASE mysql", "apikey")
+	require.Equal(s.T(), ErrInvalidVariable, err)
+	require.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func TestVariableServiceSuite(t *testing.T) {
+	suite.Run(t, &VariableServiceSuite{})
+}
@@ -0,0 +1,96 @@
+package variable
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistVariablesSuccess(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.ListVariables{
+		Size:  1,
+		Items: []openapi.Variable{{Name: "max_connections", Value: "500"}},
+	})
+	r := httptest.NewRequest("POST", "/variable", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test1")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	u := &openapi.ListVariables{}
+	err = json.Unmarshal(bodyBytes, u)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusCreated, response.StatusCode, "result should succeed")
+	assert.Equal(t, StatusApplied, u.Items[0].Status, "Should be applied")
+}
+
+func TestPersistVariablesFailure(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	body, _ := json.Marshal(openapi.ListVariables{
+		Size:  1,
+		Items: []openapi.Variable{{Name: "unknown", Value: "1"}},
+	})
+	r := httptest.NewRequest("POST", "/variable", bytes.NewReader(body))
+
+	r.Header.Set("apiKey", "test2")
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode, "result should fail")
+}
+
+func TestGetVariableByName(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("GET", "/variable/version", nil)
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	response := w.Result()
+
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		panic(err)
+	}
+	v := &openapi.Variable{}
+	err = json.Unmarshal(bodyBytes, v)
+	assert.Equal(t, err, nil, "Should succeed")
+	assert.Equal(t, http.StatusOK, response.StatusCode, "result should succeed")
+	assert.Equal(t, "8.0.24", v.Value, "Should return the version")
+
+	r = httptest.NewRequest("GET", "/variable/unknown", nil)
+	w = httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode, "result should fail")
+}
+
+func TestResetVariable(t *testing.T) {
+	c := NewController(&mockService{})
+
+	next := openapi.NewRouter(c)
+	r := httptest.NewRequest("DELETE", "/variable/max_connections", nil)
+
+	w := httptest.NewRecorder()
+	next.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode, "result should succeed")
+}
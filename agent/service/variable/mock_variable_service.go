@@ -0,0 +1,32 @@
+package variable
+
+import (
+	"errors"
+	"net/http"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+)
+
+type mockService struct{}
+
+func (s *mockService) GetVariableByName(name string, apikey string) (interface{}, int, error) {
+	if name == "version" {
+		return openapi.Variable{Name: name, Value: "8.0.24", Status: StatusApplied}, http.StatusOK, nil
+	}
+	return openapi.Message{Code: int32(http.StatusNotFound), Message: "unknown variable"}, http.StatusNotFound, ErrVariableNotFound
+}
+
+func (s *mockService) PersistVariables(o openapi.ListVariables, apikey string) (interface{}, int, error) {
+	if apikey == "test1" {
+		items := []openapi.Variable{}
+		for _, v := range o.Items {
+			items = append(items, openapi.Variable{Name: v.Name, Value: v.Value, Status: StatusApplied})
+		}
+		return openapi.ListVariables{Size: int32(len(items)), Items: items}, http.StatusCreated, nil
+	}
+	return openapi.Message{Code: int32(http.StatusBadRequest), Message: "unknown variable"}, http.StatusBadRequest, errors.New("failed")
+}
+
+func (s *mockService) ResetVariable(name string, apikey string) (interface{}, int, error) {
+	return openapi.Variable{Name: name, Status: StatusApplied}, http.StatusOK, nil
+}
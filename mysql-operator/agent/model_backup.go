@@ -6,11 +6,16 @@ import (
 
 // Backup output for a backup request
 type Backup struct {
-	Identifier string     `json:"identifier"`
-	Bucket     string     `json:"bucket"`
-	Location   string     `json:"location"`
-	StartTime  time.Time  `json:"start_time"`
-	EndTime    *time.Time `json:"end_time,omitempty"`
+	Identifier string `json:"identifier"`
+	Bucket     string `json:"bucket"`
+	Location   string `json:"location"`
+	// codec of the dump
+	Compression string `json:"compression,omitempty"`
+	// method of the backup, mysqldump, xtrabackup or clone
+	Method    string     `json:"method,omitempty"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
 	// backup status
-	Status string `json:"status"`
+	Status   string    `json:"status"`
+	Manifest *Manifest `json:"manifest,omitempty"`
 }
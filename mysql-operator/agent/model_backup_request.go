@@ -6,4 +6,11 @@ type BackupRequest struct {
 	Bucket   string   `json:"bucket"`
 	Location string   `json:"location"`
 	Envs     []EnvVar `json:"envs,omitempty"`
+	// codec that compresses the dump while it is written, the codec extension is added to the location
+	Compression string `json:"compression,omitempty"`
+	// method of the backup, a logical dump with mysqldump or a physical backup with xtrabackup or the clone plugin. It defaults to mysqldump
+	Method     string       `json:"method,omitempty"`
+	Encryption *Encryption  `json:"encryption,omitempty"`
+	Dump       *DumpOptions `json:"dump,omitempty"`
+	S3         *S3Options   `json:"s3,omitempty"`
 }
@@ -0,0 +1,23 @@
+package agent
+
+import (
+	"time"
+)
+
+// BinlogArchive archiving of the closed binary logs to a store
+type BinlogArchive struct {
+	Backend string `json:"backend"`
+	Bucket  string `json:"bucket"`
+	// prefix of the archived binary logs and of their index in the bucket
+	Location   string      `json:"location"`
+	Envs       []EnvVar    `json:"envs,omitempty"`
+	S3         *S3Options  `json:"s3,omitempty"`
+	Encryption *Encryption `json:"encryption,omitempty"`
+	// number of seconds after which the binary log in use is closed so that it is archived, 0 keeps it open until the server rotates it
+	FlushInterval int32 `json:"flush_interval,omitempty"`
+	// archive status
+	Status          string     `json:"status,omitempty"`
+	LastBinlog      string     `json:"last_binlog,omitempty"`
+	LastArchiveTime *time.Time `json:"last_archive_time,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+}
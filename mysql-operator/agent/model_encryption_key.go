@@ -0,0 +1,7 @@
+package agent
+
+// EncryptionKey a 32 bytes key, base64 encoded
+type EncryptionKey struct {
+	Id  string `json:"id"`
+	Key string `json:"key"`
+}
@@ -0,0 +1,7 @@
+package agent
+
+// ListVariables struct for ListVariables
+type ListVariables struct {
+	Size  int32      `json:"size,omitempty"`
+	Items []Variable `json:"items,omitempty"`
+}
@@ -0,0 +1,7 @@
+package agent
+
+// PromoteRequest GTID set a replica must apply before its promotion
+type PromoteRequest struct {
+	GtidSet string `json:"gtid_set,omitempty"`
+	Timeout int32  `json:"timeout,omitempty"`
+}
@@ -0,0 +1,12 @@
+package agent
+
+// ReplicationRequest struct for ReplicationRequest
+type ReplicationRequest struct {
+	Host     string         `json:"host"`
+	Port     int32          `json:"port,omitempty"`
+	Username string         `json:"username"`
+	Password string         `json:"password"`
+	ServerId int32          `json:"server_id"`
+	Seed     string         `json:"seed,omitempty"`
+	Backup   *BackupRequest `json:"backup,omitempty"`
+}
@@ -0,0 +1,9 @@
+package agent
+
+// Variable a server variable
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// applied when the variable is used by the server, pending when it requires a restart
+	Status string `json:"status,omitempty"`
+}
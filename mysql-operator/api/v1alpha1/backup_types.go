@@ -10,8 +10,85 @@ type BackupSpec struct {
 	Store string `json:"store"`
 	// Instance to backup.
 	Instance string `json:"instance"`
+	// Compression is the codec that compresses the dump while it is
+	// streamed, none, gzip or zstd. The location ends with the codec
+	// extension
+	// +kubebuilder:validation:Enum=none;gzip;zstd
+	// +optional
+	Compression string `json:"compression,omitempty"`
+	// Method is how the backup is taken, a logical dump with mysqldump or a
+	// hot physical backup with xtrabackup or the clone plugin. A physical
+	// backup can only be restored when an instance is created
+	// +kubebuilder:validation:Enum=mysqldump;xtrabackup;clone
+	// +optional
+	Method string `json:"method,omitempty"`
+	// Dump defines the consistency and the content of a mysqldump backup,
+	// it cannot be used with a physical backup
+	// +optional
+	Dump *DumpSpec `json:"dump,omitempty"`
+	// DeletionPolicy defines what happens to the store object when the
+	// backup is deleted: Delete removes it and Retain keeps it. It defaults
+	// to the deletion policy of the store
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// Location references an object that already exists in the store. The
+	// backup is not taken, it only describes the object so that it can be
+	// restored. The backups created by the discovery of a store set it
+	// +optional
+	Location string `json:"location,omitempty"`
+}
+
+// DumpSpec defines the options of a logical backup
+type DumpSpec struct {
+	// Consistency is single-transaction to dump a consistent snapshot of the
+	// InnoDB tables without locks or lock-all to lock every table during the
+	// dump. It defaults to lock-all
+	// +kubebuilder:validation:Enum=single-transaction;lock-all
+	// +optional
+	Consistency string `json:"consistency,omitempty"`
+	// Databases are the databases that are dumped, every database is dumped
+	// when it is empty
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+	// ExcludeDatabases are the databases that are not dumped, it cannot be
+	// used with Databases
+	// +optional
+	ExcludeDatabases []string `json:"excludeDatabases,omitempty"`
+	// Tables are the tables that are dumped as database.table, they must
+	// belong to the same database
+	// +optional
+	Tables []string `json:"tables,omitempty"`
+	// ExcludeTables are the tables that are not dumped as database.table
+	// +optional
+	ExcludeTables []string `json:"excludeTables,omitempty"`
+	// Routines dumps the stored procedures and functions
+	// +optional
+	Routines bool `json:"routines,omitempty"`
+	// Triggers dumps the triggers, it defaults to true
+	// +optional
+	Triggers *bool `json:"triggers,omitempty"`
+	// Events dumps the events of the scheduler
+	// +optional
+	Events bool `json:"events,omitempty"`
 }
 
+const (
+	// DumpSingleTransaction dumps a consistent snapshot of the InnoDB tables
+	DumpSingleTransaction = "single-transaction"
+	// DumpLockAll locks every table during the dump
+	DumpLockAll = "lock-all"
+)
+
+const (
+	// BackupMethodMysqldump is a logical dump of all the databases
+	BackupMethodMysqldump = "mysqldump"
+	// BackupMethodXtrabackup is a hot physical backup with Percona XtraBackup
+	BackupMethodXtrabackup = "xtrabackup"
+	// BackupMethodClone is a physical backup with the MySQL clone plugin
+	BackupMethodClone = "clone"
+)
+
 const (
 	// BackupFailed the associated backup has failed
 	BackupFailed = "Failed"
@@ -23,6 +100,11 @@ const (
 	BackupStoreNotReady = "StoreNotReady"
 	// BackupMissingVariable some variables are missing
 	BackupMissingVariable = "StoreMissingVariable"
+	// BackupEncryptionKeyError the store encryption keys cannot be used
+	BackupEncryptionKeyError = "EncryptionKeyError"
+	// BackupDeletionFailed the store object of a deleted backup could not
+	// be deleted
+	BackupDeletionFailed = "DeletionFailed"
 	// BackupInstanceAccessError the associated instance could not be accessed
 	BackupInstanceAccessError = "InstanceAccessError"
 	// BackupInstanceNotReady the associated instance is not yet ready
@@ -45,10 +127,36 @@ type BackupDetails struct {
 	Bucket string `json:"bucket,omitempty"`
 	// Location in bucket
 	Location string `json:"location,omitempty"`
+	// Compression of the dump
+	Compression string `json:"compression,omitempty"`
+	// Method of the backup
+	Method string `json:"method,omitempty"`
 	// Start Time
 	StartTime *metav1.Time `json:"backupTime,omitempty"`
 	// End Time
 	EndTime *metav1.Time `json:"endTime,omitempty"`
+	// Manifest describes the object and the server it comes from, it is
+	// written next to the object when the backup succeeds
+	Manifest *BackupManifest `json:"manifest,omitempty"`
+}
+
+// BackupManifest defines the content of the manifest of a backup
+type BackupManifest struct {
+	// SHA256 of the object, hex encoded
+	SHA256 string `json:"sha256,omitempty"`
+	// Size of the object in bytes
+	Size int64 `json:"size,omitempty"`
+	// MySQLVersion is the version of the server
+	MySQLVersion string `json:"mysqlVersion,omitempty"`
+	// GTIDExecuted is the GTID set the backup is consistent with
+	GTIDExecuted string `json:"gtidExecuted,omitempty"`
+	// BinlogFile is the binary log the backup is consistent with
+	BinlogFile string `json:"binlogFile,omitempty"`
+	// BinlogPosition is the position in the binary log the backup is
+	// consistent with
+	BinlogPosition int64 `json:"binlogPosition,omitempty"`
+	// Databases included in the backup
+	Databases []string `json:"databases,omitempty"`
 }
 
 // BackupStatus defines the observed state of Backup
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -30,15 +31,101 @@ const (
 	InstanceStatefulSetCreated = "StatefulSetCreated"
 	// InstanceStatefulSetWaiting the statefulset is not yet reported as ready
 	InstanceStatefulSetWaiting = "StatefulSetWaitingForReady"
+	// InstanceStatefulSetRollingUpdate the statefulset pods are being updated
+	InstanceStatefulSetRollingUpdate = "StatefulSetRollingUpdate"
 	// InstanceStatefulSetReady the statefulset is ready
 	InstanceStatefulSetReady = "StatefulSetReady"
+	// InstanceSpecInvalid the instance specification is not valid
+	InstanceSpecInvalid = "SpecInvalid"
+	// InstanceReplicationSecretInaccessible the secret for the replication could not be accessed
+	InstanceReplicationSecretInaccessible = "ReplicationSecretInaccessible"
+	// InstanceReplicationSecretCreated the secret for the replication has been created
+	InstanceReplicationSecretCreated = "ReplicationSecretCreated"
+	// InstanceReplicationSecretFailed the secret for the replication could not be created
+	InstanceReplicationSecretFailed = "ReplicationSecretFailed"
+	// InstancePrimaryUnavailable the primary cannot be reached through its agent
+	InstancePrimaryUnavailable = "PrimaryUnavailable"
+	// InstanceFailoverSucceeded a replica has been promoted to primary
+	InstanceFailoverSucceeded = "FailoverSucceeded"
+	// InstanceFailoverFailed no replica could be promoted to primary
+	InstanceFailoverFailed = "FailoverFailed"
+	// InstanceConfigMapFailed the configmap for my.cnf could not be accessed, created or updated
+	InstanceConfigMapFailed = "ConfigMapFailed"
+	// InstanceRootSecretInaccessible the secret for the root password could not be accessed
+	InstanceRootSecretInaccessible = "RootSecretInaccessible"
+	// InstanceRootSecretCreated the secret for the root password has been created
+	InstanceRootSecretCreated = "RootSecretCreated"
+	// InstanceRootSecretFailed the secret for the root password could not be created
+	InstanceRootSecretFailed = "RootSecretFailed"
+	// InstanceAgentSecretInaccessible the secret for the agent account could not be accessed
+	InstanceAgentSecretInaccessible = "AgentSecretInaccessible"
+	// InstanceAgentSecretCreated the secret for the agent account has been created
+	InstanceAgentSecretCreated = "AgentSecretCreated"
+	// InstanceAgentSecretFailed the secret for the agent account could not be created
+	InstanceAgentSecretFailed = "AgentSecretFailed"
+	// InstanceServicesFailed the services could not be created or updated
+	InstanceServicesFailed = "ServicesFailed"
+	// InstanceFinalBackupFailed the backup taken before the instance is deleted has failed
+	InstanceFinalBackupFailed = "FinalBackupFailed"
+	// InstanceBackupInaccessible the backup to restore could not be accessed
+	InstanceBackupInaccessible = "BackupInaccessible"
+	// InstanceBackupNotReady the backup to restore has not succeeded yet
+	InstanceBackupNotReady = "BackupNotReady"
+	// InstanceBackupFailed the backup to restore has failed and cannot be used
+	InstanceBackupFailed = "BackupFailed"
+)
+
+const (
+	// DeletionPolicyDelete deletes the instance volumes with the instance
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyRetain keeps the instance volumes when the instance is deleted
+	DeletionPolicyRetain = "Retain"
+	// DeletionPolicyBackupThenDelete takes a backup to the scheduled store
+	// before the instance and its volumes are deleted
+	DeletionPolicyBackupThenDelete = "BackupThenDelete"
+)
+
+const (
+	// ReplicationSeedDump seeds a replica from a fresh dump of the primary
+	ReplicationSeedDump = "dump"
+	// ReplicationSeedStore seeds a replica from a backup in a store
+	ReplicationSeedStore = "store"
+)
+
+const (
+	// MemberRolePrimary is the role of the member that accepts writes
+	MemberRolePrimary = "primary"
+	// MemberRoleReplica is the role of a read replica
+	MemberRoleReplica = "replica"
 )
 
 // RestoreSpec defines the backup location when create a instance with a restore
 type RestoreSpec struct {
+	// BackupRef is the backup the instance is created from. The store, the
+	// bucket and the location are read from the backup, that must have
+	// succeeded. It replaces store and location
+	// +optional
+	BackupRef string `json:"backupRef,omitempty"`
+
 	Store string `json:"store,omitempty"`
 
 	Location string `json:"location,omitempty"`
+
+	// BinlogLocation is the location of the binary logs archived by the
+	// instance the backup comes from, like <prefix>/<instance>-binlog. They
+	// are replayed after the backup up to time or gtidSet
+	// +optional
+	BinlogLocation string `json:"binlogLocation,omitempty"`
+
+	// Time is the point in time the instance is restored to, the binary
+	// logs are replayed up to the last transaction before it
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
+
+	// GTIDSet restricts the replayed binary logs to the transactions of a
+	// GTID set, like 3e11fa47-71ca-11e1-9e33-c80aa9429562:1-1234
+	// +optional
+	GTIDSet string `json:"gtidSet,omitempty"`
 }
 
 // BackupScheduleSpec defines the backup schedule properties
@@ -48,6 +135,71 @@ type BackupScheduleSpec struct {
 
 	// The backup schedule to use for backups
 	Schedule string `json:"schedule,omitempty"`
+
+	// Compression is the codec of the scheduled backups, none, gzip or zstd
+	// +kubebuilder:validation:Enum=none;gzip;zstd
+	// +optional
+	Compression string `json:"compression,omitempty"`
+
+	// Method is the method of the scheduled backups, mysqldump, xtrabackup
+	// or clone
+	// +kubebuilder:validation:Enum=mysqldump;xtrabackup;clone
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Dump defines the options of the scheduled mysqldump backups
+	// +optional
+	Dump *DumpSpec `json:"dump,omitempty"`
+
+	// Retention defines the scheduled backups that are kept. The other
+	// backups that have succeeded are deleted and their deletion policy
+	// applies to their store object
+	// +optional
+	Retention *RetentionSpec `json:"retention,omitempty"`
+}
+
+// RetentionSpec defines the scheduled backups that are kept. A backup is
+// kept when one of the rules selects it
+type RetentionSpec struct {
+	// KeepLast is the number of most recent backups that are kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepLast int32 `json:"keepLast,omitempty"`
+
+	// KeepDaily is the number of days for which the most recent backup of
+	// the day is kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepDaily int32 `json:"keepDaily,omitempty"`
+
+	// KeepWeekly is the number of weeks for which the most recent backup of
+	// the week is kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepWeekly int32 `json:"keepWeekly,omitempty"`
+
+	// KeepMonthly is the number of months for which the most recent backup
+	// of the month is kept
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepMonthly int32 `json:"keepMonthly,omitempty"`
+}
+
+// BinlogArchiveSpec defines the archiving of the binary logs used for
+// point-in-time recovery
+type BinlogArchiveSpec struct {
+	// Enabled ships the closed binary logs of the primary to the
+	// backupSchedule store
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// FlushInterval is the number of seconds after which the binary log in
+	// use is closed so that it is archived. It bounds the data that can be
+	// lost and defaults to 300, 0 keeps the binary logs until MySQL rotates
+	// them
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FlushInterval *int32 `json:"flushInterval,omitempty"`
 }
 
 // MaintenanceScheduleSpec defines the backup schedule properties
@@ -60,6 +212,75 @@ type MaintenanceScheduleSpec struct {
 	Duration int `json:"duration,omitempty"`
 }
 
+// VolumeSpec defines the properties of a volume claimed by the instance
+type VolumeSpec struct {
+	// Size of the volume, it defaults to 500Mi
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+}
+
+// StorageSpec defines the volumes used by the instance
+type StorageSpec struct {
+	// StorageClassName is the StorageClass used by the instance volumes. The
+	// default StorageClass is used when it is not set
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// Data defines the volume that stores the MySQL data
+	// +optional
+	Data VolumeSpec `json:"data,omitempty"`
+
+	// Init defines the volume used to restore backups at startup
+	// +optional
+	Init VolumeSpec `json:"init,omitempty"`
+}
+
+// ResourcesSpec defines the compute resources for each instance container
+type ResourcesSpec struct {
+	// MySQL defines the resources for the mysql container
+	// +optional
+	MySQL corev1.ResourceRequirements `json:"mysql,omitempty"`
+
+	// Agent defines the resources for the agent container
+	// +optional
+	Agent corev1.ResourceRequirements `json:"agent,omitempty"`
+
+	// Exporter defines the resources for the prometheus exporter container
+	// +optional
+	Exporter corev1.ResourceRequirements `json:"exporter,omitempty"`
+}
+
+// ReplicationSpec defines how the read replicas are seeded
+type ReplicationSpec struct {
+	// Seed defines how a new replica is loaded before it starts to
+	// replicate, from a fresh dump of the primary or from a store backup
+	// +kubebuilder:validation:Enum=dump;store
+	// +optional
+	Seed string `json:"seed,omitempty"`
+
+	// Store is the store that contains the backup when seed is store
+	// +optional
+	Store string `json:"store,omitempty"`
+
+	// Location is the backup location in the store when seed is store
+	// +optional
+	Location string `json:"location,omitempty"`
+}
+
+// FailoverSpec defines how the operator replaces a primary that has failed
+type FailoverSpec struct {
+	// Disabled prevents the operator from promoting a replica when the
+	// primary fails
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Delay is the number of seconds the primary pod must be gone or failing
+	// before a replica is promoted. It defaults to 120
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Delay *int32 `json:"delay,omitempty"`
+}
+
 // InstanceSpec defines the desired state of Instance
 type InstanceSpec struct {
 	// Restore when starting from an existing configuration
@@ -68,11 +289,62 @@ type InstanceSpec struct {
 	// Defines the backup schedules
 	BackupSchedule BackupScheduleSpec `json:"backupSchedule,omitempty"`
 
+	// BinlogArchive defines the archiving of the binary logs to the
+	// backupSchedule store for point-in-time recovery
+	// +optional
+	BinlogArchive BinlogArchiveSpec `json:"binlogArchive,omitempty"`
+
 	// Database is the default database name for the instance
 	Database string `json:"database,omitempty"`
 
 	// Defines the backup schedules
 	MaintenanceSchedule MaintenanceScheduleSpec `json:"maintenanceSchedule,omitempty"`
+
+	// Storage defines the size and StorageClass of the instance volumes
+	// +optional
+	Storage StorageSpec `json:"storage,omitempty"`
+
+	// Resources defines the compute resources for the instance containers
+	// +optional
+	Resources ResourcesSpec `json:"resources,omitempty"`
+
+	// Replicas is the number of MySQL servers. The first one is the primary,
+	// the others are read replicas that use GTID based asynchronous
+	// replication. It defaults to 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Replication defines how the read replicas are seeded
+	// +optional
+	Replication ReplicationSpec `json:"replication,omitempty"`
+
+	// Failover defines how a replica is promoted when the primary fails
+	// +optional
+	Failover FailoverSpec `json:"failover,omitempty"`
+
+	// Config defines the MySQL server variables, by name. They are written
+	// in the [mysqld] section of my.cnf and applied online with SET PERSIST.
+	// The ones that cannot be changed online are applied by a restart
+	// operation in the next maintenance window
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+
+	// Version is the MySQL version of the instance, the operator default
+	// version is used when it is not set. A change creates an upgrade
+	// operation that runs in the next maintenance window
+	// +kubebuilder:validation:Pattern=`^[0-9]+\.[0-9]+(\.[0-9]+)?$`
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// DeletionPolicy defines what happens to the data when the instance is
+	// deleted: Delete removes the volumes, Retain keeps them and
+	// BackupThenDelete takes a final backup to the backupSchedule store
+	// before it removes them
+	// +kubebuilder:validation:Enum=Delete;Retain;BackupThenDelete
+	// +kubebuilder:default:=Delete
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
 }
 
 // ScheduleEntry defines schedule properties
@@ -101,12 +373,94 @@ type ScheduleStatus struct {
 	MaintenanceEndTime *metav1.Time `json:"maintenanceEndTime,omitempty"`
 }
 
+// MemberStatus defines the role and replication status of an instance pod
+type MemberStatus struct {
+	// Name of the pod
+	Name string `json:"name"`
+	// Role is primary or replica
+	Role string `json:"role,omitempty"`
+	// State of the replication as reported by the agent, Seeding, Running,
+	// Stopped or Failed
+	State string `json:"state,omitempty"`
+	// Source is the host the replica replicates from, the name of the
+	// primary pod in the headless service
+	Source string `json:"source,omitempty"`
+	// Lag is the replication lag in seconds
+	Lag *int32 `json:"lag,omitempty"`
+	// A human readable message about the last replication error
+	Message string `json:"message,omitempty"`
+}
+
+// ConfigVariableStatus defines a server variable that is not used yet
+type ConfigVariableStatus struct {
+	// Name of the variable
+	Name string `json:"name"`
+	// Value of the variable in the instance configuration
+	Value string `json:"value"`
+}
+
+// ServicesStatus defines the services of an Instance
+type ServicesStatus struct {
+	// Headless is the service that gives the pods a stable DNS name
+	Headless string `json:"headless,omitempty"`
+	// Client is the service applications connect to, it points to the
+	// primary
+	Client string `json:"client,omitempty"`
+	// ReadOnly is the service that spreads connections on the replicas,
+	// it exists when the instance has more than one pod
+	ReadOnly string `json:"readOnly,omitempty"`
+}
+
+// BinlogArchiveStatus defines the progress of the binary logs archive
+type BinlogArchiveStatus struct {
+	// Location is where the binary logs are archived in the store
+	Location string `json:"location,omitempty"`
+	// LastBinlog is the last binary log archived by the primary
+	LastBinlog string `json:"lastBinlog,omitempty"`
+	// LastArchiveTime is when the last binary log has been archived
+	LastArchiveTime *metav1.Time `json:"lastArchiveTime,omitempty"`
+	// A human readable message about the last archive error
+	Message string `json:"message,omitempty"`
+}
+
+// RetentionStatus defines the result of the last prune of the scheduled
+// backups
+type RetentionStatus struct {
+	// LastPruneTime is when backups have last been deleted
+	LastPruneTime *metav1.Time `json:"lastPruneTime,omitempty"`
+	// Pruned lists the backups deleted by the last prune
+	Pruned []string `json:"pruned,omitempty"`
+	// A human readable message about the last prune error
+	Message string `json:"message,omitempty"`
+}
+
+// RestoredBackupStatus defines the backup an instance has been created from
+type RestoredBackupStatus struct {
+	// Backup is the name of the backup resource
+	Backup string `json:"backup,omitempty"`
+	// Store is the store the backup is pulled from
+	Store string `json:"store,omitempty"`
+	// Bucket in the store
+	Bucket string `json:"bucket,omitempty"`
+	// Location in bucket
+	Location string `json:"location,omitempty"`
+	// Method of the backup, a physical backup is restored in the data
+	// directory before the server starts
+	Method string `json:"method,omitempty"`
+}
+
 // InstanceStatus defines the observed state of Instance
 type InstanceStatus struct {
 	// StatefulSet keeps track of the instance Statefulset
 	StatefulSet corev1.ObjectReference `json:"statefulset,omitempty"`
 	// ExporterSecret keeps track of the secret used for the exporter
 	ExporterSecret corev1.ObjectReference `json:"exporter,omitempty"`
+	// ReplicationSecret keeps track of the secret used for the replication
+	ReplicationSecret corev1.ObjectReference `json:"replication,omitempty"`
+	// RootSecret keeps track of the secret used for the root password
+	RootSecret corev1.ObjectReference `json:"root,omitempty"`
+	// AgentSecret keeps track of the secret used for the agent account
+	AgentSecret corev1.ObjectReference `json:"agent,omitempty"`
 	// Defines if the instance is ready
 	Ready metav1.ConditionStatus `json:"ready,omitempty"`
 	// Defines if the store current Reason
@@ -120,12 +474,47 @@ type InstanceStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 	// Schedules provides information about the current running schedules, including backups and maintenance
 	Schedules ScheduleStatus `json:"schedules,omitempty"`
+	// Members provides the role and replication lag of each pod
+	Members []MemberStatus `json:"members,omitempty"`
+	// Primary is the name of the pod that accepts writes
+	Primary string `json:"primary,omitempty"`
+	// Services are the names of the services of the instance
+	Services ServicesStatus `json:"services,omitempty"`
+	// PrimaryLostTime is when the primary was first detected as unavailable
+	PrimaryLostTime *metav1.Time `json:"primaryLostTime,omitempty"`
+	// PendingRestart lists the variables that are only used after a restart
+	PendingRestart []ConfigVariableStatus `json:"pendingRestart,omitempty"`
+	// RestartOperation is the operation that restarts the instance to use
+	// the pending variables
+	RestartOperation string `json:"restartOperation,omitempty"`
+	// PersistedVariables are the names of the variables persisted on the
+	// servers, so that the ones removed from the config are reset
+	PersistedVariables []string `json:"persistedVariables,omitempty"`
+	// Version is the MySQL version the pods run when spec.version is set
+	Version string `json:"version,omitempty"`
+	// UpgradeOperation is the operation that upgrades the instance to
+	// spec.version
+	UpgradeOperation string `json:"upgradeOperation,omitempty"`
+	// FinalBackup is the backup taken when the instance is deleted with the
+	// BackupThenDelete policy
+	FinalBackup string `json:"finalBackup,omitempty"`
+	// BinlogArchive is the progress of the binary logs archive
+	BinlogArchive BinlogArchiveStatus `json:"binlogArchive,omitempty"`
+	// Restore is the restore that is loading a backup in the instance, the
+	// instance stays in maintenance until it ends
+	Restore string `json:"restore,omitempty"`
+	// RestoredBackup is the backup referenced by restore.backupRef that has
+	// seeded the instance. It is kept when the backup is deleted
+	RestoredBackup *RestoredBackupStatus `json:"restoredBackup,omitempty"`
+	// Retention is the result of the last prune of the scheduled backups
+	Retention RetentionStatus `json:"retention,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Instance ready"
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.reason",description="Instance phase"
+// +kubebuilder:printcolumn:name="Primary",type="string",JSONPath=".status.primary",description="Instance primary"
 // +kubebuilder:printcolumn:name="Maintenance",type="boolean",JSONPath=".status.maintenanceMode",description="Instance currently in Maintenance"
 
 // Instance is the Schema for the instances API
@@ -22,6 +22,12 @@ const (
 	OperationTypeRestart OperationType = "restart"
 	// OperationTypeNoOp a do nothing operation
 	OperationTypeNoop OperationType = "noop"
+	// OperationTypeSwitchover promotes a replica and demotes the primary
+	OperationTypeSwitchover OperationType = "switchover"
+	// OperationTypeRotate changes the root, agent and exporter passwords
+	OperationTypeRotate OperationType = "rotate"
+	// OperationTypeUpgrade changes the MySQL version of an instance
+	OperationTypeUpgrade OperationType = "upgrade"
 )
 
 const (
@@ -54,13 +60,19 @@ type OperationSpec struct {
 	// +kubebuilder:default:="maintenance"
 	Mode OperationMode `json:"mode,omitempty"`
 
-	// Type defines the operation type. For now, only noop is supported
-	// +kubebuilder:validation:Enum=noop
+	// Type defines the operation type, noop, restart, rotate, switchover or
+	// upgrade
+	// +kubebuilder:validation:Enum=noop;restart;rotate;switchover;upgrade
 	// +kubebuilder:default:="noop"
 	Type OperationType `json:"type,omitempty"`
 
 	// Defines the instance the operation applies to
 	Instance string `json:"instance,omitempty"`
+
+	// Target is the pod promoted by a switchover. The most advanced replica
+	// is promoted when it is not set
+	// +optional
+	Target string `json:"target,omitempty"`
 }
 
 // OperationStatus defines the observed state of Operation
@@ -74,6 +86,15 @@ type OperationStatus struct {
 	Message string `json:"message,omitempty"`
 	// Conditions provides informations about the the last conditions
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// StartTime is when the operation has been started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// EndTime is when the operation has been executed, with success or not
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+	// Backup is the backup taken before an upgrade
+	Backup string `json:"backup,omitempty"`
+	// Version is the MySQL version an upgrade rolls out. The instance
+	// status only gets it once every pod runs it
+	Version string `json:"version,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,117 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RestoreResourceSpec defines the desired state of Restore. It is not named
+// RestoreSpec because RestoreSpec defines the restore of a new instance
+type RestoreResourceSpec struct {
+	// Instance the backup is loaded in.
+	Instance string `json:"instance"`
+	// Backup is the backup to restore. When it is not set, store and
+	// location are used.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+	// The store the backup is pulled from.
+	// +optional
+	Store string `json:"store,omitempty"`
+	// Location of the backup in the store bucket.
+	// +optional
+	Location string `json:"location,omitempty"`
+}
+
+const (
+	// RestoreFailed the associated restore has failed
+	RestoreFailed = "Failed"
+	// RestoreRunning the associated restore is running
+	RestoreRunning = "Running"
+	// RestoreSpecInvalid the restore does not reference a backup or a location
+	RestoreSpecInvalid = "SpecInvalid"
+	// RestoreBackupAccessError the associated backup could not be accessed
+	RestoreBackupAccessError = "BackupAccessError"
+	// RestoreBackupNotReady the associated backup has not succeeded yet
+	RestoreBackupNotReady = "BackupNotReady"
+	// RestoreBackupPhysical the associated backup is a physical backup that
+	// cannot be loaded in a running instance
+	RestoreBackupPhysical = "BackupPhysical"
+	// RestoreStoreAccessError the associated store could not be accessed
+	RestoreStoreAccessError = "StoreAccessError"
+	// RestoreStoreNotReady the associated store is not yet ready
+	RestoreStoreNotReady = "StoreNotReady"
+	// RestoreMissingVariable some variables are missing
+	RestoreMissingVariable = "StoreMissingVariable"
+	// RestoreEncryptionKeyError the store encryption keys cannot be used
+	RestoreEncryptionKeyError = "EncryptionKeyError"
+	// RestoreInstanceAccessError the associated instance could not be accessed
+	RestoreInstanceAccessError = "InstanceAccessError"
+	// RestoreInstanceNotReady the associated instance is not yet ready or
+	// is restoring another backup
+	RestoreInstanceNotReady = "InstanceNotReady"
+	// RestoreAgentNotFound the agent could not be found
+	RestoreAgentNotFound = "AgentNotFound"
+	// RestoreAgentFailed a request to the agent failed
+	RestoreAgentFailed = "AgentFailed"
+	// RestoreSucceeded the backup has been loaded in the instance
+	RestoreSucceeded = "Succeeded"
+)
+
+// RestoreDetails defines the restored backup and the restore progress
+type RestoreDetails struct {
+	// Internal Identifier
+	Identifier string `json:"identifier,omitempty"`
+	// Bucket
+	Bucket string `json:"bucket,omitempty"`
+	// Location in bucket
+	Location string `json:"location,omitempty"`
+	// Step of a running restore, Pulling or Loading
+	Step string `json:"step,omitempty"`
+	// Start Time
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// End Time
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+}
+
+// RestoreStatus defines the observed state of Restore
+type RestoreStatus struct {
+	// Defines the details for the restore
+	Details *RestoreDetails `json:"details,omitempty"`
+	// Defines if the restore has succeeded
+	Ready metav1.ConditionStatus `json:"ready,omitempty"`
+	// Defines the restore current Reason
+	Reason string `json:"reason,omitempty"`
+	// A human readable message indicating details about why the restore is
+	// in this condition.
+	Message string `json:"message,omitempty"`
+	// Conditions provides informations about the the last conditions
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Instance",type="string",JSONPath=".spec.instance",description="Restored instance"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Restore ready"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.reason",description="Restore phase"
+// +kubebuilder:printcolumn:name="Step",type="string",JSONPath=".status.details.step",description="Restore step"
+
+// Restore is the Schema for the restores API
+type Restore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RestoreResourceSpec `json:"spec,omitempty"`
+	Status RestoreStatus       `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RestoreList contains a list of Restore
+type RestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Restore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Restore{}, &RestoreList{})
+}
@@ -53,12 +53,17 @@ const (
 	BackendBlackhole Backend = "blackhole"
 	// BackendGCP for GCP. A backend used to perform tests
 	BackendGCP Backend = "gcp"
+	// BackendAzure for Azure Blob Storage, the bucket is the container
+	BackendAzure Backend = "azure"
+	// BackendFilesystem for a volume claim mounted in the instances, the
+	// bucket is the directory the claim is mounted in
+	BackendFilesystem Backend = "filesystem"
 )
 
 // StoreSpec defines the desired state of Store
 type StoreSpec struct {
 	// Defines the type of backend to be used for the store.
-	// +kubebuilder:validation:Enum=s3;blackhole;gcp
+	// +kubebuilder:validation:Enum=s3;blackhole;gcp;azure;filesystem
 	Backend Backend `json:"backend,omitempty"`
 	// the store bucket
 	Bucket string `json:"bucket"`
@@ -70,6 +75,80 @@ type StoreSpec struct {
 	// secured stores which should be the case for every store
 	// +optional
 	Envs []corev1.EnvVar `json:"envs,omitempty"`
+	// Encryption defines the keys that encrypt the backups before they are
+	// pushed to the store
+	// +optional
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+	// Filesystem references the volume claim of a filesystem store
+	// +optional
+	Filesystem *FilesystemSpec `json:"filesystem,omitempty"`
+	// S3 defines how to connect to a S3 compatible store, like MinIO or
+	// Ceph, when it is not AWS S3
+	// +optional
+	S3 *S3Spec `json:"s3,omitempty"`
+	// DeletionPolicy defines what happens to the object of a backup when it
+	// is deleted, unless the backup defines its own policy: Delete removes
+	// it and Retain, the default, keeps it
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// Discovery scans the prefix of the store on a regular basis and
+	// creates a read-only backup for every object that is not referenced by
+	// a backup yet, so that it can be restored
+	// +optional
+	Discovery *DiscoverySpec `json:"discovery,omitempty"`
+}
+
+// DiscoverySpec defines how the objects of a store are discovered
+type DiscoverySpec struct {
+	// Interval is the number of seconds between two scans of the store, it
+	// defaults to 3600
+	// +kubebuilder:validation:Minimum=60
+	// +optional
+	Interval *int32 `json:"interval,omitempty"`
+}
+
+// FilesystemSpec defines the volume that keeps the backups of a filesystem
+// store
+type FilesystemSpec struct {
+	// ClaimName is the persistent volume claim, in the store namespace, that
+	// keeps the backups. It must be ReadWriteMany, like a NFS volume, to be
+	// mounted by several instances
+	ClaimName string `json:"claimName"`
+}
+
+// S3Spec defines the connection to a S3 compatible store
+type S3Spec struct {
+	// Endpoint is the URL of the S3 API, e.g. http://minio.minio.svc:9000
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region is the region of the bucket, it overrides AWS_REGION
+	// +optional
+	Region string `json:"region,omitempty"`
+	// ForcePathStyle addresses the buckets in the path of the URLs instead
+	// of the host name, most S3 compatible stores require it
+	// +optional
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+	// CABundle is the PEM encoded certificate authority that signs the
+	// certificate of the endpoint
+	// +optional
+	CABundle string `json:"caBundle,omitempty"`
+}
+
+// EncryptionSpec references the keys that encrypt the backups of a store.
+// Each key of the secret is a key ID and its value a 32 bytes key, raw or
+// base64 encoded
+type EncryptionSpec struct {
+	// SecretName is the secret in the store namespace that contains the keys
+	SecretName string `json:"secretName"`
+	// KeyID is the key of the secret that encrypts new backups. The other
+	// keys decrypt the backups made before it has changed
+	KeyID string `json:"keyID"`
+	// AllowUnencrypted restores the backups of the store that are not
+	// encrypted, like the ones made before the encryption was set. They are
+	// rejected otherwise
+	// +optional
+	AllowUnencrypted bool `json:"allowUnencrypted,omitempty"`
 }
 
 // StoreStatus defines the observed state of Store
@@ -86,6 +165,19 @@ type StoreStatus struct {
 	// A human readable message indicating details about why the store is in
 	// this condition.
 	Conditions []metav1.Condition `json:"Conditions,omitempty"`
+	// Discovery reports the last scan of the store prefix
+	// +optional
+	Discovery *DiscoveryStatus `json:"discovery,omitempty"`
+}
+
+// DiscoveryStatus defines the outcome of the last scan of a store
+type DiscoveryStatus struct {
+	// LastScanTime is the time the store has been scanned for the last time
+	LastScanTime *metav1.Time `json:"lastScanTime,omitempty"`
+	// Discovered is the number of backups the last scan has created
+	Discovered int32 `json:"discovered,omitempty"`
+	// A human readable message indicating how the last scan went
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/blaqkube/mysql-operator/agent/backend"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 )
@@ -19,12 +22,13 @@ type BackupReconciler struct {
 	Log        logr.Logger
 	Scheme     *runtime.Scheme
 	Properties StatefulSetProperties
+	Storages   map[string]backend.Storage
 }
 
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 // +kubebuilder:rbac:groups="apps",resources=statefulsets,verbs=get;list;watch
-// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=stores,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=instances,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=backups,verbs=get;list;watch;create;update;patch;delete
@@ -43,16 +47,30 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	bm := &BackupManager{
+		Context:     ctx,
+		Reconciler:  r,
+		TimeManager: NewTimeManager(),
+	}
+	if !backup.DeletionTimestamp.IsZero() {
+		return bm.finalizeBackup(backup)
+	}
+	if !controllerutil.ContainsFinalizer(backup, backupFinalizer) {
+		controllerutil.AddFinalizer(backup, backupFinalizer)
+		if err := r.Update(ctx, backup); err != nil {
+			log.Error(err, "Unable to add the finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
 	if backup.Status.Reason == mysqlv1alpha1.BackupSucceeded ||
 		backup.Status.Reason == mysqlv1alpha1.BackupFailed ||
 		backup.Status.Reason == mysqlv1alpha1.BackupNotImplemented {
 		return ctrl.Result{}, nil
 	}
 
-	bm := &BackupManager{
-		Context:     ctx,
-		Reconciler:  r,
-		TimeManager: NewTimeManager(),
+	if backup.Spec.Location != "" {
+		return bm.ImportBackup(backup)
 	}
 
 	if backup.Status.Reason == mysqlv1alpha1.BackupRunning {
@@ -111,6 +129,9 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		case ErrMissingVariable:
 			condition.Reason = mysqlv1alpha1.BackupMissingVariable
 			condition.Message = "Backup environment variable missing from store"
+		case ErrEncryptionKeyNotFound, ErrEncryptionKeyInvalid:
+			condition.Reason = mysqlv1alpha1.BackupEncryptionKeyError
+			condition.Message = fmt.Sprintf("Backup store encryption keys cannot be used: %v", err)
 		default:
 			condition.Reason = mysqlv1alpha1.BackupAgentFailed
 			condition.Message = fmt.Sprintf("Unexpected failure with agent: %v", err)
@@ -125,19 +146,13 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		Message:            fmt.Sprintf("Backup started on %s with success. Now monitoring progress", backup.Spec.Instance),
 	}
 
-	return bm.setBackupCondition(
-		backup,
-		condition,
-		&mysqlv1alpha1.BackupDetails{
-			Identifier: b.Identifier,
-			Location:   b.Location,
-			StartTime:  b.StartTime,
-		})
+	return bm.setBackupCondition(backup, condition, b)
 }
 
 // SetupWithManager configure type of events the manager should watch
 func (r *BackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&mysqlv1alpha1.Backup{}).
+		Owns(&corev1.Pod{}).
 		Complete(r)
 }
@@ -8,11 +8,14 @@ import (
 	"github.com/go-logr/zapr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/blaqkube/mysql-operator/agent/backend"
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
@@ -47,4 +50,209 @@ var _ = Describe("Backup Controller", func() {
 
 	})
 
+	It("Delete the store object with the backup", func() {
+		ctx := context.Background()
+		store := mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "store-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Bucket:         "pong",
+				DeletionPolicy: mysqlv1alpha1.DeletionPolicyDelete,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &store)).To(Succeed())
+		backup := mysqlv1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "backup-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.BackupSpec{
+				Store:    store.Name,
+				Instance: "instance",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &backup)).To(Succeed())
+		backup.Status = mysqlv1alpha1.BackupStatus{
+			Reason: mysqlv1alpha1.BackupSucceeded,
+			Details: &mysqlv1alpha1.BackupDetails{
+				Bucket:   "pong",
+				Location: "/instance-20210301-000000.sql",
+				Manifest: &mysqlv1alpha1.BackupManifest{SHA256: "e3b0c442"},
+			},
+		}
+		Expect(k8sClient.Status().Update(ctx, &backup)).To(Succeed())
+
+		storage := NewStorage(storeMockStatusFailDel)
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &BackupReconciler{
+			Client:   k8sClient,
+			Log:      zapr.NewLogger(zapLog),
+			Scheme:   scheme.Scheme,
+			Storages: map[string]backend.Storage{"s3": storage},
+		}
+		backupName := types.NamespacedName{Namespace: backup.Namespace, Name: backup.Name}
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: backupName})).To(Equal(ctrl.Result{}))
+		response := mysqlv1alpha1.Backup{}
+		Expect(k8sClient.Get(ctx, backupName, &response)).To(Succeed())
+		Expect(response.Finalizers).To(ContainElement(backupFinalizer))
+
+		Expect(k8sClient.Delete(ctx, &response)).To(Succeed())
+		_, err := reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: backupName})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(k8sClient.Get(ctx, backupName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.BackupDeletionFailed))
+
+		storage.Status = storeMockStatusSucceed
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: backupName})).To(Equal(ctrl.Result{}))
+		Expect(storage.Deleted).To(Equal([]string{
+			"/instance-20210301-000000.sql",
+			"/instance-20210301-000000.sql.manifest.json",
+		}))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, backupName, &response))).To(BeTrue())
+	})
+
+	It("Delete the object of a filesystem store with a pod", func() {
+		ctx := context.Background()
+		store := mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "store-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend:        mysqlv1alpha1.BackendFilesystem,
+				Bucket:         "backups",
+				Filesystem:     &mysqlv1alpha1.FilesystemSpec{ClaimName: "nfs"},
+				DeletionPolicy: mysqlv1alpha1.DeletionPolicyDelete,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &store)).To(Succeed())
+		backup := mysqlv1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "backup-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.BackupSpec{
+				Store:    store.Name,
+				Instance: "instance",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &backup)).To(Succeed())
+		backup.Status = mysqlv1alpha1.BackupStatus{
+			Reason: mysqlv1alpha1.BackupSucceeded,
+			Details: &mysqlv1alpha1.BackupDetails{
+				Bucket:   "backups",
+				Location: "/instance-20210301-000000.sql",
+				Manifest: &mysqlv1alpha1.BackupManifest{SHA256: "e3b0c442"},
+			},
+		}
+		Expect(k8sClient.Status().Update(ctx, &backup)).To(Succeed())
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &BackupReconciler{
+			Client:     k8sClient,
+			Log:        zapr.NewLogger(zapLog),
+			Scheme:     scheme.Scheme,
+			Properties: StatefulSetProperties{AgentVersion: "latest"},
+		}
+		backupName := types.NamespacedName{Namespace: backup.Namespace, Name: backup.Name}
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: backupName})).To(Equal(ctrl.Result{}))
+		response := mysqlv1alpha1.Backup{}
+		Expect(k8sClient.Get(ctx, backupName, &response)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, &response)).To(Succeed())
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: backupName})).To(Equal(ctrl.Result{RequeueAfter: storeCheckPollingInterval}))
+
+		pod := corev1.Pod{}
+		podName := types.NamespacedName{Namespace: backup.Namespace, Name: backup.Name + "-delete"}
+		Expect(k8sClient.Get(ctx, podName, &pod)).To(Succeed())
+		Expect(pod.Spec.Containers[0].Command).To(Equal([]string{
+			"./mysql-agent",
+			"delete",
+			"/instance-20210301-000000.sql",
+			"/instance-20210301-000000.sql.manifest.json",
+		}))
+		Expect(pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("nfs"))
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: backupName})).To(Equal(ctrl.Result{RequeueAfter: storeCheckPollingInterval}))
+
+		pod.Status.Phase = corev1.PodSucceeded
+		Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: backupName})).To(Equal(ctrl.Result{}))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, backupName, &response))).To(BeTrue())
+	})
+
+	It("Import a backup that references a store object", func() {
+		ctx := context.Background()
+		store := mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "store-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Bucket: "pong",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &store)).To(Succeed())
+		backup := mysqlv1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "backup-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.BackupSpec{
+				Store:    store.Name,
+				Instance: "blue",
+				Location: "/blue-20210301-000000.tar.zst",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &backup)).To(Succeed())
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &BackupReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+		}
+		backupName := types.NamespacedName{Namespace: backup.Namespace, Name: backup.Name}
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: backupName})).To(Equal(ctrl.Result{}))
+		response := mysqlv1alpha1.Backup{}
+		Expect(k8sClient.Get(ctx, backupName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.BackupSucceeded))
+		Expect(response.Status.Details.Bucket).To(Equal("pong"))
+		Expect(response.Status.Details.Method).To(Equal(mysqlv1alpha1.BackupMethodClone))
+		Expect(response.Status.Details.Compression).To(Equal("zstd"))
+	})
+
+	It("Resolve the deletion policy of a backup", func() {
+		backup := &mysqlv1alpha1.Backup{}
+		store := &mysqlv1alpha1.Store{}
+		Expect(backupDeletionPolicy(backup, nil)).To(Equal(mysqlv1alpha1.DeletionPolicyRetain))
+		Expect(backupDeletionPolicy(backup, store)).To(Equal(mysqlv1alpha1.DeletionPolicyRetain))
+		store.Spec.DeletionPolicy = mysqlv1alpha1.DeletionPolicyDelete
+		Expect(backupDeletionPolicy(backup, store)).To(Equal(mysqlv1alpha1.DeletionPolicyDelete))
+		backup.Spec.DeletionPolicy = mysqlv1alpha1.DeletionPolicyRetain
+		Expect(backupDeletionPolicy(backup, store)).To(Equal(mysqlv1alpha1.DeletionPolicyRetain))
+	})
+
+	It("Convert the dump options of a backup", func() {
+		Expect(dumpOptions(nil)).To(BeNil())
+		triggers := false
+		options := dumpOptions(&mysqlv1alpha1.DumpSpec{
+			Consistency:   mysqlv1alpha1.DumpSingleTransaction,
+			Databases:     []string{"blue"},
+			ExcludeTables: []string{"blue.sessions"},
+			Routines:      true,
+			Triggers:      &triggers,
+		})
+		Expect(options.Consistency).To(Equal("single-transaction"))
+		Expect(options.Databases).To(Equal([]string{"blue"}))
+		Expect(options.ExcludeTables).To(Equal([]string{"blue.sessions"}))
+		Expect(options.Routines).To(BeTrue())
+		Expect(*options.Triggers).To(BeFalse())
+		Expect(options.Events).To(BeFalse())
+	})
+
+	It("Locate the manifest of a backup", func() {
+		Expect(manifestLocation("/backups/blue-20210301-000000.sql.zst")).To(Equal("/backups/blue-20210301-000000.sql.zst.manifest.json"))
+	})
+
 })
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blaqkube/mysql-operator/agent/backend"
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	// backupFinalizer keeps the backup until its deletion policy has been
+	// applied to the store object
+	backupFinalizer = "mysql.blaqkube.io/backup"
+)
+
+// backupDeletionPolicy returns the deletion policy of a backup, it defaults
+// to the policy of its store and then to Retain
+func backupDeletionPolicy(backup *mysqlv1alpha1.Backup, store *mysqlv1alpha1.Store) string {
+	if backup.Spec.DeletionPolicy != "" {
+		return backup.Spec.DeletionPolicy
+	}
+	if store != nil && store.Spec.DeletionPolicy != "" {
+		return store.Spec.DeletionPolicy
+	}
+	return mysqlv1alpha1.DeletionPolicyRetain
+}
+
+// ObjectManager deletes the objects of the backups from the stores
+type ObjectManager struct {
+	Context  context.Context
+	Client   client.Client
+	Log      logr.Logger
+	Storages map[string]backend.Storage
+}
+
+// DeleteObject deletes the object of a backup and its manifest from the
+// store with the store credentials
+func (om *ObjectManager) DeleteObject(store *mysqlv1alpha1.Store, backup *mysqlv1alpha1.Backup) error {
+	storage := "s3"
+	if store.Spec.Backend != "" {
+		storage = string(store.Spec.Backend)
+	}
+	s, ok := om.Storages[storage]
+	if !ok {
+		return ErrNotImplemented
+	}
+	em := &EnvManager{
+		Client: om.Client,
+		Log:    om.Log,
+	}
+	envs, err := em.GetEnvVars(om.Context, *store)
+	if err != nil {
+		return err
+	}
+	e := []openapi.EnvVar{}
+	for k := range envs {
+		e = append(e, openapi.EnvVar{Name: k, Value: envs[k]})
+	}
+	request := &openapi.BackupRequest{
+		Bucket:   backup.Status.Details.Bucket,
+		Location: backup.Status.Details.Location,
+		Envs:     e,
+		S3:       storageS3Options(store),
+	}
+	if err := s.Delete(request); err != nil {
+		return err
+	}
+	if backup.Status.Details.Manifest != nil {
+		request.Location = manifestLocation(backup.Status.Details.Location)
+		return s.Delete(request)
+	}
+	return nil
+}
+
+// finalizeBackup applies the deletion policy to the store object and
+// releases the backup. A failure is reported as a condition and retried
+func (bm *BackupManager) finalizeBackup(backup *mysqlv1alpha1.Backup) (ctrl.Result, error) {
+	log := bm.Reconciler.Log.WithValues("namespace", backup.Namespace, "backup", backup.Name)
+	if !controllerutil.ContainsFinalizer(backup, backupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	if backup.Status.Details != nil && backup.Status.Details.Location != "" &&
+		backup.Spec.DeletionPolicy != mysqlv1alpha1.DeletionPolicyRetain {
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.BackupDeletionFailed,
+		}
+		store := &mysqlv1alpha1.Store{}
+		storeName := types.NamespacedName{Namespace: backup.Namespace, Name: backup.Spec.Store}
+		err := bm.Reconciler.Get(bm.Context, storeName, store)
+		if err != nil && !errors.IsNotFound(err) {
+			condition.Message = fmt.Sprintf("Cannot get store %s: %v", backup.Spec.Store, err)
+			return bm.setBackupCondition(backup, condition, nil)
+		}
+		if err != nil {
+			// the credentials of a deleted store are not known anymore
+			store = nil
+		}
+		policy := backupDeletionPolicy(backup, store)
+		if policy == mysqlv1alpha1.DeletionPolicyDelete && store == nil {
+			condition.Message = fmt.Sprintf("Store %s not found, set deletionPolicy to Retain to keep the object", backup.Spec.Store)
+			return bm.setBackupCondition(backup, condition, nil)
+		}
+		if policy == mysqlv1alpha1.DeletionPolicyDelete && store.Spec.Backend == mysqlv1alpha1.BackendFilesystem {
+			done, err := bm.deleteFilesystemObject(store, backup)
+			if err != nil {
+				log.Info(fmt.Sprintf("Unable to delete the store object, error: %v", err))
+				condition.Message = fmt.Sprintf("Cannot delete %s from store %s, set deletionPolicy to Retain to keep the object: %v", backup.Status.Details.Location, store.Name, err)
+				return bm.setBackupCondition(backup, condition, nil)
+			}
+			if !done {
+				return ctrl.Result{RequeueAfter: storeCheckPollingInterval}, nil
+			}
+			log.Info("Store object deleted", "location", backup.Status.Details.Location)
+		} else if policy == mysqlv1alpha1.DeletionPolicyDelete {
+			om := &ObjectManager{
+				Context:  bm.Context,
+				Client:   bm.Reconciler.Client,
+				Log:      bm.Reconciler.Log,
+				Storages: bm.Reconciler.Storages,
+			}
+			if err := om.DeleteObject(store, backup); err != nil {
+				log.Info(fmt.Sprintf("Unable to delete the store object, error: %v", err))
+				condition.Message = fmt.Sprintf("Cannot delete %s from store %s, set deletionPolicy to Retain to keep the object: %v", backup.Status.Details.Location, store.Name, err)
+				return bm.setBackupCondition(backup, condition, nil)
+			}
+			log.Info("Store object deleted", "location", backup.Status.Details.Location)
+		}
+	}
+	controllerutil.RemoveFinalizer(backup, backupFinalizer)
+	return ctrl.Result{}, client.IgnoreNotFound(bm.Reconciler.Update(bm.Context, backup))
+}
@@ -27,6 +27,58 @@ var (
 	ErrBackupRunning = errors.New("BackupRunning")
 )
 
+// compressionExtension returns the extension the agent adds to the location
+// of a compressed dump
+func compressionExtension(compression string) string {
+	switch compression {
+	case "gzip":
+		return ".gz"
+	case "zstd":
+		return ".zst"
+	}
+	return ""
+}
+
+// methodExtension returns the extension of the location of a backup taken
+// with the method
+func methodExtension(method string) string {
+	switch method {
+	case mysqlv1alpha1.BackupMethodXtrabackup:
+		return ".xbstream"
+	case mysqlv1alpha1.BackupMethodClone:
+		return ".tar"
+	}
+	return ".sql"
+}
+
+// manifestLocation returns the location of the manifest the agent writes
+// next to a backup
+func manifestLocation(location string) string {
+	return location + ".manifest.json"
+}
+
+// isPhysical returns true when the method takes a physical backup
+func isPhysical(method string) bool {
+	return method == mysqlv1alpha1.BackupMethodXtrabackup || method == mysqlv1alpha1.BackupMethodClone
+}
+
+// dumpOptions returns the agent options of a logical backup
+func dumpOptions(spec *mysqlv1alpha1.DumpSpec) *agent.DumpOptions {
+	if spec == nil {
+		return nil
+	}
+	return &agent.DumpOptions{
+		Consistency:      spec.Consistency,
+		Databases:        spec.Databases,
+		ExcludeDatabases: spec.ExcludeDatabases,
+		Tables:           spec.Tables,
+		ExcludeTables:    spec.ExcludeTables,
+		Routines:         spec.Routines,
+		Triggers:         spec.Triggers,
+		Events:           spec.Events,
+	}
+}
+
 // BackupManager provides methods to manage the backup subcomponents
 type BackupManager struct {
 	Context     context.Context
@@ -105,13 +157,26 @@ func (bm *BackupManager) MonitorBackup(backup *mysqlv1alpha1.Backup) (*mysqlv1al
 		return b, ErrBackupRunning
 	}
 	details := &mysqlv1alpha1.BackupDetails{
-		Identifier: data.Identifier,
-		Bucket:     data.Bucket,
-		StartTime:  &metav1.Time{Time: data.StartTime},
-		Location:   data.Location,
+		Identifier:  data.Identifier,
+		Bucket:      data.Bucket,
+		StartTime:   &metav1.Time{Time: data.StartTime},
+		Location:    data.Location,
+		Compression: data.Compression,
+		Method:      data.Method,
 	}
 	if data.EndTime != nil {
-		b.EndTime = &metav1.Time{Time: *data.EndTime}
+		details.EndTime = &metav1.Time{Time: *data.EndTime}
+	}
+	if data.Manifest != nil {
+		details.Manifest = &mysqlv1alpha1.BackupManifest{
+			SHA256:         data.Manifest.Sha256,
+			Size:           data.Manifest.Size,
+			MySQLVersion:   data.Manifest.MysqlVersion,
+			GTIDExecuted:   data.Manifest.GtidExecuted,
+			BinlogFile:     data.Manifest.BinlogFile,
+			BinlogPosition: data.Manifest.BinlogPosition,
+			Databases:      data.Manifest.Databases,
+		}
 	}
 	if data.Status == "Succeeded" {
 		return details, nil
@@ -160,12 +225,21 @@ func (bm *BackupManager) CreateBackup(backup *mysqlv1alpha1.Backup) (*mysqlv1alp
 	for k, v := range envs {
 		agentEnvs = append(agentEnvs, agent.EnvVar{Name: k, Value: v})
 	}
+	encryption, err := em.GetEncryption(bm.Context, *store)
+	if err != nil {
+		return nil, err
+	}
 
 	payload := agent.BackupRequest{
-		Backend:  string(store.Spec.Backend),
-		Bucket:   store.Spec.Bucket,
-		Location: fmt.Sprintf("%s/%s-%s.sql", store.Spec.Prefix, backup.Spec.Instance, time.Now().Format("20060102-150405")),
-		Envs:     agentEnvs,
+		Backend:     string(store.Spec.Backend),
+		Bucket:      store.Spec.Bucket,
+		Location:    fmt.Sprintf("%s/%s-%s%s%s", store.Spec.Prefix, backup.Spec.Instance, time.Now().Format("20060102-150405"), methodExtension(backup.Spec.Method), compressionExtension(backup.Spec.Compression)),
+		Envs:        agentEnvs,
+		Compression: backup.Spec.Compression,
+		Method:      backup.Spec.Method,
+		Encryption:  encryption,
+		Dump:        dumpOptions(backup.Spec.Dump),
+		S3:          s3Options(store),
 	}
 
 	b, response, err := api.MysqlApi.CreateBackup(bm.Context, payload, nil)
@@ -182,10 +256,12 @@ func (bm *BackupManager) CreateBackup(backup *mysqlv1alpha1.Backup) (*mysqlv1alp
 		return nil, ErrAgentRequestFailed
 	}
 	return &mysqlv1alpha1.BackupDetails{
-		Identifier: b.Identifier,
-		Bucket:     b.Bucket,
-		StartTime:  &metav1.Time{Time: b.StartTime},
-		Location:   b.Location,
+		Identifier:  b.Identifier,
+		Bucket:      b.Bucket,
+		StartTime:   &metav1.Time{Time: b.StartTime},
+		Location:    b.Location,
+		Compression: b.Compression,
+		Method:      b.Method,
 	}, nil
 }
 
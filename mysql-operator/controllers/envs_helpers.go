@@ -2,9 +2,13 @@ package controllers
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -18,11 +22,71 @@ type EnvManager struct {
 	Log logr.Logger
 }
 
+// encryptionKeysDir is where the keys of the store are mounted in the
+// restore init container
+const encryptionKeysDir = "/var/run/blaqkube/keys"
+
+// encryptionKeySize is the size of the keys that encrypt the backups
+const encryptionKeySize = 32
+
 var (
 	// ErrMissingVariable shows when a variable is missing from the store
 	ErrMissingVariable = errors.New("MissingVariable")
+
+	// ErrEncryptionKeyNotFound shows when the store secret or its current
+	// key cannot be read
+	ErrEncryptionKeyNotFound = errors.New("EncryptionKeyNotFound")
+
+	// ErrEncryptionKeyInvalid shows when a key of the store secret is not
+	// 32 bytes, raw or base64 encoded
+	ErrEncryptionKeyInvalid = errors.New("EncryptionKeyInvalid")
 )
 
+// encryptionKey returns the base64 encoding of a raw or base64 encoded key
+func encryptionKey(value []byte) (string, error) {
+	if len(value) == encryptionKeySize {
+		return base64.StdEncoding.EncodeToString(value), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(value)))
+	if err != nil || len(key) != encryptionKeySize {
+		return "", ErrEncryptionKeyInvalid
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// GetEncryption returns the keys of the store, it returns nil when the
+// backups of the store are not encrypted
+func (em *EnvManager) GetEncryption(ctx context.Context, store mysqlv1alpha1.Store) (*agent.Encryption, error) {
+	if store.Spec.Encryption == nil {
+		return nil, nil
+	}
+	log := em.Log.WithValues("store", types.NamespacedName{Namespace: store.Namespace, Name: store.Name})
+	secret := corev1.Secret{}
+	secretName := types.NamespacedName{Namespace: store.Namespace, Name: store.Spec.Encryption.SecretName}
+	if err := em.Get(ctx, secretName, &secret); err != nil {
+		log.Info(fmt.Sprintf("Error getting encryption secret, %v", err), "secret", secretName.Name)
+		return nil, ErrEncryptionKeyNotFound
+	}
+	if _, ok := secret.Data[store.Spec.Encryption.KeyID]; !ok {
+		return nil, ErrEncryptionKeyNotFound
+	}
+	encryption := &agent.Encryption{
+		KeyId:            store.Spec.Encryption.KeyID,
+		Keys:             []agent.EncryptionKey{},
+		AllowUnencrypted: store.Spec.Encryption.AllowUnencrypted,
+	}
+	for id, value := range secret.Data {
+		key, err := encryptionKey(value)
+		if err != nil {
+			log.Info("Invalid encryption key", "secret", secretName.Name, "key", id)
+			return nil, err
+		}
+		encryption.Keys = append(encryption.Keys, agent.EncryptionKey{Id: id, Key: key})
+	}
+	sort.Slice(encryption.Keys, func(i, j int) bool { return encryption.Keys[i].Id < encryption.Keys[j].Id })
+	return encryption, nil
+}
+
 // GetEnvVars returns the environment variables for the store
 func (em *EnvManager) GetEnvVars(ctx context.Context, store mysqlv1alpha1.Store) (map[string]string, error) {
 	log := em.Log.WithValues("store", types.NamespacedName{Namespace: store.Namespace, Name: store.Name})
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidGTIDSet is reported when a GTID set cannot be parsed
+	ErrInvalidGTIDSet = errors.New("InvalidGTIDSet")
+)
+
+// gtidInterval is a range of transaction numbers, both ends included
+type gtidInterval struct {
+	start int64
+	end   int64
+}
+
+// gtidSet is a set of transactions, indexed by source UUID, as reported by
+// gtid_executed. A tag is kept with its UUID, i.e. uuid:tag
+type gtidSet map[string][]gtidInterval
+
+// parseGTIDSet parses a GTID set like
+// 3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:11,2174B383-5441-11E8-B90A-C80AA9429562:1-3
+func parseGTIDSet(value string) (gtidSet, error) {
+	set := gtidSet{}
+	value = strings.NewReplacer("\n", "", "\r", "", " ", "", "\t", "").Replace(value)
+	if value == "" {
+		return set, nil
+	}
+	for _, source := range strings.Split(value, ",") {
+		parts := strings.Split(source, ":")
+		if len(parts) < 2 || parts[0] == "" {
+			return nil, ErrInvalidGTIDSet
+		}
+		key := strings.ToLower(parts[0])
+		for _, part := range parts[1:] {
+			if part == "" {
+				return nil, ErrInvalidGTIDSet
+			}
+			if part[0] < '0' || part[0] > '9' {
+				key = strings.ToLower(parts[0]) + ":" + strings.ToLower(part)
+				continue
+			}
+			bounds := strings.SplitN(part, "-", 2)
+			start, err := strconv.ParseInt(bounds[0], 10, 64)
+			if err != nil {
+				return nil, ErrInvalidGTIDSet
+			}
+			end := start
+			if len(bounds) == 2 {
+				if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil {
+					return nil, ErrInvalidGTIDSet
+				}
+			}
+			if start < 1 || end < start {
+				return nil, ErrInvalidGTIDSet
+			}
+			set[key] = append(set[key], gtidInterval{start: start, end: end})
+		}
+	}
+	for key := range set {
+		set[key] = mergeGTIDIntervals(set[key])
+	}
+	return set, nil
+}
+
+// mergeGTIDIntervals sorts intervals and merges the ones that overlap or
+// are contiguous
+func mergeGTIDIntervals(intervals []gtidInterval) []gtidInterval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	merged := []gtidInterval{}
+	for _, v := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && v.start <= merged[last].end+1 {
+			if v.end > merged[last].end {
+				merged[last].end = v.end
+			}
+			continue
+		}
+		merged = append(merged, v)
+	}
+	return merged
+}
+
+// union returns the transactions that are in either of the sets
+func (g gtidSet) union(other gtidSet) gtidSet {
+	set := gtidSet{}
+	for _, s := range []gtidSet{g, other} {
+		for key, intervals := range s {
+			set[key] = append(set[key], intervals...)
+		}
+	}
+	for key := range set {
+		set[key] = mergeGTIDIntervals(set[key])
+	}
+	return set
+}
+
+// contains reports if all the transactions of other are in the set
+func (g gtidSet) contains(other gtidSet) bool {
+	for key, intervals := range other {
+		for _, v := range intervals {
+			found := false
+			for _, w := range g[key] {
+				if w.start <= v.start && v.end <= w.end {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// count returns the number of transactions in the set
+func (g gtidSet) count() int64 {
+	var count int64
+	for _, intervals := range g {
+		for _, v := range intervals {
+			count += v.end - v.start + 1
+		}
+	}
+	return count
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGTIDSet(t *testing.T) {
+	set, err := parseGTIDSet(testUUID1 + ":1-5:7:6,\n" + testUUID2 + ":1-3")
+	require.NoError(t, err)
+	require.Equal(t, []gtidInterval{{start: 1, end: 7}}, set[testUUID1])
+	require.Equal(t, int64(10), set.count())
+
+	other, err := parseGTIDSet(testUUID1 + ":2-4")
+	require.NoError(t, err)
+	require.True(t, set.contains(other))
+	require.False(t, other.contains(set))
+	require.Equal(t, int64(10), other.union(set).count())
+
+	empty, err := parseGTIDSet("")
+	require.NoError(t, err)
+	require.True(t, set.contains(empty))
+}
+
+func TestInvalidGTIDSet(t *testing.T) {
+	_, err := parseGTIDSet(testUUID1 + ":5-1")
+	require.Equal(t, ErrInvalidGTIDSet, err)
+	_, err = parseGTIDSet(testUUID1)
+	require.Equal(t, ErrInvalidGTIDSet, err)
+}
@@ -58,6 +58,12 @@ var (
 
 	// ErrAgentRequestFailed is reported when the agent request fails
 	ErrAgentRequestFailed = errors.New("AgentRequestFailed")
+
+	// ErrBackupNotFound is reported when the backup cannot be accessed
+	ErrBackupNotFound = errors.New("BackupNotFound")
+
+	// ErrBackupNotReady is reported when the backup has not succeeded yet
+	ErrBackupNotReady = errors.New("BackupNotReady")
 )
 
 // APIReconciler reconciles an object
@@ -81,19 +87,21 @@ func (a *APIReconciler) GetAPI(ctx context.Context, instanceName types.Namespace
 	}
 	pod := &corev1.Pod{}
 	podName := types.NamespacedName{
-		Name:      instanceName.Name + "-0",
+		Name:      instancePrimary(instance),
 		Namespace: instanceName.Namespace,
 	}
 	if err := a.Client.Get(ctx, podName, pod); err != nil {
 		log.Info("Could not access pod", "pod", podName.Name)
 		return nil, ErrPodNotFound
 	}
-	url := fmt.Sprintf("http://%s:%d", pod.Status.PodIP, defaultAgentPort)
+	return newAgentClient(pod.Status.PodIP), nil
+}
 
+// newAgentClient returns a client for the agent running with a pod IP
+func newAgentClient(podIP string) *agent.APIClient {
 	cfg := agent.NewConfiguration()
-	cfg.BasePath = url
-	return agent.NewAPIClient(cfg), nil
-
+	cfg.BasePath = fmt.Sprintf("http://%s:%d", podIP, defaultAgentPort)
+	return agent.NewAPIClient(cfg)
 }
 
 // GetUser gets a user from the name and namespace
@@ -143,3 +151,29 @@ func (a *APIReconciler) GetStore(ctx context.Context, storeName types.Namespaced
 	}
 	return store, nil
 }
+
+// GetBackup gets a backup that has succeeded from the name and namespace, a
+// failed backup is reported with ErrBackupFailed
+func (a *APIReconciler) GetBackup(ctx context.Context, backupName types.NamespacedName) (*mysqlv1alpha1.Backup, error) {
+	log := a.Log.WithValues("namespace", backupName.Namespace, "backup", backupName.Name)
+
+	backup := &mysqlv1alpha1.Backup{}
+	if err := a.Client.Get(ctx, backupName, backup); err != nil {
+		log.Info("Unable to fetch backup")
+		return nil, ErrBackupNotFound
+	}
+	switch backup.Status.Reason {
+	case mysqlv1alpha1.BackupSucceeded:
+	case mysqlv1alpha1.BackupFailed, mysqlv1alpha1.BackupNotImplemented:
+		log.Info("Backup has failed")
+		return nil, ErrBackupFailed
+	default:
+		log.Info("Backup has not succeeded yet")
+		return nil, ErrBackupNotReady
+	}
+	if backup.Status.Details == nil || backup.Status.Details.Location == "" {
+		log.Info("Backup has no location")
+		return nil, ErrBackupFailed
+	}
+	return backup, nil
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+// defaultFlushInterval is the number of seconds after which the agent
+// closes the binary log in use so that it is archived
+const defaultFlushInterval = 300
+
+// binlogArchiveLocation is where the binary logs of an instance are archived
+// in its store
+func binlogArchiveLocation(store *mysqlv1alpha1.Store, instance *mysqlv1alpha1.Instance) string {
+	return fmt.Sprintf("%s/%s-binlog", store.Spec.Prefix, instance.Name)
+}
+
+// restoreBinlogEnvs returns the variables of the restore init container that
+// replay the archived binary logs after the backup
+func restoreBinlogEnvs(instance *mysqlv1alpha1.Instance) []corev1.EnvVar {
+	restore := instance.Spec.Restore
+	if restore.BinlogLocation == "" {
+		return nil
+	}
+	env := []corev1.EnvVar{
+		{
+			Name:  "AGT_BINLOG_LOCATION",
+			Value: restore.BinlogLocation,
+		},
+	}
+	if restore.Time != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "AGT_STOP_DATETIME",
+			Value: restore.Time.UTC().Format(time.RFC3339),
+		})
+	}
+	if restore.GTIDSet != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "AGT_INCLUDE_GTIDS",
+			Value: restore.GTIDSet,
+		})
+	}
+	return env
+}
+
+// newBinlogArchiveRequest creates the agent request to archive the binary
+// logs to the backupSchedule store, with the keys of the store when it is
+// encrypted. The variables are sorted so that the agent keeps its archive
+// running when the request does not change
+func (im *InstanceManager) newBinlogArchiveRequest(instance *mysqlv1alpha1.Instance) (*agent.BinlogArchive, error) {
+	store := &mysqlv1alpha1.Store{}
+	storeName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.BackupSchedule.Store}
+	if err := im.Reconciler.Client.Get(im.Context, storeName, store); err != nil {
+		return nil, ErrStoreNotFound
+	}
+	em := &EnvManager{
+		Client: im.Reconciler.Client,
+		Log:    im.Reconciler.Log,
+	}
+	envs, err := em.GetEnvVars(im.Context, *store)
+	if err != nil {
+		return nil, err
+	}
+	agentEnvs := []agent.EnvVar{}
+	for k, v := range envs {
+		agentEnvs = append(agentEnvs, agent.EnvVar{Name: k, Value: v})
+	}
+	sort.Slice(agentEnvs, func(i, j int) bool { return agentEnvs[i].Name < agentEnvs[j].Name })
+	// the binary logs contain every change, they are encrypted like the
+	// backups of the store
+	encryption, err := em.GetEncryption(im.Context, *store)
+	if err != nil {
+		return nil, err
+	}
+	flushInterval := int32(defaultFlushInterval)
+	if instance.Spec.BinlogArchive.FlushInterval != nil {
+		flushInterval = *instance.Spec.BinlogArchive.FlushInterval
+	}
+	return &agent.BinlogArchive{
+		Backend:       string(store.Spec.Backend),
+		Bucket:        store.Spec.Bucket,
+		Location:      binlogArchiveLocation(store, instance),
+		Envs:          agentEnvs,
+		FlushInterval: flushInterval,
+		S3:            s3Options(store),
+		Encryption:    encryption,
+	}, nil
+}
+
+// reconcileBinlogArchive makes the agent of the primary archive the binary
+// logs and stops the archive on the replicas, so that a replica that has
+// been promoted takes over. The progress is reported in the status
+func (im *InstanceManager) reconcileBinlogArchive(instance *mysqlv1alpha1.Instance) {
+	log := im.Reconciler.Log.WithValues("function", "reconcileBinlogArchive", "namespace", instance.Namespace, "instance", instance.Name)
+
+	if !instance.Spec.BinlogArchive.Enabled && instance.Status.BinlogArchive.Location == "" {
+		return
+	}
+	var request *agent.BinlogArchive
+	if instance.Spec.BinlogArchive.Enabled {
+		var err error
+		request, err = im.newBinlogArchiveRequest(instance)
+		if err != nil {
+			log.Info(fmt.Sprintf("Binary log archive request failed, error: %v", err))
+			instance.Status.BinlogArchive.Message = fmt.Sprintf("The archive request could not be created: %v", err)
+			return
+		}
+		instance.Status.BinlogArchive.Location = request.Location
+	}
+	primary := instancePrimary(instance)
+	stopped := true
+	for i := int32(0); i < instanceReplicas(instance); i++ {
+		pod, err := im.getMemberPod(instance, i)
+		if err != nil {
+			log.Info(fmt.Sprintf("Pod is not available, error: %v", err), "ordinal", i)
+			stopped = false
+			continue
+		}
+		api := newAgentClient(pod.Status.PodIP).MysqlApi
+		if request == nil || pod.Name != primary {
+			if _, _, err := api.StopBinlogArchive(im.Context, nil); err != nil {
+				log.Info(fmt.Sprintf("Binary log archive could not be stopped, error: %v", err), "pod", pod.Name)
+				stopped = false
+			}
+			continue
+		}
+		archive, _, err := api.StartBinlogArchive(im.Context, *request, nil)
+		if err != nil {
+			log.Info(fmt.Sprintf("Binary log archive could not be started, error: %v", err), "pod", pod.Name)
+			instance.Status.BinlogArchive.Message = fmt.Sprintf("The agent of %s could not be reached: %v", pod.Name, err)
+			continue
+		}
+		instance.Status.BinlogArchive.LastBinlog = archive.LastBinlog
+		instance.Status.BinlogArchive.LastArchiveTime = nil
+		if archive.LastArchiveTime != nil {
+			archiveTime := metav1.NewTime(*archive.LastArchiveTime).Rfc3339Copy()
+			instance.Status.BinlogArchive.LastArchiveTime = &archiveTime
+		}
+		instance.Status.BinlogArchive.Message = archive.LastError
+	}
+	if request == nil && stopped {
+		instance.Status.BinlogArchive = mysqlv1alpha1.BinlogArchiveStatus{}
+	}
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	configFile      = "my.cnf"
+	configMountPath = "/etc/mysql/conf.d/blaqkube.cnf"
+)
+
+var configVariableName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*(\.[a-zA-Z][a-zA-Z0-9_-]*)?$`)
+
+// validateConfig checks the variables can be written in my.cnf
+func validateConfig(config map[string]string) error {
+	for name, value := range config {
+		if !configVariableName.MatchString(name) {
+			return fmt.Errorf("config variable %q is not a valid name", name)
+		}
+		if value == "" || strings.ContainsAny(value, "\n\r") {
+			return fmt.Errorf("config variable %q should have a single line value", name)
+		}
+	}
+	return nil
+}
+
+// configVariables returns the instance variables sorted by name
+func configVariables(instance *mysqlv1alpha1.Instance) []agent.Variable {
+	variables := []agent.Variable{}
+	for name, value := range instance.Spec.Config {
+		variables = append(variables, agent.Variable{Name: name, Value: value})
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables
+}
+
+// renderConfig renders the [mysqld] section of my.cnf
+func renderConfig(instance *mysqlv1alpha1.Instance) string {
+	config := "[mysqld]\n"
+	for _, v := range configVariables(instance) {
+		config += fmt.Sprintf("%s = %s\n", v.Name, v.Value)
+	}
+	return config
+}
+
+// syncConfigMap creates the configmap with my.cnf or renders it again when
+// the instance config has changed. MySQL reads the file on its next start
+// only.
+func (im *InstanceManager) syncConfigMap(instance *mysqlv1alpha1.Instance) error {
+	log := im.Reconciler.Log.WithValues("function", "syncConfigMap", "namespace", instance.Namespace, "instance", instance.Name)
+
+	config := renderConfig(instance)
+	configMap := &corev1.ConfigMap{}
+	configMapName := types.NamespacedName{
+		Name:      instance.Name + "-config",
+		Namespace: instance.Namespace,
+	}
+	err := im.Reconciler.Client.Get(im.Context, configMapName, configMap)
+	if err == nil {
+		if configMap.Data[configFile] == config {
+			return nil
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[configFile] = config
+		log.Info("Update configmap", "configmap", configMap.Name)
+		return im.Reconciler.Client.Update(im.Context, configMap)
+	}
+	if !errors.IsNotFound(err) {
+		log.Error(err, "Error getting configmap", "configmap", configMapName.Name)
+		return err
+	}
+	configMap = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName.Name,
+			Namespace: configMapName.Namespace,
+			Labels: map[string]string{
+				"app": instance.Name,
+			},
+		},
+		Data: map[string]string{
+			configFile: config,
+		},
+	}
+	if err := controllerutil.SetControllerReference(instance, configMap, im.Reconciler.Scheme); err != nil {
+		return err
+	}
+	log.Info("Create configmap", "configmap", configMap.Name)
+	return im.Reconciler.Client.Create(im.Context, configMap)
+}
+
+// reconcileVariables applies the instance variables online on every pod
+// and records the ones that require a restart. The variables removed from
+// the config are reset. A restart operation is created for the next
+// maintenance window when the pending variables change, unless the one that
+// is queued can pick them up.
+func (im *InstanceManager) reconcileVariables(instance *mysqlv1alpha1.Instance) {
+	log := im.Reconciler.Log.WithValues("function", "reconcileVariables", "namespace", instance.Namespace, "instance", instance.Name)
+
+	variables := configVariables(instance)
+	persisted := im.resetVariables(instance)
+	for _, v := range variables {
+		persisted = append(persisted, v.Name)
+	}
+	sort.Strings(persisted)
+	instance.Status.PersistedVariables = persisted
+	if len(persisted) == 0 {
+		instance.Status.PersistedVariables = nil
+	}
+	if len(variables) == 0 {
+		instance.Status.PendingRestart = nil
+		instance.Status.RestartOperation = ""
+		return
+	}
+	request := agent.ListVariables{Size: int32(len(variables)), Items: variables}
+	pending := map[string]string{}
+	for i := int32(0); i < instanceReplicas(instance); i++ {
+		pod, err := im.getMemberPod(instance, i)
+		if err != nil {
+			log.Info(fmt.Sprintf("Pod is not available, error: %v", err), "ordinal", i)
+			continue
+		}
+		result, _, err := newAgentClient(pod.Status.PodIP).MysqlApi.PersistVariables(im.Context, request, nil)
+		if err != nil {
+			log.Info(fmt.Sprintf("Variables could not be applied, error: %v", err), "pod", pod.Name)
+			continue
+		}
+		for _, v := range result.Items {
+			if v.Status == "pending" {
+				pending[v.Name] = v.Value
+			}
+		}
+	}
+	if len(pending) == 0 {
+		instance.Status.PendingRestart = nil
+		instance.Status.RestartOperation = ""
+		return
+	}
+	status := []mysqlv1alpha1.ConfigVariableStatus{}
+	for _, v := range variables {
+		if value, ok := pending[v.Name]; ok {
+			status = append(status, mysqlv1alpha1.ConfigVariableStatus{Name: v.Name, Value: value})
+		}
+	}
+	if (instance.Status.RestartOperation == "" || !equality.Semantic.DeepEqual(status, instance.Status.PendingRestart)) &&
+		!im.isOperationQueued(instance, instance.Status.RestartOperation) {
+		operation, err := im.createOperation(instance, mysqlv1alpha1.OperationTypeRestart)
+		if err != nil {
+			log.Error(err, "Restart operation creation failed")
+			return
+		}
+		instance.Status.RestartOperation = operation.Name
+	}
+	instance.Status.PendingRestart = status
+}
+
+// resetVariables resets the persisted variables that are not in the
+// instance config anymore on every pod. It returns the ones that could not
+// be reset on every pod, so that they are reset again later.
+func (im *InstanceManager) resetVariables(instance *mysqlv1alpha1.Instance) []string {
+	log := im.Reconciler.Log.WithValues("function", "resetVariables", "namespace", instance.Namespace, "instance", instance.Name)
+
+	removed := []string{}
+	for _, name := range instance.Status.PersistedVariables {
+		if _, ok := instance.Spec.Config[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) == 0 {
+		return []string{}
+	}
+	failed := map[string]bool{}
+	for i := int32(0); i < instanceReplicas(instance); i++ {
+		pod, err := im.getMemberPod(instance, i)
+		if err != nil {
+			log.Info(fmt.Sprintf("Pod is not available, error: %v", err), "ordinal", i)
+			for _, name := range removed {
+				failed[name] = true
+			}
+			continue
+		}
+		for _, name := range removed {
+			if _, _, err := newAgentClient(pod.Status.PodIP).MysqlApi.ResetVariable(im.Context, name, nil); err != nil {
+				log.Info(fmt.Sprintf("Variable could not be reset, error: %v", err), "pod", pod.Name, "variable", name)
+				failed[name] = true
+			}
+		}
+	}
+	remaining := []string{}
+	for _, name := range removed {
+		if failed[name] {
+			remaining = append(remaining, name)
+			continue
+		}
+		log.Info("Variable reset", "variable", name)
+	}
+	return remaining
+}
+
+// isOperationQueued reports if an operation of the instance has not started
+// yet, so that it uses the latest configuration when it runs
+func (im *InstanceManager) isOperationQueued(instance *mysqlv1alpha1.Instance, name string) bool {
+	if name == "" {
+		return false
+	}
+	operation := &mysqlv1alpha1.Operation{}
+	operationName := types.NamespacedName{Namespace: instance.Namespace, Name: name}
+	if err := im.Reconciler.Client.Get(im.Context, operationName, operation); err != nil {
+		return false
+	}
+	switch operation.Status.Reason {
+	case "", mysqlv1alpha1.OperationPending, mysqlv1alpha1.OperationRequested, mysqlv1alpha1.OperationWaitingForMaintenanceWindow:
+		return true
+	}
+	return false
+}
+
+// createOperation requests an operation on the instance in the next
+// maintenance window
+func (im *InstanceManager) createOperation(instance *mysqlv1alpha1.Instance, operationType mysqlv1alpha1.OperationType) (*mysqlv1alpha1.Operation, error) {
+	operation := &mysqlv1alpha1.Operation{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: instance.Name + "-" + string(operationType) + "-",
+			Namespace:    instance.Namespace,
+			Labels: map[string]string{
+				"app": instance.Name,
+			},
+		},
+		Spec: mysqlv1alpha1.OperationSpec{
+			Mode:     mysqlv1alpha1.OperationModeMaintenance,
+			Type:     operationType,
+			Instance: instance.Name,
+		},
+	}
+	if err := controllerutil.SetControllerReference(instance, operation, im.Reconciler.Scheme); err != nil {
+		return nil, err
+	}
+	if err := im.Reconciler.Client.Create(im.Context, operation); err != nil {
+		return nil, err
+	}
+	im.Reconciler.Log.Info("Operation created", "namespace", instance.Namespace, "instance", instance.Name, "operation", operation.Name, "type", operationType)
+	return operation, nil
+}
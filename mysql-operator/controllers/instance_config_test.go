@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+var _ = Describe("Instance Config", func() {
+	It("Render my.cnf with sorted variables", func() {
+		instance := &mysqlv1alpha1.Instance{
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Config: map[string]string{
+					"max_connections":         "500",
+					"innodb_buffer_pool_size": "1G",
+				},
+			},
+		}
+		Expect(renderConfig(instance)).To(Equal("[mysqld]\ninnodb_buffer_pool_size = 1G\nmax_connections = 500\n"))
+		Expect(renderConfig(&mysqlv1alpha1.Instance{})).To(Equal("[mysqld]\n"))
+	})
+
+	It("Reject variables that cannot be written in my.cnf", func() {
+		Expect(validateConfig(map[string]string{"innodb-log-file-size": "256M"})).To(Succeed())
+		Expect(validateConfig(map[string]string{"max_connections": ""})).ToNot(Succeed())
+		Expect(validateConfig(map[string]string{"max_connections": "1\n[client]"})).ToNot(Succeed())
+		Expect(validateConfig(map[string]string{"[client]": "1"})).ToNot(Succeed())
+	})
+})
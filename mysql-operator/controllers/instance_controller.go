@@ -10,10 +10,17 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 )
@@ -25,12 +32,19 @@ type InstanceReconciler struct {
 	Scheme     *runtime.Scheme
 	Properties *StatefulSetProperties
 	Crontab    Crontab
+	Connector  ReplicationConnector
 }
 
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=operations,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=stores,verbs=get;list;watch
 // +kubebuilder:rbac:groups="apps",resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=backups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=restores,verbs=get;list;watch
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=instances,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=instances/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=instances/finalizers,verbs=update
@@ -46,6 +60,23 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	im := &InstanceManager{
+		Context:     ctx,
+		Reconciler:  r,
+		Properties:  r.Properties,
+		TimeManager: NewTimeManager(),
+	}
+	if !instance.DeletionTimestamp.IsZero() {
+		return im.finalizeInstance(instance)
+	}
+	if !controllerutil.ContainsFinalizer(instance, instanceFinalizer) {
+		controllerutil.AddFinalizer(instance, instanceFinalizer)
+		if err := r.Update(ctx, instance); err != nil {
+			log.Error(err, "Unable to add the finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
 	if r.Crontab.reScheduleAll(r.Client, instance, r.Log, r.Scheme) {
 		if err := r.Status().Update(ctx, instance); err != nil {
 			log.Info(fmt.Sprintf("Error rescheduling jobs, err: %v", err))
@@ -55,11 +86,16 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, nil
 	}
 
-	im := &InstanceManager{
-		Context:     ctx,
-		Reconciler:  r,
-		Properties:  r.Properties,
-		TimeManager: NewTimeManager(),
+	if err := validateInstance(instance); err != nil {
+		log.Info(fmt.Sprintf("Invalid instance specification, error: %v", err))
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceSpecInvalid,
+			Message:            fmt.Sprintf("The instance specification is not valid: %v", err),
+		}
+		return im.setInstanceCondition(instance, condition)
 	}
 	secret, err := im.getExporterSecret(instance)
 	if err != nil && !errors.IsNotFound(err) {
@@ -78,6 +114,48 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	if secret.UID != instance.Status.ExporterSecret.UID {
 		im.deleteExporterSecret(instance, secret)
 	}
+	if created, result, err := im.reconcileCredentialSecrets(instance); created {
+		return result, err
+	}
+	replicationSecret := &corev1.Secret{}
+	if instanceReplicas(instance) > 1 {
+		replicationSecret, err = im.getReplicationSecret(instance)
+		if err != nil && !errors.IsNotFound(err) {
+			condition := metav1.Condition{
+				Type:               "available",
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             mysqlv1alpha1.InstanceReplicationSecretInaccessible,
+				Message:            fmt.Sprintf("The secret replication could not be accessed: %v", err),
+			}
+			return im.setInstanceCondition(instance, condition)
+		}
+		if err != nil {
+			return im.createReplicationSecret(instance)
+		}
+	}
+
+	if err := im.syncConfigMap(instance); err != nil {
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceConfigMapFailed,
+			Message:            fmt.Sprintf("The configmap could not be synchronized: %v", err),
+		}
+		return im.setInstanceCondition(instance, condition)
+	}
+
+	if err := syncServices(ctx, r.Client, r.Scheme, instance); err != nil {
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceServicesFailed,
+			Message:            fmt.Sprintf("The services could not be synchronized: %v", err),
+		}
+		return im.setInstanceCondition(instance, condition)
+	}
 
 	sts, stsErr := im.getStatefulSet(instance)
 	if stsErr != nil && !errors.IsNotFound(stsErr) {
@@ -93,9 +171,38 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	// TODO: detect changes on the store when it is needed and start the instance accordingly
 	store := &mysqlv1alpha1.Store{}
 	location := ""
-	if instance.Spec.Restore.Store != "" {
+	storeName := instance.Spec.Restore.Store
+	var restored *mysqlv1alpha1.RestoredBackupStatus
+	if instance.Spec.Restore.BackupRef != "" {
+		var err error
+		restored, err = im.restoreBackup(instance)
+		if err != nil {
+			log.Info(fmt.Sprintf("Unable to use the restore backup, error: %v", err), "backup", instance.Spec.Restore.BackupRef)
+			condition := metav1.Condition{
+				Type:               "available",
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             mysqlv1alpha1.InstanceBackupInaccessible,
+				Message:            fmt.Sprintf("Cannot access the restore backup %s", instance.Spec.Restore.BackupRef),
+			}
+			switch err {
+			case ErrBackupNotReady:
+				condition.Reason = mysqlv1alpha1.InstanceBackupNotReady
+				condition.Message = fmt.Sprintf("The restore backup %s has not succeeded yet", instance.Spec.Restore.BackupRef)
+			case ErrBackupFailed:
+				condition.Reason = mysqlv1alpha1.InstanceBackupFailed
+				condition.Message = fmt.Sprintf("The restore backup %s has failed, change restore.backupRef", instance.Spec.Restore.BackupRef)
+			}
+			return im.setInstanceCondition(instance, condition)
+		}
+		storeName = restored.Store
+	}
+	if storeName != "" {
 		location = instance.Spec.Restore.Location
-		NamespacedStore := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.Restore.Store}
+		if restored != nil {
+			location = restored.Location
+		}
+		NamespacedStore := types.NamespacedName{Namespace: instance.Namespace, Name: storeName}
 		if err := r.Get(ctx, NamespacedStore, store); err != nil {
 			log.Info(fmt.Sprintf("Unable to fetch store, error: %v", err), "store", store.Name)
 			condition := metav1.Condition{
@@ -118,12 +225,21 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			}
 			return im.setInstanceCondition(instance, condition)
 		}
+		store = restoreStore(store, restored)
+		instance.Status.RestoredBackup = restored
 	}
 	if stsErr != nil {
 		return im.createStatefulSet(instance, store, location)
 	}
-	// TODO: Check the StatefulSet matches the requirements
 	if sts.UID != instance.Status.StatefulSet.UID {
+		instance.Status.StatefulSet = corev1.ObjectReference{
+			Kind:            sts.Kind,
+			Namespace:       sts.Namespace,
+			Name:            sts.Name,
+			UID:             sts.UID,
+			APIVersion:      sts.APIVersion,
+			ResourceVersion: sts.ResourceVersion,
+		}
 		condition := metav1.Condition{
 			Type:               "available",
 			Status:             metav1.ConditionFalse,
@@ -136,7 +252,30 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 		return im.setInstanceCondition(instance, condition)
 	}
-	if sts.Status.ReadyReplicas != sts.Status.Replicas {
+	syncVersion(instance, sts)
+	if updated, result, err := im.updateStatefulSet(instance, sts, store, location); updated {
+		return result, err
+	}
+	if (sts.Status.ObservedGeneration != 0 && sts.Status.ObservedGeneration < sts.Generation) ||
+		sts.Status.CurrentRevision != sts.Status.UpdateRevision {
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceStatefulSetRollingUpdate,
+			Message: fmt.Sprintf("Waiting for the rolling update, %d/%d pods updated",
+				sts.Status.UpdatedReplicas,
+				sts.Status.Replicas,
+			),
+		}
+		return im.setInstanceCondition(instance, condition)
+	}
+	if instanceReplicas(instance) > 1 {
+		if stop, result, err := im.reconcileFailover(instance, replicationSecret); stop {
+			return result, err
+		}
+	}
+	if sts.Spec.Replicas != nil && sts.Status.ReadyReplicas != *sts.Spec.Replicas {
 		condition := metav1.Condition{
 			Type:               "available",
 			Status:             metav1.ConditionFalse,
@@ -146,6 +285,45 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 		return im.setInstanceCondition(instance, condition)
 	}
+	members := instance.Status.Members
+	pending := instance.Status.PendingRestart
+	restartOperation := instance.Status.RestartOperation
+	persisted := instance.Status.PersistedVariables
+	services := instance.Status.Services
+	version := instance.Status.Version
+	upgradeOperation := instance.Status.UpgradeOperation
+	binlogArchive := instance.Status.BinlogArchive
+	retention := instance.Status.Retention
+	if instanceReplicas(instance) > 1 {
+		im.reconcileReplication(instance, replicationSecret)
+		// the replicas join the read-only service once they replicate
+		if err := syncRoleLabels(ctx, r.Client, instance); err != nil {
+			log.Info(fmt.Sprintf("Unable to label the replicas, error: %v", err))
+		}
+	} else {
+		instance.Status.Members = nil
+	}
+	im.reconcileVariables(instance)
+	im.reconcileUpgrade(instance)
+	im.reconcileBinlogArchive(instance)
+	im.reconcileRetention(instance)
+	if (!equality.Semantic.DeepEqual(members, instance.Status.Members) ||
+		!equality.Semantic.DeepEqual(pending, instance.Status.PendingRestart) ||
+		restartOperation != instance.Status.RestartOperation ||
+		!equality.Semantic.DeepEqual(persisted, instance.Status.PersistedVariables) ||
+		services != instance.Status.Services ||
+		version != instance.Status.Version ||
+		upgradeOperation != instance.Status.UpgradeOperation ||
+		!equality.Semantic.DeepEqual(binlogArchive, instance.Status.BinlogArchive) ||
+		!equality.Semantic.DeepEqual(retention, instance.Status.Retention)) &&
+		instance.Status.Reason == mysqlv1alpha1.InstanceStatefulSetReady {
+		log.Info("Updating instance members, variables, services, version, binlog archive and retention")
+		if err := r.Status().Update(ctx, instance); err != nil {
+			log.Error(err, "Unable to update instance")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: replicationPollInterval}, nil
+	}
 	condition := metav1.Condition{
 		Type:               "available",
 		Status:             metav1.ConditionTrue,
@@ -162,6 +340,33 @@ func (r *InstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&mysqlv1alpha1.Instance{}).
 		Owns(&corev1.Secret{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
 		Owns(&mysqlv1alpha1.Backup{}).
+		Watches(
+			&source.Kind{Type: &mysqlv1alpha1.Store{}},
+			handler.EnqueueRequestsFromMapFunc(r.filesystemStoreInstances),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(r)
 }
+
+// filesystemStoreInstances returns the instances of the namespace of a
+// filesystem store, they mount its claim
+func (r *InstanceReconciler) filesystemStoreInstances(o client.Object) []reconcile.Request {
+	store, ok := o.(*mysqlv1alpha1.Store)
+	if !ok || store.Spec.Backend != mysqlv1alpha1.BackendFilesystem {
+		return nil
+	}
+	instances := &mysqlv1alpha1.InstanceList{}
+	if err := r.List(context.Background(), instances, client.InNamespace(store.Namespace)); err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, instance := range instances.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name},
+		})
+	}
+	return requests
+}
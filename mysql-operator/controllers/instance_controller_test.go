@@ -1,20 +1,27 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/go-logr/zapr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/blaqkube/mysql-operator/agent/backend"
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
@@ -45,7 +52,8 @@ var _ = Describe("Instance Controller", func() {
 				AgentVersion: "latest",
 				MySQLVersion: "8.0.22",
 			},
-			Crontab: NewMockCrontabCrontab(),
+			Crontab:   NewMockCrontabCrontab(),
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
 		}
 		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
 
@@ -69,10 +77,42 @@ var _ = Describe("Instance Controller", func() {
 
 		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
 
+		response = mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &response)).To(Succeed())
+		Expect(response.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceRootSecretCreated), "Expected reconcile to change the status to RootSecretCreated")
+
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+
+		response = mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &response)).To(Succeed())
+		Expect(response.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceAgentSecretCreated), "Expected reconcile to change the status to AgentSecretCreated")
+
+		secretName = types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name + "-agent"}
+		Expect(k8sClient.Get(ctx, secretName, &secret)).To(Succeed())
+		Expect(string(secret.Data["agent.yaml"])).
+			Should(ContainSubstring("agent_username: agent"), "Data should contain the agent configuration")
+		Expect(string(secret.Data["init.sql"])).
+			Should(ContainSubstring("CREATE USER IF NOT EXISTS 'agent'@'127.0.0.1'"), "Data should create the agent account")
+
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+
 		response = mysqlv1alpha1.Instance{}
 		Expect(k8sClient.Get(ctx, instanceName, &response)).To(Succeed())
 		Expect(mysqlv1alpha1.InstanceStatefulSetCreated).
 			To(Equal(response.Status.Reason), "Expected reconcile to change the status to StatefulSetCreated")
+		Expect(response.Status.Services).To(Equal(mysqlv1alpha1.ServicesStatus{
+			Headless: instance.Name,
+			Client:   instance.Name + "-rw",
+		}))
+
+		service := corev1.Service{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, &service)).To(Succeed())
+		Expect(service.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone), "Expected a headless service for the statefulset")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name + "-rw"}, &service)).To(Succeed())
+		Expect(service.Spec.Selector).To(HaveKeyWithValue("statefulset.kubernetes.io/pod-name", instance.Name+"-0"))
+		Expect(service.Spec.Ports[0].Port).To(Equal(int32(3306)))
 	})
 
 	It("Create an Instance with a Store", func() {
@@ -140,7 +180,8 @@ var _ = Describe("Instance Controller", func() {
 				AgentVersion: "latest",
 				MySQLVersion: "8.0.22",
 			},
-			Crontab: NewMockCrontabCrontab(),
+			Crontab:   NewMockCrontabCrontab(),
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
 		}
 		Expect(instanceReconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
 
@@ -164,6 +205,20 @@ var _ = Describe("Instance Controller", func() {
 
 		Expect(instanceReconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
 
+		instanceResponse = mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceRootSecretCreated), "Expected reconcile to change the status to RootSecretCreated")
+
+		Expect(instanceReconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+
+		instanceResponse = mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceAgentSecretCreated), "Expected reconcile to change the status to AgentSecretCreated")
+
+		Expect(instanceReconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+
 		instanceResponse = mysqlv1alpha1.Instance{}
 		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
 		Expect(mysqlv1alpha1.InstanceStatefulSetCreated).
@@ -201,7 +256,8 @@ var _ = Describe("Instance Controller", func() {
 				AgentVersion: "latest",
 				MySQLVersion: "8.0.22",
 			},
-			Crontab: NewMockCrontabCrontab(),
+			Crontab:   NewMockCrontabCrontab(),
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
 		}
 		Expect(instanceReconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
 
@@ -225,6 +281,20 @@ var _ = Describe("Instance Controller", func() {
 
 		Expect(instanceReconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
 
+		instanceResponse = mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceRootSecretCreated), "Expected reconcile to change the status to RootSecretCreated")
+
+		Expect(instanceReconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+
+		instanceResponse = mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceAgentSecretCreated), "Expected reconcile to change the status to AgentSecretCreated")
+
+		Expect(instanceReconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+
 		instanceResponse = mysqlv1alpha1.Instance{}
 		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
 		Expect(mysqlv1alpha1.InstanceStoreInaccessible).
@@ -272,4 +342,647 @@ var _ = Describe("Instance Controller", func() {
 			To(Equal(instanceResponse.Status.Reason), "Expected reconcile to change the status to StatefulSetCreated")
 	})
 
+	It("Create an Instance with storage and resources", func() {
+		storageClassName := "fast"
+		dataSize := resource.MustParse("200Gi")
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sized",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Storage: mysqlv1alpha1.StorageSpec{
+					StorageClassName: &storageClassName,
+					Data:             mysqlv1alpha1.VolumeSpec{Size: &dataSize},
+				},
+				Resources: mysqlv1alpha1.ResourcesSpec{
+					MySQL: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					},
+				},
+			},
+		}
+		Expect(validateInstance(instance)).To(Succeed())
+
+		properties := &StatefulSetProperties{
+			AgentVersion: "latest",
+			MySQLVersion: "8.0.22",
+		}
+		sts := properties.NewStatefulSetForInstance(instance, nil, "")
+		Expect(sts.Spec.VolumeClaimTemplates).To(HaveLen(2))
+		data := sts.Spec.VolumeClaimTemplates[0]
+		Expect(data.Spec.StorageClassName).To(Equal(&storageClassName))
+		Expect(data.Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(dataSize))
+		init := sts.Spec.VolumeClaimTemplates[1]
+		Expect(init.Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(*resource.NewQuantity(defaultVolumeSize, resource.BinarySI)))
+		Expect(sts.Spec.Template.Spec.Containers[0].Resources).To(Equal(instance.Spec.Resources.MySQL))
+	})
+
+	It("Keep the containers of an Instance with limits only", func() {
+		resources := corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+		}
+		desired := []corev1.Container{{Name: "mysql", Image: "mysql:8.0.23", Resources: resources}}
+		// the API server defaults the requests to the limits
+		current := []corev1.Container{{Name: "mysql", Image: "mysql:8.0.23", Resources: corev1.ResourceRequirements{
+			Limits: resources.Limits,
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1000m"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+		}}}
+		Expect(syncContainers(current, desired)).To(BeEmpty())
+
+		desired[0].Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}
+		Expect(syncContainers(current, desired)).To(Equal([]string{"mysql/resources"}))
+		Expect(current[0].Resources).To(Equal(desired[0].Resources))
+	})
+
+	It("Create an Instance with root and agent credentials", func() {
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secured",
+				Namespace: "default",
+			},
+		}
+		properties := &StatefulSetProperties{
+			AgentVersion: "latest",
+			MySQLVersion: "8.0.23",
+		}
+		sts := properties.NewStatefulSetForInstance(instance, nil, "")
+		mysql := sts.Spec.Template.Spec.Containers[0]
+		Expect(mysql.Env[0].Name).To(Equal("MYSQL_ROOT_PASSWORD"))
+		Expect(mysql.Env[0].ValueFrom.SecretKeyRef.Name).To(Equal("secured-root"))
+		Expect(mysql.ReadinessProbe.Exec.Command).To(ContainElement("--defaults-extra-file=/etc/blaqkube/root/.my.cnf"))
+		Expect(mysql.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "secured-agent",
+			MountPath: agentInitScript,
+			SubPath:   agentInitFile,
+			ReadOnly:  true,
+		}))
+		agentContainer := sts.Spec.Template.Spec.Containers[1]
+		Expect(agentContainer.Args).To(Equal([]string{"--config=/etc/blaqkube/agent/agent.yaml"}))
+		Expect(agentContainer.Env).To(ContainElement(corev1.EnvVar{Name: "AGT_ROOT_PASSWORD_FILE", Value: "/etc/blaqkube/root/password"}))
+		Expect(agentContainer.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "secured-root",
+			MountPath: rootConfigPath,
+			ReadOnly:  true,
+		}))
+
+		data := agentSecretData("changeme")
+		Expect(data["agent.yaml"]).To(Equal("agent_username: agent\nagent_password: changeme\nbootstrap_file: /etc/blaqkube/agent/init.sql\n"))
+		Expect(data["init.sql"]).To(ContainSubstring("GRANT APPLICATION_PASSWORD_ADMIN"))
+		Expect(rootSecretData("changeme")[".my.cnf"]).To(Equal("[client]\nuser=root\npassword=changeme\n"))
+	})
+
+	It("Create an Instance with read replicas", func() {
+		replicas := int32(3)
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "replicated",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Replicas: &replicas,
+				Replication: mysqlv1alpha1.ReplicationSpec{
+					Seed: mysqlv1alpha1.ReplicationSeedStore,
+				},
+			},
+		}
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected a store seed without store to be rejected")
+		instance.Spec.Replication.Store = "docs"
+		instance.Spec.Replication.Location = "/blue/blue-20210301-020000.xbstream.gz"
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected a physical backup seed to be rejected")
+		instance.Spec.Replication.Location = "/blue/blue-20210301-020000.sql.gz"
+		Expect(validateInstance(instance)).To(Succeed())
+		instance.Spec.Replication.Seed = mysqlv1alpha1.ReplicationSeedDump
+		Expect(validateInstance(instance)).To(Succeed())
+
+		properties := &StatefulSetProperties{
+			AgentVersion: "latest",
+			MySQLVersion: "8.0.23",
+		}
+		sts := properties.NewStatefulSetForInstance(instance, nil, "")
+		Expect(*sts.Spec.Replicas).To(Equal(replicas))
+		Expect(sts.Spec.Template.Spec.Containers[0].Args).To(ContainElement("--gtid-mode=ON"))
+
+		im := &InstanceManager{}
+		user := agent.User{Username: "replication", Password: "changeme"}
+		request, err := im.newReplicationRequest(instance, "10.0.0.12", user, 2, mysqlv1alpha1.ReplicationSeedDump)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request.ServerId).To(Equal(int32(3)))
+		Expect(request.Seed).To(Equal(mysqlv1alpha1.ReplicationSeedDump))
+		Expect(request.Host).To(Equal("10.0.0.12"))
+
+		instance.Status.Primary = "replicated-2"
+		Expect(memberOrdinal(instance, instance.Status.Primary)).To(Equal(int32(2)))
+		Expect(validateInstance(instance)).To(Succeed())
+		replicas = int32(2)
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected a scale down that removes the primary to be rejected")
+	})
+
+	It("Create an Instance with a version", func() {
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "versioned",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Version: "8.0",
+			},
+		}
+		Expect(validateInstance(instance)).To(Succeed())
+		properties := &StatefulSetProperties{
+			AgentVersion: "latest",
+			MySQLVersion: "8.0.23",
+		}
+		sts := properties.NewStatefulSetForInstance(instance, nil, "")
+		Expect(sts.Spec.Template.Spec.Containers[0].Image).To(Equal("mysql:8.0"))
+
+		syncVersion(instance, sts)
+		Expect(instance.Status.Version).To(Equal("8.0"))
+		instance.Spec.Version = "8.0.24"
+		sts = properties.NewStatefulSetForInstance(instance, nil, "")
+		Expect(sts.Spec.Template.Spec.Containers[0].Image).To(Equal("mysql:8.0"), "Expected the version to change with an upgrade only")
+
+		instance.Spec.Version = "5.7.33"
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected a downgrade to be rejected")
+		instance.Spec.Version = "latest"
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected a version without number to be rejected")
+
+		instance.Spec.Version = ""
+		syncVersion(instance, sts)
+		Expect(instance.Status.Version).To(BeEmpty())
+		sts = properties.NewStatefulSetForInstance(instance, nil, "")
+		Expect(sts.Spec.Template.Spec.Containers[0].Image).To(Equal("mysql:8.0.23"), "Expected the default version without version")
+
+		Expect(compareVersions("8.0.24", "8.0.23")).To(Equal(1))
+		Expect(compareVersions("8.0", "8.0.0")).To(Equal(0))
+		Expect(compareVersions("5.7.33", "8.0.23")).To(Equal(-1))
+		Expect(matchVersion("8.0.24", "8.0.24")).To(BeTrue())
+		Expect(matchVersion("8.0.24-debug", "8.0.24")).To(BeTrue())
+		Expect(matchVersion("8.0.26", "8.0")).To(BeTrue())
+		Expect(matchVersion("8.0.24", "8.0.2")).To(BeFalse())
+	})
+
+	It("Create an Instance with a point-in-time restore", func() {
+		target := metav1.NewTime(time.Date(2021, 3, 1, 10, 30, 0, 0, time.UTC))
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pitr",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Restore: mysqlv1alpha1.RestoreSpec{
+					Store:          "store",
+					Location:       "/blue-20210301-000000.sql",
+					BinlogLocation: "/blue-binlog",
+					Time:           &target,
+				},
+				BinlogArchive: mysqlv1alpha1.BinlogArchiveSpec{
+					Enabled: true,
+				},
+			},
+		}
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected the archive to require a store")
+		instance.Spec.BackupSchedule.Store = "store"
+		Expect(validateInstance(instance)).To(Succeed())
+
+		store := &mysqlv1alpha1.Store{
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend: mysqlv1alpha1.BackendS3,
+				Bucket:  "bucket",
+				Prefix:  "/backups",
+				Envs:    []corev1.EnvVar{{Name: "AWS_REGION", Value: "eu-west-1"}},
+			},
+		}
+		Expect(binlogArchiveLocation(store, instance)).To(Equal("/backups/pitr-binlog"))
+		properties := &StatefulSetProperties{
+			AgentVersion: "latest",
+			MySQLVersion: "8.0.23",
+		}
+		sts := properties.NewStatefulSetForInstance(instance, store, instance.Spec.Restore.Location)
+		Expect(sts.Spec.Template.Spec.InitContainers).To(HaveLen(1))
+		env := sts.Spec.Template.Spec.InitContainers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "AGT_BINLOG_LOCATION", Value: "/blue-binlog"}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "AGT_STOP_DATETIME", Value: "2021-03-01T10:30:00Z"}))
+
+		instance.Spec.Restore.BinlogLocation = ""
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected a target time to require the binlog location")
+		instance.Spec.Restore = mysqlv1alpha1.RestoreSpec{
+			BinlogLocation: "/blue-binlog",
+			GTIDSet:        "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-1234",
+		}
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected the binlogs to require a backup")
+	})
+
+	It("Create an Instance from a backup reference", func() {
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "seeded",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Restore: mysqlv1alpha1.RestoreSpec{
+					BackupRef: "blue-backup",
+					Store:     "store",
+				},
+			},
+		}
+		Expect(validateInstance(instance)).NotTo(Succeed(), "Expected backupRef not to be used with a store")
+		instance.Spec.Restore.Store = ""
+		instance.Spec.Restore.BinlogLocation = "/blue-binlog"
+		Expect(validateInstance(instance)).To(Succeed(), "Expected backupRef to be enough to replay the binlogs")
+
+		restored := &mysqlv1alpha1.RestoredBackupStatus{
+			Backup:   "blue-backup",
+			Store:    "store",
+			Bucket:   "former-bucket",
+			Location: "/backups/blue-20210301-000000.sql",
+		}
+		instance.Status.RestoredBackup = restored
+		im := &InstanceManager{Context: context.TODO()}
+		Expect(im.restoreBackup(instance)).To(Equal(restored), "Expected the recorded backup to be used")
+
+		store := &mysqlv1alpha1.Store{
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend: mysqlv1alpha1.BackendS3,
+				Bucket:  "bucket",
+				Envs:    []corev1.EnvVar{{Name: "AWS_REGION", Value: "eu-west-1"}},
+			},
+		}
+		Expect(restoreStore(store, restored).Spec.Bucket).To(Equal("former-bucket"))
+		Expect(store.Spec.Bucket).To(Equal("bucket"), "Expected the store not to be changed")
+		properties := &StatefulSetProperties{
+			AgentVersion: "latest",
+			MySQLVersion: "8.0.23",
+		}
+		sts := properties.NewStatefulSetForInstance(instance, restoreStore(store, restored), restored.Location)
+		env := sts.Spec.Template.Spec.InitContainers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "AGT_BUCKET", Value: "former-bucket"}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "AGT_LOCATION", Value: "/backups/blue-20210301-000000.sql"}))
+	})
+
+	It("Create an Instance from a physical backup", func() {
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "physical",
+				Namespace: "default",
+			},
+			Status: mysqlv1alpha1.InstanceStatus{
+				RestoredBackup: &mysqlv1alpha1.RestoredBackupStatus{
+					Backup:   "blue-backup",
+					Store:    "store",
+					Location: "/backups/blue-20210301-000000.xbstream",
+					Method:   mysqlv1alpha1.BackupMethodXtrabackup,
+				},
+			},
+		}
+		store := &mysqlv1alpha1.Store{
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend: mysqlv1alpha1.BackendS3,
+				Bucket:  "bucket",
+				Envs:    []corev1.EnvVar{{Name: "AWS_REGION", Value: "eu-west-1"}},
+			},
+		}
+		properties := &StatefulSetProperties{
+			AgentVersion: "latest",
+			MySQLVersion: "8.0.23",
+		}
+		sts := properties.NewStatefulSetForInstance(instance, store, instance.Status.RestoredBackup.Location)
+		restore := sts.Spec.Template.Spec.InitContainers[0]
+		Expect(restore.Env).To(ContainElement(corev1.EnvVar{Name: "AGT_METHOD", Value: "xtrabackup"}))
+		Expect(restore.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "physical-data", MountPath: "/var/lib/mysql"}))
+		Expect(sts.Spec.Template.Spec.Containers[0].Args).To(ContainElement("--init-file=" + agentInitScript))
+
+		instance.Status.RestoredBackup.Method = mysqlv1alpha1.BackupMethodMysqldump
+		sts = properties.NewStatefulSetForInstance(instance, store, instance.Status.RestoredBackup.Location)
+		Expect(sts.Spec.Template.Spec.InitContainers[0].VolumeMounts).To(HaveLen(1))
+		Expect(sts.Spec.Template.Spec.Containers[0].Args).To(HaveLen(2))
+		Expect(methodExtension(mysqlv1alpha1.BackupMethodClone)).To(Equal(".tar"))
+	})
+
+	It("Create an Instance from an encrypted store", func() {
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sealed",
+				Namespace: "default",
+			},
+		}
+		store := &mysqlv1alpha1.Store{
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend: mysqlv1alpha1.BackendS3,
+				Bucket:  "bucket",
+				Envs:    []corev1.EnvVar{{Name: "AWS_REGION", Value: "eu-west-1"}},
+				Encryption: &mysqlv1alpha1.EncryptionSpec{
+					SecretName: "backup-keys",
+					KeyID:      "2021-03",
+				},
+			},
+		}
+		properties := &StatefulSetProperties{
+			AgentVersion: "latest",
+			MySQLVersion: "8.0.23",
+		}
+		sts := properties.NewStatefulSetForInstance(instance, store, "/sealed.sql")
+		restore := sts.Spec.Template.Spec.InitContainers[0]
+		Expect(restore.Env).To(ContainElement(corev1.EnvVar{Name: "AGT_KEYS_DIR", Value: encryptionKeysDir}))
+		Expect(restore.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "sealed-keys", MountPath: encryptionKeysDir, ReadOnly: true}))
+		volume := sts.Spec.Template.Spec.Volumes[len(sts.Spec.Template.Spec.Volumes)-1]
+		Expect(volume.Name).To(Equal("sealed-keys"))
+		Expect(volume.Secret.SecretName).To(Equal("backup-keys"))
+		Expect(restore.Env).NotTo(ContainElement(corev1.EnvVar{Name: "AGT_ALLOW_UNENCRYPTED", Value: "true"}))
+
+		store.Spec.Encryption.AllowUnencrypted = true
+		sts = properties.NewStatefulSetForInstance(instance, store, "/sealed.sql")
+		restore = sts.Spec.Template.Spec.InitContainers[0]
+		Expect(restore.Env).To(ContainElement(corev1.EnvVar{Name: "AGT_ALLOW_UNENCRYPTED", Value: "true"}))
+
+		key := bytes.Repeat([]byte{1}, encryptionKeySize)
+		encoded := base64.StdEncoding.EncodeToString(key)
+		Expect(encryptionKey(key)).To(Equal(encoded))
+		Expect(encryptionKey([]byte(encoded + "\n"))).To(Equal(encoded))
+		_, err := encryptionKey([]byte("short"))
+		Expect(err).To(Equal(ErrEncryptionKeyInvalid))
+	})
+
+	It("Create an Instance from a failed backup", func() {
+		ctx := context.TODO()
+		zapLog, _ := zap.NewDevelopment()
+		backup := mysqlv1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "backup-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.BackupSpec{
+				Store:    "store",
+				Instance: "blue",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &backup)).To(Succeed())
+		backup.Status.Reason = mysqlv1alpha1.BackupFailed
+		Expect(k8sClient.Status().Update(ctx, &backup)).To(Succeed())
+
+		instance := mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "instance-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Restore: mysqlv1alpha1.RestoreSpec{
+					BackupRef: backup.Name,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		instanceName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+		instanceReconcile := &InstanceReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+			Properties: &StatefulSetProperties{
+				AgentVersion: "latest",
+				MySQLVersion: "8.0.22",
+			},
+			Crontab:   NewMockCrontabCrontab(),
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
+		}
+		for i := 0; i < 6; i++ {
+			_, err := instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})
+			Expect(err).To(Succeed())
+		}
+
+		instanceResponse := mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceBackupFailed), "Expected reconcile to refuse the failed backup")
+		Expect(instanceResponse.Status.RestoredBackup).To(BeNil())
+	})
+
+	It("Create an Instance with an invalid specification", func() {
+		ctx := context.TODO()
+		zapLog, _ := zap.NewDevelopment()
+		instance := mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "instance-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Resources: mysqlv1alpha1.ResourcesSpec{
+					Agent: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		instanceName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+		instanceReconcile := &InstanceReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+			Properties: &StatefulSetProperties{
+				AgentVersion: "latest",
+				MySQLVersion: "8.0.22",
+			},
+			Crontab:   NewMockCrontabCrontab(),
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
+		}
+		Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+		Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+
+		instanceResponse := mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceSpecInvalid), "Expected reconcile to change the status to SpecInvalid")
+
+		secret := corev1.Secret{}
+		secretName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name + "-exporter"}
+		Expect(k8sClient.Get(ctx, secretName, &secret)).NotTo(Succeed(), "Expected the exporter secret not to be created")
+	})
+
+	It("Update the StatefulSet when the Instance changes", func() {
+		ctx := context.TODO()
+		zapLog, _ := zap.NewDevelopment()
+		instance := mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "instance-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Database: "me",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		instanceName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+		instanceReconcile := &InstanceReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+			Properties: &StatefulSetProperties{
+				AgentVersion: "latest",
+				MySQLVersion: "8.0.22",
+			},
+			Crontab:   NewMockCrontabCrontab(),
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
+		}
+		for i := 0; i < 5; i++ {
+			Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+		}
+		instanceResponse := mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceStatefulSetCreated), "Expected reconcile to change the status to StatefulSetCreated")
+
+		Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceStatefulSetWaiting), "Expected an unchanged statefulset to wait for pods")
+
+		instanceResponse.Spec.Resources.Exporter = corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		}
+		Expect(k8sClient.Update(ctx, &instanceResponse)).To(Succeed())
+		instanceReconcile.Properties.MySQLVersion = "8.0.23"
+
+		Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.Reason).
+			To(Equal(mysqlv1alpha1.InstanceStatefulSetUpdated), "Expected reconcile to change the status to StatefulSetUpdated")
+		Expect(instanceResponse.Status.Message).To(ContainSubstring("mysql/image"))
+		Expect(instanceResponse.Status.Message).To(ContainSubstring("exporter/resources"))
+
+		sts := appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, instanceName, &sts)).To(Succeed())
+		Expect(sts.Spec.Template.Spec.Containers[0].Image).To(Equal("mysql:8.0.23"))
+		Expect(sts.Spec.Template.Spec.Containers[2].Resources).To(Equal(instanceResponse.Spec.Resources.Exporter))
+	})
+
+	It("Delete an Instance with a final backup", func() {
+		ctx := context.TODO()
+		zapLog, _ := zap.NewDevelopment()
+		instance := mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "instance-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				DeletionPolicy: mysqlv1alpha1.DeletionPolicyBackupThenDelete,
+			},
+		}
+		Expect(validateInstance(&instance)).NotTo(Succeed(), "Expected a final backup without store to be rejected")
+		instance.Spec.BackupSchedule.Store = "final-store"
+		Expect(validateInstance(&instance)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		instanceName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+		instanceReconcile := &InstanceReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+			Properties: &StatefulSetProperties{
+				AgentVersion: "latest",
+				MySQLVersion: "8.0.22",
+			},
+			Crontab:   NewMockCrontabCrontab(),
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
+		}
+		Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+
+		instanceResponse := mysqlv1alpha1.Instance{}
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Finalizers).To(ContainElement(instanceFinalizer))
+
+		Expect(k8sClient.Delete(ctx, &instanceResponse)).To(Succeed())
+		Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).
+			To(Equal(ctrl.Result{RequeueAfter: finalBackupPollInterval}))
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed())
+		Expect(instanceResponse.Status.FinalBackup).To(HavePrefix(instance.Name + "-final-"))
+
+		backup := mysqlv1alpha1.Backup{}
+		backupName := types.NamespacedName{Namespace: instance.Namespace, Name: instanceResponse.Status.FinalBackup}
+		Expect(k8sClient.Get(ctx, backupName, &backup)).To(Succeed())
+		Expect(backup.Spec.Store).To(Equal("final-store"))
+		Expect(backup.OwnerReferences).To(BeEmpty(), "Expected the final backup to be kept with the instance deleted")
+
+		Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).
+			To(Equal(ctrl.Result{RequeueAfter: finalBackupPollInterval}))
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).To(Succeed(), "Expected the instance to wait for the backup")
+
+		backup.Status.Reason = mysqlv1alpha1.BackupSucceeded
+		Expect(k8sClient.Status().Update(ctx, &backup)).To(Succeed())
+		Expect(instanceReconcile.Reconcile(ctx, ctrl.Request{NamespacedName: instanceName})).To(Equal(ctrl.Result{}))
+		Expect(k8sClient.Get(ctx, instanceName, &instanceResponse)).NotTo(Succeed(), "Expected the instance to be deleted")
+
+		Expect(isInstanceVolumeClaim(&instance, instance.Name+"-data-"+instance.Name+"-0")).To(BeTrue())
+		Expect(isInstanceVolumeClaim(&instance, instance.Name+"-other-"+instance.Name+"-0")).To(BeFalse())
+	})
+
+	It("Label the replicas once they replicate from the primary", func() {
+		ctx := context.Background()
+		replicas := int32(3)
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "labels",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Database: "me",
+				Replicas: &replicas,
+			},
+			Status: mysqlv1alpha1.InstanceStatus{
+				Primary: "labels-0",
+				Members: []mysqlv1alpha1.MemberStatus{
+					{Name: "labels-0", Role: "primary"},
+					{Name: "labels-1", Role: "replica", State: "Running", Source: "labels-0.labels"},
+					{Name: "labels-2", Role: "replica", State: "Seeding", Source: "labels-0.labels"},
+				},
+			},
+		}
+		for i := 0; i < 3; i++ {
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("labels-%d", i),
+					Namespace: "default",
+					Labels:    map[string]string{"app": "labels", roleLabel: roleReplica},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "mysql", Image: "mysql:8.0.24"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &pod)).To(Succeed())
+		}
+		Expect(syncRoleLabels(ctx, k8sClient, instance)).To(Succeed())
+		roles := map[string]string{}
+		for i := 0; i < 3; i++ {
+			pod := corev1.Pod{}
+			podName := types.NamespacedName{Namespace: "default", Name: fmt.Sprintf("labels-%d", i)}
+			Expect(k8sClient.Get(ctx, podName, &pod)).To(Succeed())
+			roles[pod.Name] = pod.Labels[roleLabel]
+		}
+		Expect(roles).To(Equal(map[string]string{
+			"labels-0": rolePrimary,
+			"labels-1": roleReplica,
+			"labels-2": "",
+		}))
+	})
 })
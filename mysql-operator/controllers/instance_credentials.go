@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	rootUsername     = "root"
+	agentUsername    = "agent"
+	exporterUsername = "exporter"
+
+	// agentHost is the host of the agent account, the agent connects with
+	// TCP on the loopback and MySQL does not resolve names
+	agentHost = "127.0.0.1"
+
+	// rootConfigPath is where the root .my.cnf is mounted in the mysql
+	// container for the probes, and the root password in the agent container
+	// so that it sets it on instances that still have an empty one
+	rootConfigPath = "/etc/blaqkube/root"
+
+	// agentConfigPath is where the agent configuration is mounted. The
+	// kubelet updates the files when the secret changes and the agent
+	// reloads its credentials
+	agentConfigPath = "/etc/blaqkube/agent"
+	agentConfigFile = "agent.yaml"
+	agentInitFile   = "init.sql"
+
+	// exporterConfigPath is where the exporter .my.cnf is mounted. The
+	// exporter only reads it when it starts: it runs with a copy in
+	// exporterRunPath and its liveness probe fails when the kubelet has
+	// updated the secret, so that the kubelet restarts the exporter alone
+	exporterConfigPath = "/home"
+	exporterRunPath    = "/run/exporter"
+
+	// agentInitScript is run by the MySQL entrypoint when the instance is
+	// initialized, after the restored backup if any
+	agentInitScript = "/docker-entrypoint-initdb.d/zz-blaqkube-agent.sql"
+)
+
+// agentPrivileges are the privileges of the agent account. It manages
+// databases, users, grants, backups, replication and variables but it
+// cannot shutdown the server or access files.
+var agentPrivileges = []string{
+	"SELECT, INSERT, UPDATE, DELETE, CREATE, DROP, RELOAD, PROCESS, REFERENCES, INDEX, ALTER, " +
+		"SHOW DATABASES, CREATE TEMPORARY TABLES, LOCK TABLES, EXECUTE, REPLICATION SLAVE, " +
+		"REPLICATION CLIENT, CREATE VIEW, SHOW VIEW, CREATE ROUTINE, ALTER ROUTINE, CREATE USER, " +
+		"EVENT, TRIGGER ON *.* TO '%[1]s'@'%[2]s' WITH GRANT OPTION",
+	"APPLICATION_PASSWORD_ADMIN, BACKUP_ADMIN, CONNECTION_ADMIN, PERSIST_RO_VARIABLES_ADMIN, " +
+		"REPLICATION_SLAVE_ADMIN, SET_USER_ID, SYSTEM_USER, SYSTEM_VARIABLES_ADMIN ON *.* TO '%[1]s'@'%[2]s'",
+}
+
+// rootSecretData returns the content of the root secret
+func rootSecretData(password string) map[string]string {
+	return map[string]string{
+		".my.cnf":  fmt.Sprintf("[client]\nuser=%s\npassword=%s\n", rootUsername, password),
+		"username": rootUsername,
+		"password": password,
+	}
+}
+
+// agentSecretData returns the content of the agent secret: the agent
+// configuration, loaded with viper, and the script that creates the account
+func agentSecretData(password string) map[string]string {
+	config := fmt.Sprintf(
+		"agent_username: %s\nagent_password: %s\nbootstrap_file: %s/%s\n",
+		agentUsername,
+		password,
+		agentConfigPath,
+		agentInitFile,
+	)
+	init := fmt.Sprintf("CREATE USER IF NOT EXISTS '%[1]s'@'%[2]s' IDENTIFIED BY '%[3]s';\nALTER USER '%[1]s'@'%[2]s' IDENTIFIED BY '%[3]s';\n", agentUsername, agentHost, password)
+	for _, privileges := range agentPrivileges {
+		init += "GRANT " + fmt.Sprintf(privileges, agentUsername, agentHost) + ";\n"
+	}
+	return map[string]string{
+		agentConfigFile: config,
+		agentInitFile:   init,
+		"username":      agentUsername,
+		"password":      password,
+	}
+}
+
+// exporterSecretData returns the content of the exporter secret
+func exporterSecretData(password string) map[string]string {
+	return map[string]string{
+		".my.cnf":  fmt.Sprintf("[client]\nuser=%s\npassword=%s\nhost=localhost\n", exporterUsername, password),
+		"username": exporterUsername,
+		"password": password,
+	}
+}
+
+// getCredentialSecret gets a secret of the instance from its suffix
+func (im *InstanceManager) getCredentialSecret(instance *mysqlv1alpha1.Instance, suffix string) (*corev1.Secret, error) {
+	log := im.Reconciler.Log.WithValues("function", "getCredentialSecret", "namespace", instance.Namespace, "instance", instance.Name)
+
+	secretName := types.NamespacedName{
+		Name:      instance.Name + "-" + suffix,
+		Namespace: instance.Namespace,
+	}
+	secret := &corev1.Secret{}
+	err := im.Reconciler.Client.Get(im.Context, secretName, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Error getting secret", "secret", secretName.Name)
+	}
+	if err != nil {
+		log.Info("Secret does not exist", "secret", secretName.Name)
+	}
+	return secret, err
+}
+
+// createCredentialSecret creates a secret with a new password and keeps
+// track of it in reference
+func (im *InstanceManager) createCredentialSecret(
+	instance *mysqlv1alpha1.Instance,
+	suffix string,
+	data func(string) map[string]string,
+	reference *corev1.ObjectReference,
+	created, failed string,
+) (ctrl.Result, error) {
+	log := im.Reconciler.Log.WithValues("function", "createCredentialSecret", "namespace", instance.Namespace, "instance", instance.Name)
+	labels := map[string]string{
+		"app": instance.Name,
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name + "-" + suffix,
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		StringData: data(uuid.New().String()),
+	}
+	if err := controllerutil.SetControllerReference(instance, secret, im.Reconciler.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.Info("Create secret", "secret", secret.Name)
+	if err := im.Reconciler.Client.Create(im.Context, secret); err != nil {
+		log.Error(err, "Secret creation failed", "secret", secret.Name)
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             failed,
+			Message:            fmt.Sprintf("Secret %s creation failed: %v", suffix, err),
+		}
+		return im.setInstanceCondition(instance, condition)
+	}
+	log.Info("Secret create succeeded", "secret", secret.Name)
+	*reference = corev1.ObjectReference{
+		Kind:            secret.Kind,
+		Namespace:       secret.Namespace,
+		Name:            secret.Name,
+		UID:             secret.UID,
+		APIVersion:      secret.APIVersion,
+		ResourceVersion: secret.ResourceVersion,
+	}
+	condition := metav1.Condition{
+		Type:               "available",
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             created,
+		Message:            fmt.Sprintf("Secret %s has been successfully created", suffix),
+	}
+	return im.setInstanceCondition(instance, condition)
+}
+
+// reconcileCredentialSecrets creates the root and agent secrets when they
+// are missing. It reports true when a secret has been created or could not
+// be accessed and the result should be returned
+func (im *InstanceManager) reconcileCredentialSecrets(instance *mysqlv1alpha1.Instance) (bool, ctrl.Result, error) {
+	secrets := []struct {
+		suffix       string
+		data         func(string) map[string]string
+		reference    *corev1.ObjectReference
+		inaccessible string
+		created      string
+		failed       string
+	}{
+		{
+			suffix:       "root",
+			data:         rootSecretData,
+			reference:    &instance.Status.RootSecret,
+			inaccessible: mysqlv1alpha1.InstanceRootSecretInaccessible,
+			created:      mysqlv1alpha1.InstanceRootSecretCreated,
+			failed:       mysqlv1alpha1.InstanceRootSecretFailed,
+		},
+		{
+			suffix:       "agent",
+			data:         agentSecretData,
+			reference:    &instance.Status.AgentSecret,
+			inaccessible: mysqlv1alpha1.InstanceAgentSecretInaccessible,
+			created:      mysqlv1alpha1.InstanceAgentSecretCreated,
+			failed:       mysqlv1alpha1.InstanceAgentSecretFailed,
+		},
+	}
+	for _, s := range secrets {
+		_, err := im.getCredentialSecret(instance, s.suffix)
+		if err != nil && !errors.IsNotFound(err) {
+			condition := metav1.Condition{
+				Type:               "available",
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             s.inaccessible,
+				Message:            fmt.Sprintf("The secret %s could not be accessed: %v", s.suffix, err),
+			}
+			result, err := im.setInstanceCondition(instance, condition)
+			return true, result, err
+		}
+		if err != nil {
+			result, err := im.createCredentialSecret(instance, s.suffix, s.data, s.reference, s.created, s.failed)
+			return true, result, err
+		}
+	}
+	return false, ctrl.Result{}, nil
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	// instanceFinalizer keeps the instance until its deletion policy has
+	// been applied
+	instanceFinalizer = "mysql.blaqkube.io/instance"
+
+	// finalBackupPollInterval is how often the final backup is checked
+	finalBackupPollInterval = 10 * time.Second
+)
+
+// instanceDeletionPolicy returns the deletion policy, it defaults to Delete
+func instanceDeletionPolicy(instance *mysqlv1alpha1.Instance) string {
+	if instance.Spec.DeletionPolicy == "" {
+		return mysqlv1alpha1.DeletionPolicyDelete
+	}
+	return instance.Spec.DeletionPolicy
+}
+
+// finalBackupName is the name of the backup taken when the instance is
+// deleted. It contains the deletion time so that a backup kept from a
+// former instance with the same name is not used
+func finalBackupName(instance *mysqlv1alpha1.Instance) string {
+	return fmt.Sprintf("%s-final-%s", instance.Name, instance.DeletionTimestamp.Format("20060102-150405"))
+}
+
+// finalizeInstance applies the deletion policy and releases the instance so
+// that the statefulset and the other owned resources are deleted
+func (im *InstanceManager) finalizeInstance(instance *mysqlv1alpha1.Instance) (ctrl.Result, error) {
+	log := im.Reconciler.Log.WithValues("function", "finalizeInstance", "namespace", instance.Namespace, "instance", instance.Name)
+
+	if !controllerutil.ContainsFinalizer(instance, instanceFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	policy := instanceDeletionPolicy(instance)
+	if policy == mysqlv1alpha1.DeletionPolicyBackupThenDelete {
+		if done, result, err := im.reconcileFinalBackup(instance); !done {
+			return result, err
+		}
+	}
+	if policy != mysqlv1alpha1.DeletionPolicyRetain {
+		if err := im.deleteVolumeClaims(instance); err != nil {
+			log.Error(err, "Volume claims deletion failed")
+			return ctrl.Result{}, err
+		}
+	}
+	for _, schedule := range []string{BackupScheduling, MaintenanceScheduling, MaintenanceUnscheduling} {
+		im.Reconciler.Crontab.unSchedule(instance, schedule)
+	}
+	log.Info("Release instance", "deletionPolicy", policy)
+	controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+	return ctrl.Result{}, im.Reconciler.Update(im.Context, instance)
+}
+
+// reconcileFinalBackup creates the final backup and reports true once it
+// has succeeded. The backup is not owned by the instance so that it is
+// kept after the instance is deleted
+func (im *InstanceManager) reconcileFinalBackup(instance *mysqlv1alpha1.Instance) (bool, ctrl.Result, error) {
+	log := im.Reconciler.Log.WithValues("function", "reconcileFinalBackup", "namespace", instance.Namespace, "instance", instance.Name)
+
+	if instance.Spec.BackupSchedule.Store == "" {
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceFinalBackupFailed,
+			Message:            "The final backup requires backupSchedule.store, change deletionPolicy to delete the instance",
+		}
+		result, err := im.setInstanceCondition(instance, condition)
+		return false, result, err
+	}
+	backupName := types.NamespacedName{
+		Name:      finalBackupName(instance),
+		Namespace: instance.Namespace,
+	}
+	backup := &mysqlv1alpha1.Backup{}
+	err := im.Reconciler.Client.Get(im.Context, backupName, backup)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Error getting final backup", "backup", backupName.Name)
+		return false, ctrl.Result{}, err
+	}
+	if err != nil {
+		backup = &mysqlv1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      backupName.Name,
+				Namespace: backupName.Namespace,
+				Labels: map[string]string{
+					"app": instance.Name,
+				},
+			},
+			Spec: mysqlv1alpha1.BackupSpec{
+				Store:       instance.Spec.BackupSchedule.Store,
+				Instance:    instance.Name,
+				Compression: instance.Spec.BackupSchedule.Compression,
+				Method:      instance.Spec.BackupSchedule.Method,
+				Dump:        instance.Spec.BackupSchedule.Dump,
+			},
+		}
+		log.Info("Create final backup", "backup", backup.Name)
+		if err := im.Reconciler.Client.Create(im.Context, backup); err != nil {
+			log.Error(err, "Final backup creation failed", "backup", backup.Name)
+			return false, ctrl.Result{}, err
+		}
+		instance.Status.FinalBackup = backup.Name
+		if err := im.Reconciler.Status().Update(im.Context, instance); err != nil {
+			log.Error(err, "Unable to update instance")
+			return false, ctrl.Result{}, err
+		}
+		return false, ctrl.Result{RequeueAfter: finalBackupPollInterval}, nil
+	}
+	switch backup.Status.Reason {
+	case mysqlv1alpha1.BackupSucceeded:
+		log.Info("Final backup succeeded", "backup", backup.Name)
+		return true, ctrl.Result{}, nil
+	case mysqlv1alpha1.BackupFailed, mysqlv1alpha1.BackupNotImplemented:
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceFinalBackupFailed,
+			Message: fmt.Sprintf("The final backup %s has failed, change deletionPolicy to delete the instance",
+				backup.Name,
+			),
+		}
+		result, err := im.setInstanceCondition(instance, condition)
+		return false, result, err
+	}
+	log.Info("Waiting for the final backup", "backup", backup.Name, "reason", backup.Status.Reason)
+	return false, ctrl.Result{RequeueAfter: finalBackupPollInterval}, nil
+}
+
+// deleteVolumeClaims deletes the volume claims created by the statefulset.
+// Kubernetes keeps them until the pods that use them are deleted
+func (im *InstanceManager) deleteVolumeClaims(instance *mysqlv1alpha1.Instance) error {
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := im.Reconciler.Client.List(
+		im.Context,
+		claims,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{"app": instance.Name},
+	); err != nil {
+		return err
+	}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if !isInstanceVolumeClaim(instance, claim.Name) {
+			continue
+		}
+		im.Reconciler.Log.Info("Delete volume claim", "namespace", instance.Namespace, "instance", instance.Name, "claim", claim.Name)
+		if err := im.Reconciler.Client.Delete(im.Context, claim); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isInstanceVolumeClaim checks a claim has been created from one of the
+// statefulset volume claim templates, <template>-<statefulset>-<ordinal>
+func isInstanceVolumeClaim(instance *mysqlv1alpha1.Instance, name string) bool {
+	for _, template := range []string{instance.Name + "-data", instance.Name + "-init"} {
+		if strings.HasPrefix(name, template+"-"+instance.Name+"-") {
+			return true
+		}
+	}
+	return false
+}
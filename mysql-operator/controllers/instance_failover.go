@@ -0,0 +1,439 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	// defaultFailoverDelay is well above the startup window, so that a
+	// primary that restarts is not replaced
+	defaultFailoverDelay  = 120
+	defaultPromoteTimeout = 60
+
+	// startupWindow is how long the mysql container can be not ready after
+	// it starts: the probes wait 30s and fail after 3 checks 10s apart
+	startupWindow = 90 * time.Second
+)
+
+var (
+	// ErrNoStandbyAvailable is reported when no replica can be promoted
+	ErrNoStandbyAvailable = errors.New("NoStandbyAvailable")
+
+	// ErrPromotionFailed is reported when the elected replica could not be promoted
+	ErrPromotionFailed = errors.New("PromotionFailed")
+
+	// ErrDemotionFailed is reported when the primary could not be demoted
+	ErrDemotionFailed = errors.New("DemotionFailed")
+
+	// ErrFencingFailed is reported when the clients could not be stopped from
+	// writing to a lost primary
+	ErrFencingFailed = errors.New("FencingFailed")
+)
+
+// Member is a MySQL server of an instance that takes part in a failover
+type Member struct {
+	// Name of the pod
+	Name string
+	// Address is the IP of the pod, it is empty when the pod is lost
+	Address string
+	// Host is the name of the pod in the headless service, the members
+	// replicate from it so that they follow the pod when its IP changes
+	Host string
+	// ServerID is the server_id used when the member replicates
+	ServerID int32
+}
+
+// Failover promotes the most advanced replica and points the other members
+// to it. It only relies on the agents and can be tested with a fake
+// ReplicationConnector.
+type Failover struct {
+	Context   context.Context
+	Connector ReplicationConnector
+	Log       logr.Logger
+	// Timeout is the time in seconds the elected replica has to apply its
+	// relay log before the promotion fails
+	Timeout int32
+	// Fence is called when the primary is lost, before the elected standby
+	// is promoted. It stops the clients from writing to the lost primary
+	Fence func(elected *Member) error
+}
+
+// Elect returns the standby with the most advanced GTID set among the
+// replicas of the primary. The standby that contains the GTID sets of all the
+// others is preferred, then the one with the most transactions and then the
+// first one in the list.
+func (f *Failover) Elect(primary Member, standbys []Member) (*Member, error) {
+	log := f.Log.WithValues("function", "Elect")
+	var elected *Member
+	var electedSet gtidSet
+	sets := []gtidSet{}
+	candidates := []int{}
+	for i, standby := range standbys {
+		replication, err := f.Connector.GetReplication(f.Context, standby.Address)
+		if err != nil {
+			log.Info(fmt.Sprintf("Standby is not available, error: %v", err), "member", standby.Name)
+			continue
+		}
+		if replication.State == "Seeding" {
+			log.Info("Standby is being seeded", "member", standby.Name)
+			continue
+		}
+		// the replicas started before the host was used replicate from the IP
+		if replication.Role != roleReplica || replication.Source == "" ||
+			(replication.Source != primary.Host && replication.Source != primary.Address) {
+			log.Info("Standby does not replicate from the primary", "member", standby.Name, "role", replication.Role, "source", replication.Source)
+			continue
+		}
+		executed, err := parseGTIDSet(replication.GtidExecuted)
+		if err != nil {
+			log.Info(fmt.Sprintf("Standby GTID executed is not valid, error: %v", err), "member", standby.Name)
+			continue
+		}
+		retrieved, err := parseGTIDSet(replication.GtidRetrieved)
+		if err != nil {
+			log.Info(fmt.Sprintf("Standby GTID retrieved is not valid, error: %v", err), "member", standby.Name)
+			continue
+		}
+		sets = append(sets, executed.union(retrieved))
+		candidates = append(candidates, i)
+	}
+	for k, set := range sets {
+		superset := true
+		for _, other := range sets {
+			if !set.contains(other) {
+				superset = false
+				break
+			}
+		}
+		if superset {
+			return &standbys[candidates[k]], nil
+		}
+		if elected == nil || set.count() > electedSet.count() {
+			elected = &standbys[candidates[k]]
+			electedSet = set
+		}
+	}
+	if elected == nil {
+		return nil, ErrNoStandbyAvailable
+	}
+	log.Info("No standby contains all the transactions, some might be lost", "member", elected.Name)
+	return elected, nil
+}
+
+// Run promotes a standby and points the other members to it. When the
+// primary is not lost, it is a switchover: the primary is demoted first, the
+// elected standby applies all its transactions and the primary becomes a
+// replica. Otherwise, the primary is fenced before the promotion: it is
+// demoted when its agent can still be reached and Fence stops the clients
+// from using it. It is seeded again by the instance reconciliation when it
+// comes back. A non empty target is the standby to promote.
+func (f *Failover) Run(primary Member, lost bool, standbys []Member, user agent.User, target string) (*Member, error) {
+	log := f.Log.WithValues("function", "Run")
+	candidates := standbys
+	if target != "" {
+		candidates = []Member{}
+		for _, standby := range standbys {
+			if standby.Name == target {
+				candidates = append(candidates, standby)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, ErrNoStandbyAvailable
+		}
+	}
+	promote := agent.PromoteRequest{Timeout: f.Timeout}
+	if !lost {
+		log.Info("Demote primary", "member", primary.Name)
+		replication, err := f.Connector.DemotePrimary(f.Context, primary.Address)
+		if err != nil {
+			log.Info(fmt.Sprintf("Primary demotion failed, error: %v", err), "member", primary.Name)
+			return nil, ErrDemotionFailed
+		}
+		promote.GtidSet = replication.GtidExecuted
+	}
+	elected, err := f.Elect(primary, candidates)
+	if err != nil {
+		f.rollback(primary, lost)
+		return nil, err
+	}
+	if lost {
+		if err := f.fence(primary, elected); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("Promote standby", "member", elected.Name)
+	if _, err := f.Connector.PromoteReplica(f.Context, elected.Address, promote); err != nil {
+		log.Info(fmt.Sprintf("Standby promotion failed, error: %v", err), "member", elected.Name)
+		f.rollback(primary, lost)
+		return nil, ErrPromotionFailed
+	}
+	members := []Member{}
+	for _, standby := range standbys {
+		if standby.Name != elected.Name {
+			members = append(members, standby)
+		}
+	}
+	if !lost {
+		members = append(members, primary)
+	}
+	for _, member := range members {
+		request := agent.ReplicationRequest{
+			Host:     elected.Host,
+			Port:     mysqlPort,
+			Username: user.Username,
+			Password: user.Password,
+			ServerId: member.ServerID,
+			Seed:     "none",
+		}
+		if _, err := f.Connector.CreateReplication(f.Context, member.Address, request); err != nil {
+			// the instance reconciliation points the member to the primary later
+			log.Info(fmt.Sprintf("Replication change failed, error: %v", err), "member", member.Name)
+		}
+	}
+	return elected, nil
+}
+
+// fence makes a lost primary read-only when its agent answers and calls
+// Fence so that the clients stop using it
+func (f *Failover) fence(primary Member, elected *Member) error {
+	log := f.Log.WithValues("function", "fence")
+	if primary.Address != "" {
+		if _, err := f.Connector.DemotePrimary(f.Context, primary.Address); err != nil {
+			log.Info(fmt.Sprintf("Lost primary cannot be demoted, error: %v", err), "member", primary.Name)
+		}
+	}
+	if f.Fence == nil {
+		return nil
+	}
+	if err := f.Fence(elected); err != nil {
+		log.Info(fmt.Sprintf("Lost primary cannot be fenced, error: %v", err), "member", primary.Name)
+		return ErrFencingFailed
+	}
+	return nil
+}
+
+// rollback makes a demoted primary writable again
+func (f *Failover) rollback(primary Member, lost bool) {
+	if lost {
+		return
+	}
+	f.Log.Info("Restore primary", "member", primary.Name)
+	if _, err := f.Connector.PromoteReplica(f.Context, primary.Address, agent.PromoteRequest{}); err != nil {
+		f.Log.Info(fmt.Sprintf("Primary restore failed, error: %v", err), "member", primary.Name)
+	}
+}
+
+// getMembers returns the primary and the standbys of an instance that have
+// a pod with an IP. The primary is nil when its pod is not available.
+func getMembers(ctx context.Context, c client.Client, instance *mysqlv1alpha1.Instance) (*Member, []Member) {
+	var primary *Member
+	standbys := []Member{}
+	for i := int32(0); i < instanceReplicas(instance); i++ {
+		pod := &corev1.Pod{}
+		podName := types.NamespacedName{
+			Name:      fmt.Sprintf("%s-%d", instance.Name, i),
+			Namespace: instance.Namespace,
+		}
+		if err := c.Get(ctx, podName, pod); err != nil || pod.Status.PodIP == "" {
+			continue
+		}
+		member := Member{
+			Name:     pod.Name,
+			Address:  pod.Status.PodIP,
+			Host:     memberHost(instance, pod.Name),
+			ServerID: i + 1,
+		}
+		if pod.Name == instance.Status.Primary {
+			primary = &member
+			continue
+		}
+		standbys = append(standbys, member)
+	}
+	return primary, standbys
+}
+
+// isPodReady reports if the pod Ready condition is true
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isPodStarting reports if the mysql container of a pod that is not ready
+// is still in its startup window. Once it is over, the pod is failing.
+func isPodStarting(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "mysql" && status.State.Running != nil {
+			return time.Since(status.State.Running.StartedAt.Time) < startupWindow
+		}
+	}
+	return time.Since(pod.CreationTimestamp.Time) < startupWindow
+}
+
+// isRestarting reports if a restart or an upgrade operation of the instance
+// is running. The pods are then restarted on purpose, one at a time.
+func (im *InstanceManager) isRestarting(instance *mysqlv1alpha1.Instance) bool {
+	operations := &mysqlv1alpha1.OperationList{}
+	if err := im.Reconciler.Client.List(im.Context, operations, client.InNamespace(instance.Namespace)); err != nil {
+		return false
+	}
+	for _, operation := range operations.Items {
+		if operation.Spec.Instance != instance.Name || operation.Status.Reason != mysqlv1alpha1.OperationRunning {
+			continue
+		}
+		if operation.Spec.Type == mysqlv1alpha1.OperationTypeRestart || operation.Spec.Type == mysqlv1alpha1.OperationTypeUpgrade {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileFailover checks the primary is available through its agent and
+// promotes a replica when its pod has been gone or failing for longer than
+// the failover delay. A primary that is starting, or that is restarted by an
+// operation, is not lost. It reports true when the reconciliation should
+// stop with the returned result.
+func (im *InstanceManager) reconcileFailover(instance *mysqlv1alpha1.Instance, secret *corev1.Secret) (bool, ctrl.Result, error) {
+	log := im.Reconciler.Log.WithValues("function", "reconcileFailover", "namespace", instance.Namespace, "instance", instance.Name)
+
+	if instance.Status.Primary == "" {
+		return false, ctrl.Result{}, nil
+	}
+	if im.isRestarting(instance) {
+		log.Info("The pods are being restarted by an operation, the failover is skipped")
+		return false, ctrl.Result{}, nil
+	}
+	primary, standbys := getMembers(im.Context, im.Reconciler.Client, instance)
+	err := ErrPodNotFound
+	if primary != nil {
+		pod := &corev1.Pod{}
+		podName := types.NamespacedName{Name: primary.Name, Namespace: instance.Namespace}
+		if err = im.Reconciler.Client.Get(im.Context, podName, pod); err == nil && !isPodReady(pod) {
+			if isPodStarting(pod) {
+				log.Info("Primary is starting", "member", primary.Name)
+				return false, ctrl.Result{}, nil
+			}
+			err = ErrInstanceNotReady
+		}
+		if err == nil {
+			_, err = im.Reconciler.Connector.GetReplication(im.Context, primary.Address)
+		}
+	}
+	if err == nil {
+		if instance.Status.PrimaryLostTime == nil {
+			return false, ctrl.Result{}, nil
+		}
+		log.Info("Primary is available again", "member", instance.Status.Primary)
+		instance.Status.PrimaryLostTime = nil
+		if err := im.Reconciler.Status().Update(im.Context, instance); err != nil {
+			log.Error(err, "Unable to update instance")
+			return true, ctrl.Result{}, err
+		}
+		return true, ctrl.Result{}, nil
+	}
+	if instance.Spec.Failover.Disabled {
+		return false, ctrl.Result{}, nil
+	}
+	delay := time.Duration(defaultFailoverDelay) * time.Second
+	if instance.Spec.Failover.Delay != nil {
+		delay = time.Duration(*instance.Spec.Failover.Delay) * time.Second
+	}
+	if instance.Status.PrimaryLostTime == nil {
+		now := metav1.Now()
+		instance.Status.PrimaryLostTime = &now
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstancePrimaryUnavailable,
+			Message:            fmt.Sprintf("Primary %s is not available: %v", instance.Status.Primary, err),
+		}
+		return true, ctrl.Result{RequeueAfter: delay}, im.saveFailoverStatus(instance, condition)
+	}
+	if wait := delay - time.Since(instance.Status.PrimaryLostTime.Time); wait > 0 {
+		return true, ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	log.Info("Starting failover", "primary", instance.Status.Primary)
+	lost := Member{Name: instance.Status.Primary, Host: memberHost(instance, instance.Status.Primary)}
+	if primary != nil {
+		lost = *primary
+	}
+	f := &Failover{
+		Context:   im.Context,
+		Connector: im.Reconciler.Connector,
+		Log:       im.Reconciler.Log.WithValues("namespace", instance.Namespace, "instance", instance.Name),
+		Timeout:   defaultPromoteTimeout,
+		// the client service points to the elected standby, that is
+		// read-only until it is promoted
+		Fence: func(elected *Member) error {
+			fenced := instance.DeepCopy()
+			fenced.Status.Primary = elected.Name
+			return syncServices(im.Context, im.Reconciler.Client, im.Reconciler.Scheme, fenced)
+		},
+	}
+	user := agent.User{
+		Username: string(secret.Data["username"]),
+		Password: string(secret.Data["password"]),
+	}
+	elected, err := f.Run(lost, true, standbys, user, "")
+	if err != nil {
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceFailoverFailed,
+			Message:            fmt.Sprintf("Failover from %s failed: %v", instance.Status.Primary, err),
+		}
+		result, err := im.setInstanceCondition(instance, condition)
+		return true, result, err
+	}
+	previous := instance.Status.Primary
+	instance.Status.Primary = elected.Name
+	instance.Status.PrimaryLostTime = nil
+	if err := syncServices(im.Context, im.Reconciler.Client, im.Reconciler.Scheme, instance); err != nil {
+		log.Error(err, "Unable to update the services")
+	}
+	condition := metav1.Condition{
+		Type:               "available",
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             mysqlv1alpha1.InstanceFailoverSucceeded,
+		Message:            fmt.Sprintf("Primary has moved from %s to %s", previous, elected.Name),
+	}
+	return true, ctrl.Result{}, im.saveFailoverStatus(instance, condition)
+}
+
+// saveFailoverStatus saves the primary and the condition. Unlike
+// setInstanceCondition, the status is saved when the reason does not change
+// so that a second failover is not lost.
+func (im *InstanceManager) saveFailoverStatus(instance *mysqlv1alpha1.Instance, condition metav1.Condition) error {
+	if condition.Reason != instance.Status.Reason {
+		_, err := im.setInstanceCondition(instance, condition)
+		return err
+	}
+	if err := im.Reconciler.Status().Update(im.Context, instance); err != nil {
+		im.Reconciler.Log.Error(err, "Unable to update instance", "namespace", instance.Namespace, "instance", instance.Name)
+		return err
+	}
+	return nil
+}
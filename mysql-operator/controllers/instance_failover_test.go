@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/zapr"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
+)
+
+// MockReplicationConnector a fake agent that implements the ReplicationConnector
+type MockReplicationConnector struct {
+	// Members is the replication status by address, a missing address is
+	// an agent that cannot be reached
+	Members map[string]*agent.Replication
+	// Failures are the addresses that fail to be promoted
+	Failures map[string]bool
+	// Calls records the requests in the order they are made
+	Calls []string
+}
+
+// NewMockReplicationConnector generates a fake agent for a set of members
+func NewMockReplicationConnector(members map[string]*agent.Replication) *MockReplicationConnector {
+	return &MockReplicationConnector{
+		Members:  members,
+		Failures: map[string]bool{},
+		Calls:    []string{},
+	}
+}
+
+// GetReplication returns the replication status of a member
+func (mc *MockReplicationConnector) GetReplication(ctx context.Context, address string) (*agent.Replication, error) {
+	replication, ok := mc.Members[address]
+	if !ok {
+		return nil, ErrAgentAccessFailed
+	}
+	return replication, nil
+}
+
+// CreateReplicationUser records the user creation
+func (mc *MockReplicationConnector) CreateReplicationUser(ctx context.Context, address string, user agent.User) error {
+	if _, ok := mc.Members[address]; !ok {
+		return ErrAgentAccessFailed
+	}
+	mc.Calls = append(mc.Calls, "user "+address)
+	return nil
+}
+
+// CreateReplication points a member to a source
+func (mc *MockReplicationConnector) CreateReplication(ctx context.Context, address string, request agent.ReplicationRequest) (*agent.Replication, error) {
+	replication, ok := mc.Members[address]
+	if !ok {
+		return nil, ErrAgentAccessFailed
+	}
+	mc.Calls = append(mc.Calls, "replicate "+address+" from "+request.Host)
+	replication.Role = "replica"
+	replication.Source = request.Host
+	return replication, nil
+}
+
+// PromoteReplica makes a member the primary
+func (mc *MockReplicationConnector) PromoteReplica(ctx context.Context, address string, request agent.PromoteRequest) (*agent.Replication, error) {
+	replication, ok := mc.Members[address]
+	if !ok {
+		return nil, ErrAgentAccessFailed
+	}
+	mc.Calls = append(mc.Calls, "promote "+address)
+	if mc.Failures[address] {
+		return nil, errors.New("timeout")
+	}
+	replication.Role = "primary"
+	replication.Source = ""
+	return replication, nil
+}
+
+// DemotePrimary makes the primary read-only
+func (mc *MockReplicationConnector) DemotePrimary(ctx context.Context, address string) (*agent.Replication, error) {
+	replication, ok := mc.Members[address]
+	if !ok {
+		return nil, ErrAgentAccessFailed
+	}
+	mc.Calls = append(mc.Calls, "demote "+address)
+	return replication, nil
+}
+
+func newTestFailover(connector ReplicationConnector) *Failover {
+	zapLog, _ := zap.NewDevelopment()
+	return &Failover{
+		Context:   context.TODO(),
+		Connector: connector,
+		Log:       zapr.NewLogger(zapLog),
+		Timeout:   10,
+	}
+}
+
+const (
+	testUUID1 = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	testUUID2 = "2174b383-5441-11e8-b90a-c80aa9429562"
+)
+
+var (
+	testUser     = agent.User{Username: "replication", Password: "changeme"}
+	testPrimary  = Member{Name: "blue-0", Address: "10.0.0.10", Host: "blue-0.blue", ServerID: 1}
+	testStandbys = []Member{
+		{Name: "blue-1", Address: "10.0.0.11", Host: "blue-1.blue", ServerID: 2},
+		{Name: "blue-2", Address: "10.0.0.12", Host: "blue-2.blue", ServerID: 3},
+	}
+)
+
+func TestElectStandbyWithAllTransactions(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.11": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+		"10.0.0.12": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-8", GtidRetrieved: testUUID1 + ":1-12"},
+	})
+	elected, err := newTestFailover(connector).Elect(testPrimary, testStandbys)
+	require.NoError(t, err)
+	require.Equal(t, "blue-2", elected.Name)
+}
+
+func TestElectStandbyWithMostTransactions(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.11": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10," + testUUID2 + ":1"},
+		"10.0.0.12": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-8," + testUUID2 + ":1-2"},
+	})
+	elected, err := newTestFailover(connector).Elect(testPrimary, testStandbys)
+	require.NoError(t, err)
+	require.Equal(t, "blue-1", elected.Name, "Expected the standby with the most transactions when none contains all")
+}
+
+func TestElectSkipsUnavailableStandbys(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.11": {Role: "replica", State: "Seeding"},
+	})
+	_, err := newTestFailover(connector).Elect(testPrimary, testStandbys)
+	require.Equal(t, ErrNoStandbyAvailable, err, "Expected standbys that are seeding or cannot be reached to be skipped")
+}
+
+func TestElectOnlyReplicasOfPrimary(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.11": {Role: "primary", State: "Running", GtidExecuted: testUUID1 + ":1-12"},
+		"10.0.0.12": {Role: "replica", State: "Running", Source: "blue-3.blue", GtidExecuted: testUUID1 + ":1-11"},
+	})
+	_, err := newTestFailover(connector).Elect(testPrimary, testStandbys)
+	require.Equal(t, ErrNoStandbyAvailable, err)
+
+	connector.Members["10.0.0.12"].Source = "blue-0.blue"
+	elected, err := newTestFailover(connector).Elect(testPrimary, testStandbys)
+	require.NoError(t, err)
+	require.Equal(t, "blue-2", elected.Name)
+
+	// a replica started before the host was used replicates from the IP
+	connector.Members["10.0.0.12"].Source = "10.0.0.10"
+	elected, err = newTestFailover(connector).Elect(testPrimary, testStandbys)
+	require.NoError(t, err)
+	require.Equal(t, "blue-2", elected.Name)
+}
+
+func TestElectReplicasOfLostPrimaryWithoutPod(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.11": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+	})
+	lost := Member{Name: "blue-0", Host: "blue-0.blue"}
+	elected, err := newTestFailover(connector).Elect(lost, testStandbys)
+	require.NoError(t, err)
+	require.Equal(t, "blue-1", elected.Name)
+}
+
+func TestRunFailover(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.11": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+		"10.0.0.12": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-9"},
+	})
+	failover := newTestFailover(connector)
+	failover.Fence = func(elected *Member) error {
+		connector.Calls = append(connector.Calls, "fence to "+elected.Name)
+		return nil
+	}
+	elected, err := failover.Run(testPrimary, true, testStandbys, testUser, "")
+	require.NoError(t, err)
+	require.Equal(t, "blue-1", elected.Name)
+	require.Equal(t, []string{
+		"fence to blue-1",
+		"promote 10.0.0.11",
+		"replicate 10.0.0.12 from blue-1.blue",
+	}, connector.Calls)
+	require.Equal(t, "primary", connector.Members["10.0.0.11"].Role)
+}
+
+func TestRunFailoverDemotesReachablePrimary(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.10": {Role: "primary", State: "Running", GtidExecuted: testUUID1 + ":1-10"},
+		"10.0.0.11": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+	})
+	elected, err := newTestFailover(connector).Run(testPrimary, true, testStandbys, testUser, "")
+	require.NoError(t, err)
+	require.Equal(t, "blue-1", elected.Name)
+	require.Equal(t, []string{
+		"demote 10.0.0.10",
+		"promote 10.0.0.11",
+	}, connector.Calls)
+}
+
+func TestRunFailoverWithoutFencing(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.11": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+	})
+	failover := newTestFailover(connector)
+	failover.Fence = func(elected *Member) error {
+		return errors.New("forbidden")
+	}
+	_, err := failover.Run(testPrimary, true, testStandbys, testUser, "")
+	require.Equal(t, ErrFencingFailed, err)
+	require.Empty(t, connector.Calls, "Expected no promotion when the lost primary cannot be fenced")
+}
+
+func TestRunSwitchoverToTarget(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.10": {Role: "primary", State: "Running", GtidExecuted: testUUID1 + ":1-10"},
+		"10.0.0.11": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+		"10.0.0.12": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+	})
+	elected, err := newTestFailover(connector).Run(testPrimary, false, testStandbys, testUser, "blue-2")
+	require.NoError(t, err)
+	require.Equal(t, "blue-2", elected.Name)
+	require.Equal(t, []string{
+		"demote 10.0.0.10",
+		"promote 10.0.0.12",
+		"replicate 10.0.0.11 from blue-2.blue",
+		"replicate 10.0.0.10 from blue-2.blue",
+	}, connector.Calls)
+}
+
+func TestRunSwitchoverRestoresPrimary(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{
+		"10.0.0.10": {Role: "primary", State: "Running", GtidExecuted: testUUID1 + ":1-10"},
+		"10.0.0.11": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+		"10.0.0.12": {Role: "replica", State: "Running", Source: "blue-0.blue", GtidExecuted: testUUID1 + ":1-10"},
+	})
+	connector.Failures["10.0.0.11"] = true
+	_, err := newTestFailover(connector).Run(testPrimary, false, testStandbys, testUser, "")
+	require.Equal(t, ErrPromotionFailed, err)
+	require.Equal(t, []string{
+		"demote 10.0.0.10",
+		"promote 10.0.0.11",
+		"promote 10.0.0.10",
+	}, connector.Calls)
+	require.Equal(t, "blue-0.blue", connector.Members["10.0.0.12"].Source)
+}
+
+func TestRunSwitchoverToUnknownTarget(t *testing.T) {
+	connector := NewMockReplicationConnector(map[string]*agent.Replication{})
+	_, err := newTestFailover(connector).Run(testPrimary, false, testStandbys, testUser, "blue-9")
+	require.Equal(t, ErrNoStandbyAvailable, err)
+	require.Empty(t, connector.Calls)
+}
+
+func TestPodStarting(t *testing.T) {
+	started := func(d time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "mysql",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(time.Now().Add(-d))}},
+				}},
+			},
+		}
+	}
+	require.True(t, isPodStarting(started(30*time.Second)), "Expected a container in the startup window to be starting")
+	require.False(t, isPodStarting(started(5*time.Minute)), "Expected a container not ready after the startup window to be failing")
+
+	waiting := started(0)
+	waiting.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+	require.False(t, isPodStarting(waiting), "Expected a container that keeps crashing to be failing")
+	waiting.CreationTimestamp = metav1.Now()
+	require.True(t, isPodStarting(waiting), "Expected a new pod to be starting")
+}
@@ -3,21 +3,25 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 	"github.com/google/uuid"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 const (
 	maxInstanceConditions = 10
+	defaultVolumeSize     = 500 * 1024 * 1024
 )
 
 // StatefulSetProperties defines the default agent and mysql versions
@@ -57,6 +61,100 @@ func (im *InstanceManager) setInstanceCondition(instance *mysqlv1alpha1.Instance
 	return ctrl.Result{}, nil
 }
 
+// validateInstance checks the instance specification before any of its
+// subcomponents is created
+func validateInstance(instance *mysqlv1alpha1.Instance) error {
+	volumes := []struct {
+		name string
+		size *resource.Quantity
+	}{
+		{name: "data", size: instance.Spec.Storage.Data.Size},
+		{name: "init", size: instance.Spec.Storage.Init.Size},
+	}
+	for _, v := range volumes {
+		if v.size != nil && v.size.Sign() <= 0 {
+			return fmt.Errorf("storage.%s.size should be positive, current value: %s", v.name, v.size.String())
+		}
+	}
+	if instance.Spec.Storage.StorageClassName != nil {
+		name := *instance.Spec.Storage.StorageClassName
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("storage.storageClassName %q is not valid: %s", name, strings.Join(errs, ", "))
+		}
+	}
+	if instanceReplicas(instance) < 1 {
+		return fmt.Errorf("replicas should be at least 1, current value: %d", instanceReplicas(instance))
+	}
+	if instance.Spec.Replication.Seed == mysqlv1alpha1.ReplicationSeedStore &&
+		(instance.Spec.Replication.Store == "" || instance.Spec.Replication.Location == "") {
+		return fmt.Errorf("replication.store and replication.location are required to seed replicas from a store")
+	}
+	if instance.Spec.Replication.Seed == mysqlv1alpha1.ReplicationSeedStore {
+		// a physical backup can only be restored in the data directory of
+		// a new instance, a replica is seeded from a dump while MySQL runs
+		if details, _, ok := locationDetails(instance.Spec.Replication.Location); ok && isPhysical(details.Method) {
+			return fmt.Errorf("replication.location %q is a %s backup, replicas can only be seeded from a mysqldump backup", instance.Spec.Replication.Location, details.Method)
+		}
+	}
+	if instance.Spec.Version != "" {
+		if !versionFormat.MatchString(instance.Spec.Version) {
+			return fmt.Errorf("version %q is not valid, it should look like 8.0.24", instance.Spec.Version)
+		}
+		if instance.Status.Version != "" && compareVersions(instance.Spec.Version, instance.Status.Version) < 0 {
+			return fmt.Errorf("version cannot be downgraded from %s to %s", instance.Status.Version, instance.Spec.Version)
+		}
+	}
+	if instance.Spec.DeletionPolicy == mysqlv1alpha1.DeletionPolicyBackupThenDelete && instance.Spec.BackupSchedule.Store == "" {
+		return fmt.Errorf("backupSchedule.store is required to take a backup before the instance is deleted")
+	}
+	if instance.Spec.BinlogArchive.Enabled && instance.Spec.BackupSchedule.Store == "" {
+		return fmt.Errorf("backupSchedule.store is required to archive the binary logs")
+	}
+	restore := instance.Spec.Restore
+	if (restore.Time != nil || restore.GTIDSet != "") && restore.BinlogLocation == "" {
+		return fmt.Errorf("restore.binlogLocation is required to restore to a time or a GTID set")
+	}
+	if restore.BackupRef != "" && (restore.Store != "" || restore.Location != "") {
+		return fmt.Errorf("restore.backupRef cannot be used with restore.store and restore.location")
+	}
+	if restore.BinlogLocation != "" && restore.BackupRef == "" && (restore.Store == "" || restore.Location == "") {
+		return fmt.Errorf("restore.backupRef or restore.store and restore.location are required to replay the binary logs")
+	}
+	if err := validateConfig(instance.Spec.Config); err != nil {
+		return err
+	}
+	if instance.Status.Primary != "" && memberOrdinal(instance, instance.Status.Primary) >= instanceReplicas(instance) {
+		return fmt.Errorf("replicas cannot remove the primary %s, run a switchover first", instance.Status.Primary)
+	}
+	containers := []struct {
+		name      string
+		resources corev1.ResourceRequirements
+	}{
+		{name: "mysql", resources: instance.Spec.Resources.MySQL},
+		{name: "agent", resources: instance.Spec.Resources.Agent},
+		{name: "exporter", resources: instance.Spec.Resources.Exporter},
+	}
+	for _, c := range containers {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			limit, hasLimit := c.resources.Limits[name]
+			if hasLimit && limit.Sign() < 0 {
+				return fmt.Errorf("resources.%s.limits.%s should not be negative, current value: %s", c.name, name, limit.String())
+			}
+			request, hasRequest := c.resources.Requests[name]
+			if !hasRequest {
+				continue
+			}
+			if request.Sign() < 0 {
+				return fmt.Errorf("resources.%s.requests.%s should not be negative, current value: %s", c.name, name, request.String())
+			}
+			if hasLimit && request.Cmp(limit) > 0 {
+				return fmt.Errorf("resources.%s.requests.%s (%s) should not exceed the limit (%s)", c.name, name, request.String(), limit.String())
+			}
+		}
+	}
+	return nil
+}
+
 func (im *InstanceManager) getExporterSecret(instance *mysqlv1alpha1.Instance) (*corev1.Secret, error) {
 	log := im.Reconciler.Log.WithValues("function", "getExporterSecret", "namespace", instance.Namespace, "instance", instance.Name)
 
@@ -77,7 +175,6 @@ func (im *InstanceManager) getExporterSecret(instance *mysqlv1alpha1.Instance) (
 
 func (im *InstanceManager) createExporterSecret(instance *mysqlv1alpha1.Instance) (ctrl.Result, error) {
 	log := im.Reconciler.Log.WithValues("function", "createExporterSecret", "namespace", instance.Namespace, "instance", instance.Name)
-	password := uuid.New()
 	labels := map[string]string{
 		"app": instance.Name,
 	}
@@ -87,11 +184,7 @@ func (im *InstanceManager) createExporterSecret(instance *mysqlv1alpha1.Instance
 			Namespace: instance.Namespace,
 			Labels:    labels,
 		},
-		StringData: map[string]string{
-			".my.cnf":  fmt.Sprintf("[client]\nuser=exporter\npassword=%s\nhost=localhost\n", password),
-			"username": "exporter",
-			"password": fmt.Sprintf("%s", password),
-		},
+		StringData: exporterSecretData(uuid.New().String()),
 	}
 	if err := controllerutil.SetControllerReference(instance, secret, im.Reconciler.Scheme); err != nil {
 		return ctrl.Result{}, err
@@ -176,6 +269,7 @@ func (im *InstanceManager) createStatefulSet(instance *mysqlv1alpha1.Instance, s
 	log := im.Reconciler.Log.WithValues("function", "createStatefulSet", "namespace", instance.Namespace, "instance", instance.Name)
 
 	sts := im.Properties.NewStatefulSetForInstance(instance, store, location)
+	im.mountFilesystemStores(instance, sts)
 
 	if err := controllerutil.SetControllerReference(instance, sts, im.Reconciler.Scheme); err != nil {
 		return ctrl.Result{}, err
@@ -193,6 +287,7 @@ func (im *InstanceManager) createStatefulSet(instance *mysqlv1alpha1.Instance, s
 		return im.setInstanceCondition(instance, condition)
 	}
 	log.Info("Statefulset creation succeeded", "statefulset", sts.Name)
+	syncVersion(instance, sts)
 	instance.Status.StatefulSet = corev1.ObjectReference{
 		Kind:            sts.Kind,
 		Namespace:       sts.Namespace,
@@ -211,17 +306,164 @@ func (im *InstanceManager) createStatefulSet(instance *mysqlv1alpha1.Instance, s
 	return im.setInstanceCondition(instance, condition)
 }
 
+// updateStatefulSet compares the statefulset with the one expected for the
+// instance and updates the containers that have drifted. The statefulset
+// controller then rolls the pods one at a time.
+func (im *InstanceManager) updateStatefulSet(instance *mysqlv1alpha1.Instance, sts *appsv1.StatefulSet, store *mysqlv1alpha1.Store, location string) (bool, ctrl.Result, error) {
+	log := im.Reconciler.Log.WithValues("function", "updateStatefulSet", "namespace", instance.Namespace, "instance", instance.Name)
+
+	desired := im.Properties.NewStatefulSetForInstance(instance, store, location)
+	im.mountFilesystemStores(instance, desired)
+	if version := im.upgradeVersion(instance); version != "" {
+		setStatefulSetVersion(desired, version)
+	}
+	changes := []string{}
+	changes = append(changes, syncContainers(sts.Spec.Template.Spec.InitContainers, desired.Spec.Template.Spec.InitContainers)...)
+	changes = append(changes, syncContainers(sts.Spec.Template.Spec.Containers, desired.Spec.Template.Spec.Containers)...)
+	if sts.Spec.Replicas == nil || *sts.Spec.Replicas != *desired.Spec.Replicas {
+		sts.Spec.Replicas = desired.Spec.Replicas
+		changes = append(changes, "replicas")
+	}
+	if volumes := syncVolumes(sts.Spec.Template.Spec.Volumes, desired.Spec.Template.Spec.Volumes); len(volumes) != len(sts.Spec.Template.Spec.Volumes) {
+		sts.Spec.Template.Spec.Volumes = volumes
+		changes = append(changes, "volumes")
+	}
+	if sts.Spec.UpdateStrategy.Type != desired.Spec.UpdateStrategy.Type {
+		sts.Spec.UpdateStrategy = desired.Spec.UpdateStrategy
+		changes = append(changes, "updateStrategy")
+	}
+	if len(changes) == 0 {
+		return false, ctrl.Result{}, nil
+	}
+
+	log.Info("Update StatefulSet", "statefulset", sts.Name, "changes", strings.Join(changes, ", "))
+	if err := im.Reconciler.Client.Update(im.Context, sts); err != nil {
+		log.Error(err, "Statefulset update failed", "statefulset", sts.Name)
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceStatefulSetFailed,
+			Message:            fmt.Sprintf("Statefulset update failed: %v", err),
+		}
+		result, err := im.setInstanceCondition(instance, condition)
+		return true, result, err
+	}
+	log.Info("Statefulset update succeeded", "statefulset", sts.Name)
+	instance.Status.StatefulSet.ResourceVersion = sts.ResourceVersion
+	condition := metav1.Condition{
+		Type:               "available",
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             mysqlv1alpha1.InstanceStatefulSetUpdated,
+		Message:            fmt.Sprintf("StatefulSet updated, rolling out %s", strings.Join(changes, ", ")),
+	}
+	result, err := im.setInstanceCondition(instance, condition)
+	return true, result, err
+}
+
+// syncContainers copies the image, command, args, env, probes and resources from the desired
+// containers to the current ones and reports what has changed
+func syncContainers(current, desired []corev1.Container) []string {
+	changes := []string{}
+	for _, d := range desired {
+		for i := range current {
+			c := &current[i]
+			if c.Name != d.Name {
+				continue
+			}
+			if c.Image != d.Image {
+				c.Image = d.Image
+				changes = append(changes, fmt.Sprintf("%s/image", c.Name))
+			}
+			if !equality.Semantic.DeepEqual(c.Command, d.Command) {
+				c.Command = d.Command
+				changes = append(changes, fmt.Sprintf("%s/command", c.Name))
+			}
+			if !equality.Semantic.DeepEqual(c.Args, d.Args) {
+				c.Args = d.Args
+				changes = append(changes, fmt.Sprintf("%s/args", c.Name))
+			}
+			if !equality.Semantic.DeepEqual(c.Env, d.Env) {
+				c.Env = d.Env
+				changes = append(changes, fmt.Sprintf("%s/env", c.Name))
+			}
+			if !equality.Semantic.DeepEqual(c.LivenessProbe, d.LivenessProbe) ||
+				!equality.Semantic.DeepEqual(c.ReadinessProbe, d.ReadinessProbe) {
+				c.LivenessProbe = d.LivenessProbe
+				c.ReadinessProbe = d.ReadinessProbe
+				changes = append(changes, fmt.Sprintf("%s/probes", c.Name))
+			}
+			if !equality.Semantic.DeepEqual(c.Resources, defaultResources(d.Resources)) {
+				c.Resources = d.Resources
+				changes = append(changes, fmt.Sprintf("%s/resources", c.Name))
+			}
+			if !equality.Semantic.DeepEqual(c.VolumeMounts, d.VolumeMounts) {
+				c.VolumeMounts = d.VolumeMounts
+				changes = append(changes, fmt.Sprintf("%s/volumeMounts", c.Name))
+			}
+		}
+	}
+	return changes
+}
+
+// defaultResources sets the requests that are missing to the limits, like
+// the API server does for the containers, so that the resources of a
+// container can be compared with the desired ones
+func defaultResources(resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	defaulted := *resources.DeepCopy()
+	for name, limit := range resources.Limits {
+		if _, ok := defaulted.Requests[name]; ok {
+			continue
+		}
+		if defaulted.Requests == nil {
+			defaulted.Requests = corev1.ResourceList{}
+		}
+		defaulted.Requests[name] = limit.DeepCopy()
+	}
+	return defaulted
+}
+
+// syncVolumes adds the desired volumes that are missing. Volumes are
+// compared by name only because the API server sets their defaults
+func syncVolumes(current, desired []corev1.Volume) []corev1.Volume {
+	volumes := append([]corev1.Volume{}, current...)
+	for _, d := range desired {
+		found := false
+		for _, c := range current {
+			if c.Name == d.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			volumes = append(volumes, d)
+		}
+	}
+	return volumes
+}
+
 // NewStatefulSetForInstance returns a MySQL StatefulSet with the instance name/namespace
 func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha1.Instance, store *mysqlv1alpha1.Store, location string) *appsv1.StatefulSet {
 	labels := map[string]string{
 		"app": instance.Name,
 	}
-	diskSize := resource.NewQuantity(500*1024*1024, resource.BinarySI)
-	restoreDiskSize := resource.NewQuantity(500*1024*1024, resource.BinarySI)
-	var replicas int32 = 1
+	diskSize := resource.NewQuantity(defaultVolumeSize, resource.BinarySI)
+	if instance.Spec.Storage.Data.Size != nil {
+		diskSize = instance.Spec.Storage.Data.Size
+	}
+	restoreDiskSize := resource.NewQuantity(defaultVolumeSize, resource.BinarySI)
+	if instance.Spec.Storage.Init.Size != nil {
+		restoreDiskSize = instance.Spec.Storage.Init.Size
+	}
+	replicas := instanceReplicas(instance)
 	initContainers := []corev1.Container{}
+	args := []string{
+		"--gtid-mode=ON",
+		"--enforce-gtid-consistency=ON",
+	}
 	if store != nil {
-		if store.Spec.Envs != nil {
+		if store.Spec.Envs != nil || store.Spec.Backend == mysqlv1alpha1.BackendFilesystem {
 			env := store.Spec.Envs
 			env = append(env, corev1.EnvVar{
 				Name:  "AGT_BUCKET",
@@ -240,17 +482,52 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 				Name:  "AGT_WORKDIR",
 				Value: "/docker-entrypoint-initdb.d",
 			})
+			env = append(env, restoreBinlogEnvs(instance)...)
+			env = append(env, s3EnvVars(store)...)
+			mounts := []corev1.VolumeMount{
+				{
+					Name:      instance.Name + "-init",
+					MountPath: "/docker-entrypoint-initdb.d",
+				},
+			}
+			if store.Spec.Encryption != nil {
+				env = append(env, corev1.EnvVar{
+					Name:  "AGT_KEYS_DIR",
+					Value: encryptionKeysDir,
+				})
+				if store.Spec.Encryption.AllowUnencrypted {
+					env = append(env, corev1.EnvVar{
+						Name:  "AGT_ALLOW_UNENCRYPTED",
+						Value: "true",
+					})
+				}
+				mounts = append(mounts, corev1.VolumeMount{
+					Name:      instance.Name + "-keys",
+					MountPath: encryptionKeysDir,
+					ReadOnly:  true,
+				})
+			}
+			// a physical backup is restored in the data directory, the
+			// entrypoint does not run the init scripts and the server
+			// creates the agent account when it starts
+			if restored := instance.Status.RestoredBackup; restored != nil && isPhysical(restored.Method) {
+				env = append(env, corev1.EnvVar{
+					Name:  "AGT_METHOD",
+					Value: restored.Method,
+				})
+				mounts = append(mounts, corev1.VolumeMount{
+					Name:      instance.Name + "-data",
+					MountPath: "/var/lib/mysql",
+				})
+				args = append(args, "--init-file="+agentInitScript)
+			}
 			initContainers = []corev1.Container{
 				{
-					Name:  "restore",
-					Image: "quay.io/blaqkube/mysql-agent:" + s.AgentVersion,
-					Env:   env,
-					VolumeMounts: []corev1.VolumeMount{
-						{
-							Name:      instance.Name + "-init",
-							MountPath: "/docker-entrypoint-initdb.d",
-						},
-					},
+					Name:         "restore",
+					Image:        "quay.io/blaqkube/mysql-agent:" + s.AgentVersion,
+					Env:          env,
+					Resources:    instance.Spec.Resources.Agent,
+					VolumeMounts: mounts,
 					Command: []string{
 						"./mysql-agent",
 						"init",
@@ -272,6 +549,9 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 				MatchLabels: labels,
 			},
 			ServiceName: instance.Name,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
@@ -279,12 +559,21 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
-							Name:  "mysql",
-							Image: "mysql:" + s.MySQLVersion,
+							Name:      "mysql",
+							Image:     "mysql:" + s.mysqlVersion(instance),
+							Resources: instance.Spec.Resources.MySQL,
+							Args:      args,
 							Env: []corev1.EnvVar{
 								{
-									Name:  "MYSQL_ALLOW_EMPTY_PASSWORD",
-									Value: "1",
+									Name: "MYSQL_ROOT_PASSWORD",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{
+												Name: instance.Name + "-root",
+											},
+											Key: "password",
+										},
+									},
 								},
 							},
 							Ports: []corev1.ContainerPort{
@@ -302,16 +591,20 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 								InitialDelaySeconds: int32(30),
 								TimeoutSeconds:      int32(5),
 								PeriodSeconds:       int32(10),
+								SuccessThreshold:    int32(1),
+								FailureThreshold:    int32(3),
 							},
 							ReadinessProbe: &corev1.Probe{
 								Handler: corev1.Handler{
 									Exec: &corev1.ExecAction{
-										Command: []string{"mysql", "-h", "127.0.0.1", "-e", "SELECT 1"},
+										Command: []string{"mysql", "--defaults-extra-file=" + rootConfigPath + "/.my.cnf", "-h", "127.0.0.1", "-e", "SELECT 1"},
 									},
 								},
 								InitialDelaySeconds: int32(30),
 								TimeoutSeconds:      int32(5),
 								PeriodSeconds:       int32(10),
+								SuccessThreshold:    int32(1),
+								FailureThreshold:    int32(3),
 							},
 							VolumeMounts: []corev1.VolumeMount{
 								{
@@ -322,26 +615,60 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 									Name:      instance.Name + "-init",
 									MountPath: "/docker-entrypoint-initdb.d",
 								},
+								{
+									Name:      instance.Name + "-config",
+									MountPath: configMountPath,
+									SubPath:   configFile,
+								},
+								{
+									Name:      instance.Name + "-root",
+									MountPath: rootConfigPath,
+									ReadOnly:  true,
+								},
+								{
+									Name:      instance.Name + "-agent",
+									MountPath: agentInitScript,
+									SubPath:   agentInitFile,
+									ReadOnly:  true,
+								},
 							},
 						},
 						{
-							Name:  "agent",
-							Image: "quay.io/blaqkube/mysql-agent:" + s.AgentVersion,
+							Name:      "agent",
+							Image:     "quay.io/blaqkube/mysql-agent:" + s.AgentVersion,
+							Resources: instance.Spec.Resources.Agent,
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      instance.Name + "-data",
 									MountPath: "/var/lib/mysql",
 								},
+								{
+									Name:      instance.Name + "-agent",
+									MountPath: agentConfigPath,
+									ReadOnly:  true,
+								},
+								{
+									Name:      instance.Name + "-root",
+									MountPath: rootConfigPath,
+									ReadOnly:  true,
+								},
 							},
 							Command: []string{
 								"./mysql-agent",
 								"serve",
 							},
+							Args: []string{
+								"--config=" + agentConfigPath + "/" + agentConfigFile,
+							},
 							Env: []corev1.EnvVar{
 								{
 									Name:  "AGT_WORKDIR",
 									Value: "/docker-entrypoint-initdb.d",
 								},
+								{
+									Name:  "AGT_ROOT_PASSWORD_FILE",
+									Value: rootConfigPath + "/password",
+								},
 								{
 									Name: "AGT_EXPORTER_USERNAME",
 									ValueFrom: &corev1.EnvVarSource{
@@ -367,23 +694,55 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 							},
 						},
 						{
-							Name:  "exporter",
-							Image: "prom/mysqld-exporter:v0.12.1",
+							Name:      "exporter",
+							Image:     "prom/mysqld-exporter:v0.12.1",
+							Resources: instance.Spec.Resources.Exporter,
+							Command: []string{
+								"/bin/sh",
+								"-c",
+								fmt.Sprintf("cp %s/.my.cnf %s/.my.cnf && exec /bin/mysqld_exporter --config.my-cnf=%s/.my.cnf", exporterConfigPath, exporterRunPath, exporterRunPath),
+							},
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "prom-mysql",
 									ContainerPort: 9104,
 								},
 							},
+							LivenessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"cmp", "-s", exporterConfigPath + "/.my.cnf", exporterRunPath + "/.my.cnf"},
+									},
+								},
+								InitialDelaySeconds: int32(10),
+								TimeoutSeconds:      int32(5),
+								PeriodSeconds:       int32(30),
+								SuccessThreshold:    int32(1),
+								FailureThreshold:    int32(1),
+							},
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      instance.Name + "-exporter",
-									MountPath: "/home",
+									MountPath: exporterConfigPath,
+								},
+								{
+									Name:      instance.Name + "-exporter-run",
+									MountPath: exporterRunPath,
 								},
 							},
 						},
 					},
 					Volumes: []corev1.Volume{
+						{
+							Name: instance.Name + "-config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: instance.Name + "-config",
+									},
+								},
+							},
+						},
 						{
 							Name: instance.Name + "-exporter",
 							VolumeSource: corev1.VolumeSource{
@@ -398,6 +757,48 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 								},
 							},
 						},
+						{
+							Name: instance.Name + "-exporter-run",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+						{
+							Name: instance.Name + "-root",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: instance.Name + "-root",
+									Items: []corev1.KeyToPath{
+										{
+											Key:  ".my.cnf",
+											Path: ".my.cnf",
+										},
+										{
+											Key:  "password",
+											Path: "password",
+										},
+									},
+								},
+							},
+						},
+						{
+							Name: instance.Name + "-agent",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: instance.Name + "-agent",
+									Items: []corev1.KeyToPath{
+										{
+											Key:  agentConfigFile,
+											Path: agentConfigFile,
+										},
+										{
+											Key:  agentInitFile,
+											Path: agentInitFile,
+										},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -410,6 +811,7 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 						AccessModes: []corev1.PersistentVolumeAccessMode{
 							corev1.ReadWriteOnce,
 						},
+						StorageClassName: instance.Spec.Storage.StorageClassName,
 						Resources: corev1.ResourceRequirements{
 							Requests: corev1.ResourceList{
 								corev1.ResourceStorage: *diskSize,
@@ -425,6 +827,7 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 						AccessModes: []corev1.PersistentVolumeAccessMode{
 							corev1.ReadWriteOnce,
 						},
+						StorageClassName: instance.Spec.Storage.StorageClassName,
 						Resources: corev1.ResourceRequirements{
 							Requests: corev1.ResourceList{
 								corev1.ResourceStorage: *restoreDiskSize,
@@ -437,6 +840,17 @@ func (s *StatefulSetProperties) NewStatefulSetForInstance(instance *mysqlv1alpha
 	}
 	if store != nil {
 		sts.Spec.Template.Spec.InitContainers = initContainers
+		if len(initContainers) > 0 && store.Spec.Encryption != nil {
+			sts.Spec.Template.Spec.Volumes = append(sts.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name: instance.Name + "-keys",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: store.Spec.Encryption.SecretName,
+					},
+				},
+			})
+		}
+		mountFilesystemStore(sts, store)
 	}
 	if instance.Spec.Database != "" {
 		sts.Spec.Template.Spec.Containers[0].Env = append(
@@ -52,8 +52,11 @@ func (b *BackupJob) Run() {
 			Namespace: instance.Namespace,
 		},
 		Spec: mysqlv1alpha1.BackupSpec{
-			Store:    instance.Spec.BackupSchedule.Store,
-			Instance: instance.Name,
+			Store:       instance.Spec.BackupSchedule.Store,
+			Instance:    instance.Name,
+			Compression: instance.Spec.BackupSchedule.Compression,
+			Method:      instance.Spec.BackupSchedule.Method,
+			Dump:        instance.Spec.BackupSchedule.Dump,
 		},
 	}
 	if err := controllerutil.SetControllerReference(&instance, backup, b.Scheme); err != nil {
@@ -142,12 +145,13 @@ func (b *UnMaintenanceJob) Run() {
 		b.Log.Info(fmt.Sprintf("job for %s/%s failed. Could not access instance...", b.Instance.Namespace, b.Instance.Name))
 		return
 	}
-	if (instance.Status.MaintenanceMode == false) || (instance.Status.MaintenanceMode == true && instance.Status.Schedules.MaintenanceEndTime.Time.After(time.Now())) {
+	if (instance.Status.MaintenanceMode == false) || (instance.Status.MaintenanceMode == true && instance.Status.Schedules.MaintenanceEndTime != nil && instance.Status.Schedules.MaintenanceEndTime.Time.After(time.Now())) {
 		b.Log.Info(fmt.Sprintf("job for %s/%s race condition with maintenance, should be have been rescheduled", b.Instance.Namespace, b.Instance.Name))
 		return
 	}
 	if instance.Status.MaintenanceMode == true {
-		instance.Status.MaintenanceMode = false
+		// A running restore keeps the instance in maintenance until it ends
+		instance.Status.MaintenanceMode = instance.Status.Restore != ""
 		instance.Status.Schedules.MaintenanceEndTime = nil
 		b.Crontab.unSchedule(&instance, MaintenanceUnscheduling)
 	}
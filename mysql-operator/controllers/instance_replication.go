@@ -0,0 +1,383 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	replicationUsername     = "replication"
+	replicationPollInterval = 30 * time.Second
+	mysqlPort               = 3306
+)
+
+// ReplicationConnector is an interface to the replication API of the agents
+// and is used to perform tests. The address is the IP of the agent pod
+type ReplicationConnector interface {
+	GetReplication(ctx context.Context, address string) (*agent.Replication, error)
+	CreateReplicationUser(ctx context.Context, address string, user agent.User) error
+	CreateReplication(ctx context.Context, address string, request agent.ReplicationRequest) (*agent.Replication, error)
+	PromoteReplica(ctx context.Context, address string, request agent.PromoteRequest) (*agent.Replication, error)
+	DemotePrimary(ctx context.Context, address string) (*agent.Replication, error)
+}
+
+// DefaultReplicationConnector an implementation of the ReplicationConnector
+type DefaultReplicationConnector struct {
+}
+
+// NewDefaultReplicationConnector generates a replication connector based on the agent client
+func NewDefaultReplicationConnector() ReplicationConnector {
+	return &DefaultReplicationConnector{}
+}
+
+// GetReplication returns the replication status of an agent
+func (rc *DefaultReplicationConnector) GetReplication(ctx context.Context, address string) (*agent.Replication, error) {
+	replication, response, err := newAgentClient(address).MysqlApi.GetReplication(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil || response.StatusCode != http.StatusOK {
+		return nil, ErrAgentRequestFailed
+	}
+	return &replication, nil
+}
+
+// CreateReplicationUser creates the user replicas connect with
+func (rc *DefaultReplicationConnector) CreateReplicationUser(ctx context.Context, address string, user agent.User) error {
+	_, response, err := newAgentClient(address).MysqlApi.CreateReplicationUser(ctx, user, nil)
+	if err != nil {
+		return err
+	}
+	if response == nil || response.StatusCode != http.StatusCreated {
+		return ErrAgentRequestFailed
+	}
+	return nil
+}
+
+// CreateReplication points an agent to a source
+func (rc *DefaultReplicationConnector) CreateReplication(ctx context.Context, address string, request agent.ReplicationRequest) (*agent.Replication, error) {
+	replication, response, err := newAgentClient(address).MysqlApi.CreateReplication(ctx, request, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil || response.StatusCode != http.StatusCreated {
+		return nil, ErrAgentRequestFailed
+	}
+	return &replication, nil
+}
+
+// PromoteReplica stops the replication of an agent and makes it writable
+func (rc *DefaultReplicationConnector) PromoteReplica(ctx context.Context, address string, request agent.PromoteRequest) (*agent.Replication, error) {
+	replication, response, err := newAgentClient(address).MysqlApi.PromoteReplica(ctx, request, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil || response.StatusCode != http.StatusCreated {
+		return nil, ErrAgentRequestFailed
+	}
+	return &replication, nil
+}
+
+// DemotePrimary makes the primary of an agent read-only
+func (rc *DefaultReplicationConnector) DemotePrimary(ctx context.Context, address string) (*agent.Replication, error) {
+	replication, response, err := newAgentClient(address).MysqlApi.DemotePrimary(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil || response.StatusCode != http.StatusCreated {
+		return nil, ErrAgentRequestFailed
+	}
+	return &replication, nil
+}
+
+// instanceReplicas returns the number of pods requested for the instance
+func instanceReplicas(instance *mysqlv1alpha1.Instance) int32 {
+	if instance.Spec.Replicas == nil {
+		return 1
+	}
+	return *instance.Spec.Replicas
+}
+
+// instancePrimary returns the pod that accepts writes, the first pod until a
+// failover or a switchover changes it
+func instancePrimary(instance *mysqlv1alpha1.Instance) string {
+	if instance.Status.Primary == "" {
+		return fmt.Sprintf("%s-%d", instance.Name, 0)
+	}
+	return instance.Status.Primary
+}
+
+func (im *InstanceManager) getReplicationSecret(instance *mysqlv1alpha1.Instance) (*corev1.Secret, error) {
+	log := im.Reconciler.Log.WithValues("function", "getReplicationSecret", "namespace", instance.Namespace, "instance", instance.Name)
+
+	secretName := types.NamespacedName{
+		Name:      instance.Name + "-replication",
+		Namespace: instance.Namespace,
+	}
+	secret := &corev1.Secret{}
+	err := im.Reconciler.Client.Get(im.Context, secretName, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Error getting secret", "secret", secretName.Name)
+	}
+	if err != nil {
+		log.Info("Secret does not exist", "secret", secretName.Name)
+	}
+	return secret, err
+}
+
+func (im *InstanceManager) createReplicationSecret(instance *mysqlv1alpha1.Instance) (ctrl.Result, error) {
+	log := im.Reconciler.Log.WithValues("function", "createReplicationSecret", "namespace", instance.Namespace, "instance", instance.Name)
+	labels := map[string]string{
+		"app": instance.Name,
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name + "-replication",
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		StringData: map[string]string{
+			"username": replicationUsername,
+			"password": uuid.New().String(),
+		},
+	}
+	if err := controllerutil.SetControllerReference(instance, secret, im.Reconciler.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.Info("Create secret", "secret", secret.Name)
+	if err := im.Reconciler.Client.Create(im.Context, secret); err != nil {
+		log.Error(err, "Secret creation failed", "secret", secret.Name)
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.InstanceReplicationSecretFailed,
+			Message:            fmt.Sprintf("Secret replication creation failed: %v", err),
+		}
+		return im.setInstanceCondition(instance, condition)
+	}
+	log.Info("Secret create succeeded", "secret", secret.Name)
+	instance.Status.ReplicationSecret = corev1.ObjectReference{
+		Kind:            secret.Kind,
+		Namespace:       secret.Namespace,
+		Name:            secret.Name,
+		UID:             secret.UID,
+		APIVersion:      secret.APIVersion,
+		ResourceVersion: secret.ResourceVersion,
+	}
+	condition := metav1.Condition{
+		Type:               "available",
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             mysqlv1alpha1.InstanceReplicationSecretCreated,
+		Message:            "Secret replication has been successfully created",
+	}
+	return im.setInstanceCondition(instance, condition)
+}
+
+// reconcileReplication points every replica to the primary, seeding the
+// ones that have never replicated, and refreshes the role and lag of the
+// members in the instance status. A former primary is seeded again as it
+// might contain transactions that have not been replicated.
+func (im *InstanceManager) reconcileReplication(instance *mysqlv1alpha1.Instance, secret *corev1.Secret) {
+	log := im.Reconciler.Log.WithValues("function", "reconcileReplication", "namespace", instance.Namespace, "instance", instance.Name)
+
+	if instance.Status.Primary == "" {
+		instance.Status.Primary = instancePrimary(instance)
+	}
+	replicas := instanceReplicas(instance)
+	members := []mysqlv1alpha1.MemberStatus{}
+	primaryOrdinal := memberOrdinal(instance, instance.Status.Primary)
+	primary, err := im.getMemberPod(instance, primaryOrdinal)
+	if err != nil {
+		for i := int32(0); i < replicas; i++ {
+			members = append(members, mysqlv1alpha1.MemberStatus{
+				Name:    fmt.Sprintf("%s-%d", instance.Name, i),
+				Message: fmt.Sprintf("Primary is not available: %v", err),
+			})
+		}
+		instance.Status.Members = members
+		return
+	}
+
+	user := agent.User{
+		Username: string(secret.Data["username"]),
+		Password: string(secret.Data["password"]),
+	}
+	userCreated := false
+	for i := int32(0); i < replicas; i++ {
+		if i == primaryOrdinal {
+			members = append(members, im.getMemberStatus(primary))
+			continue
+		}
+		pod, err := im.getMemberPod(instance, i)
+		if err != nil {
+			members = append(members, mysqlv1alpha1.MemberStatus{
+				Name:    fmt.Sprintf("%s-%d", instance.Name, i),
+				Role:    mysqlv1alpha1.MemberRoleReplica,
+				Message: err.Error(),
+			})
+			continue
+		}
+		member := im.getMemberStatus(pod)
+		seed := ""
+		switch {
+		case member.Message != "" && member.State == "":
+			members = append(members, member)
+			continue
+		case member.Role == mysqlv1alpha1.MemberRolePrimary,
+			member.State == "Failed" && member.Source == "":
+			seed = instance.Spec.Replication.Seed
+			if seed == "" {
+				seed = mysqlv1alpha1.ReplicationSeedDump
+			}
+		case member.State != "Seeding" && member.Source != memberHost(instance, primary.Name):
+			seed = "none"
+		default:
+			members = append(members, member)
+			continue
+		}
+		if !userCreated {
+			if err := im.Reconciler.Connector.CreateReplicationUser(im.Context, primary.Status.PodIP, user); err != nil {
+				log.Info(fmt.Sprintf("Replication user creation failed, error: %v", err))
+				member.Message = fmt.Sprintf("Replication user creation failed: %v", err)
+				members = append(members, member)
+				continue
+			}
+			userCreated = true
+		}
+		request, err := im.newReplicationRequest(instance, memberHost(instance, primary.Name), user, i, seed)
+		if err != nil {
+			member.Message = fmt.Sprintf("Replication request failed: %v", err)
+			members = append(members, member)
+			continue
+		}
+		log.Info("Start replication", "pod", pod.Name, "source", request.Host, "seed", request.Seed)
+		replication, err := im.Reconciler.Connector.CreateReplication(im.Context, pod.Status.PodIP, *request)
+		if err != nil {
+			log.Info(fmt.Sprintf("Replication start failed, error: %v", err), "pod", pod.Name)
+			member.Message = fmt.Sprintf("Replication start failed: %v", err)
+			members = append(members, member)
+			continue
+		}
+		members = append(members, newMemberStatus(pod.Name, *replication))
+	}
+	instance.Status.Members = members
+}
+
+// newReplicationRequest creates the agent request to replicate from the
+// primary. A seed from a store embeds the backup location and the store
+// environment variables.
+func (im *InstanceManager) newReplicationRequest(instance *mysqlv1alpha1.Instance, source string, user agent.User, ordinal int32, seed string) (*agent.ReplicationRequest, error) {
+	request := &agent.ReplicationRequest{
+		Host:     source,
+		Port:     mysqlPort,
+		Username: user.Username,
+		Password: user.Password,
+		// server_id must be unique and MySQL defaults it to 1
+		ServerId: ordinal + 1,
+		Seed:     seed,
+	}
+	if seed != mysqlv1alpha1.ReplicationSeedStore {
+		return request, nil
+	}
+	request.Seed = "backup"
+	store := &mysqlv1alpha1.Store{}
+	storeName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.Replication.Store}
+	if err := im.Reconciler.Client.Get(im.Context, storeName, store); err != nil {
+		return nil, ErrStoreNotFound
+	}
+	em := &EnvManager{
+		Client: im.Reconciler.Client,
+		Log:    im.Reconciler.Log,
+	}
+	envs, err := em.GetEnvVars(im.Context, *store)
+	if err != nil {
+		return nil, err
+	}
+	agentEnvs := []agent.EnvVar{}
+	for k, v := range envs {
+		agentEnvs = append(agentEnvs, agent.EnvVar{Name: k, Value: v})
+	}
+	encryption, err := em.GetEncryption(im.Context, *store)
+	if err != nil {
+		return nil, err
+	}
+	request.Backup = &agent.BackupRequest{
+		Backend:    string(store.Spec.Backend),
+		Bucket:     store.Spec.Bucket,
+		Location:   instance.Spec.Replication.Location,
+		Method:     mysqlv1alpha1.BackupMethodMysqldump,
+		Envs:       agentEnvs,
+		Encryption: encryption,
+		S3:         s3Options(store),
+	}
+	return request, nil
+}
+
+func (im *InstanceManager) getMemberPod(instance *mysqlv1alpha1.Instance, ordinal int32) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	podName := types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%d", instance.Name, ordinal),
+		Namespace: instance.Namespace,
+	}
+	if err := im.Reconciler.Client.Get(im.Context, podName, pod); err != nil {
+		return nil, ErrPodNotFound
+	}
+	if pod.Status.PodIP == "" {
+		return nil, ErrPodNotFound
+	}
+	return pod, nil
+}
+
+// getMemberStatus reads the replication status from the agent of a pod
+func (im *InstanceManager) getMemberStatus(pod *corev1.Pod) mysqlv1alpha1.MemberStatus {
+	replication, err := im.Reconciler.Connector.GetReplication(im.Context, pod.Status.PodIP)
+	if err != nil {
+		return mysqlv1alpha1.MemberStatus{
+			Name:    pod.Name,
+			Message: fmt.Sprintf("Could not access agent, error: %v", err),
+		}
+	}
+	return newMemberStatus(pod.Name, *replication)
+}
+
+// memberOrdinal returns the ordinal of an instance pod from its name
+func memberOrdinal(instance *mysqlv1alpha1.Instance, name string) int32 {
+	var ordinal int32
+	if _, err := fmt.Sscanf(strings.TrimPrefix(name, instance.Name+"-"), "%d", &ordinal); err != nil {
+		return 0
+	}
+	return ordinal
+}
+
+// memberHost returns the name of an instance pod in the headless service.
+// Unlike the pod IP, it does not change when the pod is recreated
+func memberHost(instance *mysqlv1alpha1.Instance, name string) string {
+	return fmt.Sprintf("%s.%s", name, instance.Name)
+}
+
+func newMemberStatus(name string, replication agent.Replication) mysqlv1alpha1.MemberStatus {
+	return mysqlv1alpha1.MemberStatus{
+		Name:    name,
+		Role:    replication.Role,
+		State:   replication.State,
+		Source:  replication.Source,
+		Lag:     replication.SecondsBehindSource,
+		Message: replication.LastError,
+	}
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+// restoreBackup returns the backup referenced by restore.backupRef. It is
+// resolved once and kept in the status so that the instance does not depend
+// on the backup resource once it has been created
+func (im *InstanceManager) restoreBackup(instance *mysqlv1alpha1.Instance) (*mysqlv1alpha1.RestoredBackupStatus, error) {
+	ref := instance.Spec.Restore.BackupRef
+	if instance.Status.RestoredBackup != nil && instance.Status.RestoredBackup.Backup == ref {
+		return instance.Status.RestoredBackup, nil
+	}
+	a := &APIReconciler{
+		Client: im.Reconciler.Client,
+		Log:    im.Reconciler.Log,
+	}
+	backup, err := a.GetBackup(im.Context, types.NamespacedName{Namespace: instance.Namespace, Name: ref})
+	if err != nil {
+		return nil, err
+	}
+	return &mysqlv1alpha1.RestoredBackupStatus{
+		Backup:   backup.Name,
+		Store:    backup.Spec.Store,
+		Bucket:   backup.Status.Details.Bucket,
+		Location: backup.Status.Details.Location,
+		Method:   backup.Status.Details.Method,
+	}, nil
+}
+
+// restoreStore returns the store with the bucket of the restored backup, it
+// may have changed since the backup has been taken
+func restoreStore(store *mysqlv1alpha1.Store, restored *mysqlv1alpha1.RestoredBackupStatus) *mysqlv1alpha1.Store {
+	if restored == nil || restored.Bucket == "" || restored.Bucket == store.Spec.Bucket {
+		return store
+	}
+	s := store.DeepCopy()
+	s.Spec.Bucket = restored.Bucket
+	return s
+}
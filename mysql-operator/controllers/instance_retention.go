@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+// backupTime is the time used to sort backups and to find the day, the week
+// and the month they belong to
+func backupTime(backup *mysqlv1alpha1.Backup) time.Time {
+	if backup.Status.Details != nil && backup.Status.Details.StartTime != nil {
+		return backup.Status.Details.StartTime.UTC()
+	}
+	return backup.CreationTimestamp.UTC()
+}
+
+// keepPeriods keeps the most recent backup of the count most recent periods.
+// The backups must be sorted from the most recent to the oldest
+func keepPeriods(kept map[string]bool, backups []mysqlv1alpha1.Backup, count int32, period func(time.Time) string) {
+	last := ""
+	for i := range backups {
+		if count <= 0 {
+			return
+		}
+		p := period(backupTime(&backups[i]))
+		if p == last {
+			continue
+		}
+		last = p
+		kept[backups[i].Name] = true
+		count--
+	}
+}
+
+// expiredBackups returns the backups that are not kept by the retention. The
+// backups must be sorted from the most recent to the oldest. A retention
+// without any rule keeps all the backups
+func expiredBackups(retention mysqlv1alpha1.RetentionSpec, backups []mysqlv1alpha1.Backup) []mysqlv1alpha1.Backup {
+	if retention.KeepLast <= 0 && retention.KeepDaily <= 0 &&
+		retention.KeepWeekly <= 0 && retention.KeepMonthly <= 0 {
+		return nil
+	}
+	kept := map[string]bool{}
+	for i := 0; i < len(backups) && i < int(retention.KeepLast); i++ {
+		kept[backups[i].Name] = true
+	}
+	keepPeriods(kept, backups, retention.KeepDaily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepPeriods(kept, backups, retention.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%02d", year, week)
+	})
+	keepPeriods(kept, backups, retention.KeepMonthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+	expired := []mysqlv1alpha1.Backup{}
+	for _, backup := range backups {
+		if !kept[backup.Name] {
+			expired = append(expired, backup)
+		}
+	}
+	return expired
+}
+
+// expiredFailures returns the failed backups that are older than the most
+// recent backup that has succeeded; the retention always keeps that one so a
+// newer backup replaces them. A retention without any rule keeps them all
+func expiredFailures(retention mysqlv1alpha1.RetentionSpec, backups, failed []mysqlv1alpha1.Backup) []mysqlv1alpha1.Backup {
+	if retention.KeepLast <= 0 && retention.KeepDaily <= 0 &&
+		retention.KeepWeekly <= 0 && retention.KeepMonthly <= 0 {
+		return nil
+	}
+	if len(backups) == 0 {
+		return nil
+	}
+	newest := backupTime(&backups[0])
+	expired := []mysqlv1alpha1.Backup{}
+	for i := range failed {
+		if backupTime(&failed[i]).Before(newest) {
+			expired = append(expired, failed[i])
+		}
+	}
+	return expired
+}
+
+// scheduledBackups returns the backups of the instance backup schedule with
+// the reason, from the most recent to the oldest
+func (im *InstanceManager) scheduledBackups(instance *mysqlv1alpha1.Instance, reason string) ([]mysqlv1alpha1.Backup, error) {
+	list := &mysqlv1alpha1.BackupList{}
+	if err := im.Reconciler.List(im.Context, list, client.InNamespace(instance.Namespace)); err != nil {
+		return nil, err
+	}
+	backups := []mysqlv1alpha1.Backup{}
+	for _, backup := range list.Items {
+		if backup.Spec.Instance != instance.Name ||
+			!metav1.IsControlledBy(&backup, instance) ||
+			!strings.HasPrefix(backup.Name, fmt.Sprintf("%s-backup-", instance.Name)) ||
+			backup.Status.Reason != reason ||
+			!backup.DeletionTimestamp.IsZero() {
+			continue
+		}
+		backups = append(backups, backup)
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backupTime(&backups[i]).After(backupTime(&backups[j]))
+	})
+	return backups, nil
+}
+
+// protectedBackups returns the backups that are referenced by an instance
+// restore or by a restore that has not completed
+func (im *InstanceManager) protectedBackups(namespace string) (map[string]bool, error) {
+	protected := map[string]bool{}
+	instances := &mysqlv1alpha1.InstanceList{}
+	if err := im.Reconciler.List(im.Context, instances, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for _, instance := range instances.Items {
+		if instance.Spec.Restore.BackupRef != "" {
+			protected[instance.Spec.Restore.BackupRef] = true
+		}
+	}
+	restores := &mysqlv1alpha1.RestoreList{}
+	if err := im.Reconciler.List(im.Context, restores, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for _, restore := range restores.Items {
+		if restore.Status.Reason != mysqlv1alpha1.RestoreSucceeded &&
+			restore.Status.Reason != mysqlv1alpha1.RestoreFailed {
+			protected[restore.Spec.Backup] = true
+		}
+	}
+	return protected, nil
+}
+
+// pruneBackup deletes a backup. Its finalizer applies the deletion policy
+// of the backup and of its store to the store object
+func (im *InstanceManager) pruneBackup(backup *mysqlv1alpha1.Backup) error {
+	return client.IgnoreNotFound(im.Reconciler.Delete(im.Context, backup))
+}
+
+// reconcileRetention deletes the scheduled backups that are not kept by the
+// retention, as well as the failed ones that a newer backup replaces, and reports the prune in the instance status
+func (im *InstanceManager) reconcileRetention(instance *mysqlv1alpha1.Instance) {
+	retention := instance.Spec.BackupSchedule.Retention
+	if retention == nil {
+		instance.Status.Retention.Message = ""
+		return
+	}
+	log := im.Reconciler.Log.WithValues("namespace", instance.Namespace, "instance", instance.Name)
+	backups, err := im.scheduledBackups(instance, mysqlv1alpha1.BackupSucceeded)
+	if err != nil {
+		instance.Status.Retention.Message = fmt.Sprintf("Cannot list the backups: %v", err)
+		return
+	}
+	failed, err := im.scheduledBackups(instance, mysqlv1alpha1.BackupFailed)
+	if err != nil {
+		instance.Status.Retention.Message = fmt.Sprintf("Cannot list the backups: %v", err)
+		return
+	}
+	expired := append(expiredBackups(*retention, backups), expiredFailures(*retention, backups, failed)...)
+	if len(expired) == 0 {
+		return
+	}
+	protected, err := im.protectedBackups(instance.Namespace)
+	if err != nil {
+		instance.Status.Retention.Message = fmt.Sprintf("Cannot list the backups in use: %v", err)
+		return
+	}
+	message := ""
+	pruned := []string{}
+	for i := range expired {
+		if protected[expired[i].Name] {
+			continue
+		}
+		if err := im.pruneBackup(&expired[i]); err != nil {
+			log.Info(fmt.Sprintf("Unable to prune backup, error: %v", err), "backup", expired[i].Name)
+			message = fmt.Sprintf("Cannot delete backup %s: %v", expired[i].Name, err)
+			break
+		}
+		log.Info("Backup pruned", "backup", expired[i].Name)
+		pruned = append(pruned, expired[i].Name)
+	}
+	if len(pruned) > 0 {
+		instance.Status.Retention.LastPruneTime = &metav1.Time{Time: time.Now()}
+		instance.Status.Retention.Pruned = pruned
+	}
+	if len(pruned) > 0 || message != "" {
+		instance.Status.Retention.Message = message
+	}
+}
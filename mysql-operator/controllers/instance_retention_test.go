@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+// retentionBackups creates a backup every 12 hours for 90 days, from the most
+// recent to the oldest
+func retentionBackups() []mysqlv1alpha1.Backup {
+	backups := []mysqlv1alpha1.Backup{}
+	start := time.Date(2021, time.March, 31, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 180; i++ {
+		t := start.Add(-time.Duration(i) * 12 * time.Hour)
+		backups = append(backups, mysqlv1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "red-backup-" + t.Format("20060102-150405"),
+			},
+			Status: mysqlv1alpha1.BackupStatus{
+				Details: &mysqlv1alpha1.BackupDetails{
+					StartTime: &metav1.Time{Time: t},
+				},
+			},
+		})
+	}
+	return backups
+}
+
+func keptBackups(retention mysqlv1alpha1.RetentionSpec, backups []mysqlv1alpha1.Backup) []string {
+	expired := map[string]bool{}
+	for _, backup := range expiredBackups(retention, backups) {
+		expired[backup.Name] = true
+	}
+	kept := []string{}
+	for _, backup := range backups {
+		if !expired[backup.Name] {
+			kept = append(kept, backup.Name)
+		}
+	}
+	return kept
+}
+
+var _ = Describe("Instance Retention", func() {
+	It("Keep all the backups without a rule", func() {
+		Expect(expiredBackups(mysqlv1alpha1.RetentionSpec{}, retentionBackups())).To(BeEmpty())
+	})
+
+	It("Keep the last backups", func() {
+		kept := keptBackups(mysqlv1alpha1.RetentionSpec{KeepLast: 3}, retentionBackups())
+		Expect(kept).To(Equal([]string{
+			"red-backup-20210331-120000",
+			"red-backup-20210331-000000",
+			"red-backup-20210330-120000",
+		}))
+	})
+
+	It("Keep the last backup of days, weeks and months", func() {
+		kept := keptBackups(mysqlv1alpha1.RetentionSpec{KeepDaily: 2}, retentionBackups())
+		Expect(kept).To(Equal([]string{
+			"red-backup-20210331-120000",
+			"red-backup-20210330-120000",
+		}))
+		kept = keptBackups(mysqlv1alpha1.RetentionSpec{KeepWeekly: 2}, retentionBackups())
+		Expect(kept).To(Equal([]string{
+			"red-backup-20210331-120000",
+			"red-backup-20210328-120000",
+		}))
+		kept = keptBackups(mysqlv1alpha1.RetentionSpec{KeepMonthly: 3}, retentionBackups())
+		Expect(kept).To(Equal([]string{
+			"red-backup-20210331-120000",
+			"red-backup-20210228-120000",
+			"red-backup-20210131-120000",
+		}))
+	})
+
+	It("Combine the retention rules", func() {
+		kept := keptBackups(mysqlv1alpha1.RetentionSpec{KeepLast: 2, KeepDaily: 3, KeepMonthly: 2}, retentionBackups())
+		Expect(kept).To(Equal([]string{
+			"red-backup-20210331-120000",
+			"red-backup-20210331-000000",
+			"red-backup-20210330-120000",
+			"red-backup-20210329-120000",
+			"red-backup-20210228-120000",
+		}))
+		Expect(expiredBackups(mysqlv1alpha1.RetentionSpec{KeepLast: 200}, retentionBackups())).To(BeEmpty())
+	})
+
+	It("Prune the failed backups older than the most recent success", func() {
+		backups := retentionBackups()[1:3]
+		failed := []mysqlv1alpha1.Backup{}
+		for _, t := range []time.Time{
+			time.Date(2021, time.March, 31, 12, 0, 0, 0, time.UTC),
+			time.Date(2021, time.March, 30, 18, 0, 0, 0, time.UTC),
+			time.Date(2021, time.March, 29, 6, 0, 0, 0, time.UTC),
+		} {
+			failed = append(failed, mysqlv1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "red-backup-" + t.Format("20060102-150405"),
+				},
+				Status: mysqlv1alpha1.BackupStatus{
+					Reason: mysqlv1alpha1.BackupFailed,
+					Details: &mysqlv1alpha1.BackupDetails{
+						StartTime: &metav1.Time{Time: t},
+					},
+				},
+			})
+		}
+		expired := []string{}
+		for _, backup := range expiredFailures(mysqlv1alpha1.RetentionSpec{KeepLast: 1}, backups, failed) {
+			expired = append(expired, backup.Name)
+		}
+		Expect(expired).To(Equal([]string{
+			"red-backup-20210330-180000",
+			"red-backup-20210329-060000",
+		}))
+		Expect(expiredFailures(mysqlv1alpha1.RetentionSpec{}, backups, failed)).To(BeEmpty())
+		Expect(expiredFailures(mysqlv1alpha1.RetentionSpec{KeepLast: 1}, nil, failed)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	// roleLabel is set on the pods of a multi-pod instance so that the
+	// read-only service selects the replicas
+	roleLabel   = "mysql.blaqkube.io/role"
+	rolePrimary = "primary"
+	roleReplica = "replica"
+)
+
+// servicePorts returns the ports of the instance services. The defaults are
+// set so that they can be compared with the ports from the API server
+func servicePorts() []corev1.ServicePort {
+	return []corev1.ServicePort{
+		{
+			Name:       "mysql",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       mysqlPort,
+			TargetPort: intstr.FromInt(mysqlPort),
+		},
+	}
+}
+
+// syncServices creates or updates the services of the instance and records
+// their names in its status:
+// - <instance> is the headless service of the statefulset
+// - <instance>-rw is the client service, it points to the primary
+// - <instance>-ro points to the replicas and exists with more than one pod
+func syncServices(ctx context.Context, c client.Client, scheme *runtime.Scheme, instance *mysqlv1alpha1.Instance) error {
+	headless := corev1.ServiceSpec{
+		ClusterIP:                corev1.ClusterIPNone,
+		PublishNotReadyAddresses: true,
+		Selector: map[string]string{
+			"app": instance.Name,
+		},
+		Ports: servicePorts(),
+	}
+	if err := syncService(ctx, c, scheme, instance, instance.Name, headless); err != nil {
+		return err
+	}
+	instance.Status.Services.Headless = instance.Name
+
+	readWrite := corev1.ServiceSpec{
+		Selector: map[string]string{
+			"app":                                instance.Name,
+			"statefulset.kubernetes.io/pod-name": instancePrimary(instance),
+		},
+		Ports: servicePorts(),
+	}
+	if err := syncService(ctx, c, scheme, instance, instance.Name+"-rw", readWrite); err != nil {
+		return err
+	}
+	instance.Status.Services.Client = instance.Name + "-rw"
+
+	if instanceReplicas(instance) < 2 {
+		instance.Status.Services.ReadOnly = ""
+		return deleteService(ctx, c, instance, instance.Name+"-ro")
+	}
+	if err := syncRoleLabels(ctx, c, instance); err != nil {
+		return err
+	}
+	readOnly := corev1.ServiceSpec{
+		Selector: map[string]string{
+			"app":     instance.Name,
+			roleLabel: roleReplica,
+		},
+		Ports: servicePorts(),
+	}
+	if err := syncService(ctx, c, scheme, instance, instance.Name+"-ro", readOnly); err != nil {
+		return err
+	}
+	instance.Status.Services.ReadOnly = instance.Name + "-ro"
+	return nil
+}
+
+// syncService creates a service or updates its selector and ports
+func syncService(ctx context.Context, c client.Client, scheme *runtime.Scheme, instance *mysqlv1alpha1.Instance, name string, spec corev1.ServiceSpec) error {
+	service := &corev1.Service{}
+	serviceName := types.NamespacedName{Name: name, Namespace: instance.Namespace}
+	err := c.Get(ctx, serviceName, service)
+	if err == nil {
+		if equality.Semantic.DeepEqual(service.Spec.Selector, spec.Selector) &&
+			equality.Semantic.DeepEqual(service.Spec.Ports, spec.Ports) {
+			return nil
+		}
+		service.Spec.Selector = spec.Selector
+		service.Spec.Ports = spec.Ports
+		return c.Update(ctx, service)
+	}
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	service = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName.Name,
+			Namespace: serviceName.Namespace,
+			Labels: map[string]string{
+				"app": instance.Name,
+			},
+		},
+		Spec: spec,
+	}
+	if err := controllerutil.SetControllerReference(instance, service, scheme); err != nil {
+		return err
+	}
+	return c.Create(ctx, service)
+}
+
+// deleteService deletes a service when it exists
+func deleteService(ctx context.Context, c client.Client, instance *mysqlv1alpha1.Instance, name string) error {
+	service := &corev1.Service{}
+	serviceName := types.NamespacedName{Name: name, Namespace: instance.Namespace}
+	if err := c.Get(ctx, serviceName, service); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return client.IgnoreNotFound(c.Delete(ctx, service))
+}
+
+// syncRoleLabels sets the role of every pod. A replica is only labeled once
+// its member status reports it is replicating from the primary, so that the
+// read-only service does not select a pod that is seeding or has failed. A
+// pod that is recreated gets its label back with the next reconciliation
+func syncRoleLabels(ctx context.Context, c client.Client, instance *mysqlv1alpha1.Instance) error {
+	primary := instancePrimary(instance)
+	running := map[string]bool{}
+	for _, member := range instance.Status.Members {
+		if member.Role == roleReplica && member.State == "Running" && member.Source == memberHost(instance, primary) {
+			running[member.Name] = true
+		}
+	}
+	for i := int32(0); i < instanceReplicas(instance); i++ {
+		pod := &corev1.Pod{}
+		podName := types.NamespacedName{
+			Name:      fmt.Sprintf("%s-%d", instance.Name, i),
+			Namespace: instance.Namespace,
+		}
+		if err := c.Get(ctx, podName, pod); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			continue
+		}
+		role := ""
+		switch {
+		case pod.Name == primary:
+			role = rolePrimary
+		case running[pod.Name]:
+			role = roleReplica
+		}
+		if pod.Labels[roleLabel] == role {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[roleLabel] = role
+		if role == "" {
+			delete(pod.Labels, roleLabel)
+		}
+		if err := c.Patch(ctx, pod, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+var versionFormat = regexp.MustCompile(`^[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+
+// mysqlVersion returns the version of the mysql image. An instance without
+// version follows the operator default, otherwise the version in its status
+// is used so that a change is only applied by an upgrade operation
+func (s *StatefulSetProperties) mysqlVersion(instance *mysqlv1alpha1.Instance) string {
+	if instance.Spec.Version == "" {
+		return s.MySQLVersion
+	}
+	if instance.Status.Version != "" {
+		return instance.Status.Version
+	}
+	return instance.Spec.Version
+}
+
+// statefulSetVersion returns the version of the mysql image of a statefulset
+func statefulSetVersion(sts *appsv1.StatefulSet) string {
+	for _, container := range sts.Spec.Template.Spec.Containers {
+		if container.Name == "mysql" {
+			return strings.TrimPrefix(container.Image, "mysql:")
+		}
+	}
+	return ""
+}
+
+// setStatefulSetVersion changes the image of the mysql container of a
+// statefulset
+func setStatefulSetVersion(sts *appsv1.StatefulSet, version string) {
+	for i, container := range sts.Spec.Template.Spec.Containers {
+		if container.Name == "mysql" {
+			sts.Spec.Template.Spec.Containers[i].Image = "mysql:" + version
+		}
+	}
+}
+
+// upgradeVersion returns the version the upgrade operation of the instance
+// is rolling out, if any, so that the statefulset keeps it until every pod
+// runs it and the version of the instance status changes
+func (im *InstanceManager) upgradeVersion(instance *mysqlv1alpha1.Instance) string {
+	if instance.Status.UpgradeOperation == "" {
+		return ""
+	}
+	operation := &mysqlv1alpha1.Operation{}
+	operationName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Status.UpgradeOperation}
+	if err := im.Reconciler.Get(im.Context, operationName, operation); err != nil {
+		return ""
+	}
+	if operation.Status.Reason != mysqlv1alpha1.OperationRunning {
+		return ""
+	}
+	return operation.Status.Version
+}
+
+// syncVersion keeps track of the version the pods run when the instance has
+// a version. An instance that gets a version keeps the one it runs until it
+// is upgraded
+func syncVersion(instance *mysqlv1alpha1.Instance, sts *appsv1.StatefulSet) {
+	if instance.Spec.Version == "" {
+		instance.Status.Version = ""
+		return
+	}
+	if instance.Status.Version == "" {
+		instance.Status.Version = statefulSetVersion(sts)
+	}
+}
+
+// compareVersions compares 2 versions like 8.0.23, a missing patch number
+// is 0. It returns -1, 0 or 1
+func compareVersions(a, b string) int {
+	x := strings.Split(a, ".")
+	y := strings.Split(b, ".")
+	for i := 0; i < 3; i++ {
+		var m, n int
+		if i < len(x) {
+			m, _ = strconv.Atoi(x[i])
+		}
+		if i < len(y) {
+			n, _ = strconv.Atoi(y[i])
+		}
+		if m < n {
+			return -1
+		}
+		if m > n {
+			return 1
+		}
+	}
+	return 0
+}
+
+// matchVersion checks a server version, like 8.0.24-debug, matches the
+// version of an image tag, like 8.0 or 8.0.24
+func matchVersion(server, version string) bool {
+	return server == version ||
+		strings.HasPrefix(server, version+".") ||
+		strings.HasPrefix(server, version+"-")
+}
+
+// reconcileUpgrade creates an upgrade operation for the next maintenance
+// window when the instance version has changed
+func (im *InstanceManager) reconcileUpgrade(instance *mysqlv1alpha1.Instance) {
+	if instance.Spec.Version == "" || instance.Spec.Version == instance.Status.Version {
+		instance.Status.UpgradeOperation = ""
+		return
+	}
+	if instance.Status.UpgradeOperation != "" {
+		return
+	}
+	operation, err := im.createOperation(instance, mysqlv1alpha1.OperationTypeUpgrade)
+	if err != nil {
+		im.Reconciler.Log.Error(err, "Upgrade operation creation failed", "namespace", instance.Namespace, "instance", instance.Name)
+		return
+	}
+	instance.Status.UpgradeOperation = operation.Name
+}
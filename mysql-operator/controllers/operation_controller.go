@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,13 +18,21 @@ import (
 // OperationReconciler reconciles a Operation object
 type OperationReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	Connector ReplicationConnector
 }
 
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=operations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=operations/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=operations/finalizers,verbs=update
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=instances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=instances/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=backups,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -73,7 +83,7 @@ func (r *OperationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			log.Info("Unable to fetch instance from kubernetes", "namespace", i.Namespace, "name", i.Name)
 			return ctrl.Result{Requeue: true, RequeueAfter: d}, nil
 		}
-		if instance.Status.MaintenanceMode == true {
+		if instance.Status.MaintenanceMode == true && instance.Status.Restore == "" {
 			condition := metav1.Condition{
 				Type:               "available",
 				Status:             metav1.ConditionFalse,
@@ -88,10 +98,51 @@ func (r *OperationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	if operation.Status.Reason == mysqlv1alpha1.OperationRequested {
+		now := metav1.Now()
+		operation.Status.StartTime = &now
+		var err error
 		switch operation.Spec.Type {
 		case mysqlv1alpha1.OperationTypeNoop:
-			om.NoOp()
+			err = om.NoOp()
+		case mysqlv1alpha1.OperationTypeRestart:
+			err = om.Restart(&operation)
+		case mysqlv1alpha1.OperationTypeRotate:
+			err = om.Rotate(&operation)
+		case mysqlv1alpha1.OperationTypeSwitchover:
+			err = om.Switchover(&operation)
+		case mysqlv1alpha1.OperationTypeUpgrade:
+			err = om.Upgrade(&operation)
 		}
+		if err != nil {
+			operation.Status.EndTime = &now
+			condition := metav1.Condition{
+				Type:               "available",
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             mysqlv1alpha1.OperationError,
+				Message:            fmt.Sprintf("The operation has failed: %v", err),
+			}
+			return om.setOperationCondition(&operation, condition)
+		}
+		if operation.Spec.Type == mysqlv1alpha1.OperationTypeRestart ||
+			operation.Spec.Type == mysqlv1alpha1.OperationTypeRotate ||
+			operation.Spec.Type == mysqlv1alpha1.OperationTypeUpgrade {
+			condition := metav1.Condition{
+				Type:               "available",
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             mysqlv1alpha1.OperationRunning,
+				Message:            "The pods are being restarted",
+			}
+			switch operation.Spec.Type {
+			case mysqlv1alpha1.OperationTypeRotate:
+				condition.Message = "The exporters are being restarted with the new passwords"
+			case mysqlv1alpha1.OperationTypeUpgrade:
+				condition.Message = fmt.Sprintf("The pre-upgrade backup %s is running", operation.Status.Backup)
+			}
+			return om.setOperationCondition(&operation, condition)
+		}
+		operation.Status.EndTime = &now
 		condition := metav1.Condition{
 			Type:               "available",
 			Status:             metav1.ConditionTrue,
@@ -102,6 +153,46 @@ func (r *OperationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return om.setOperationCondition(&operation, condition)
 	}
 
+	if operation.Status.Reason == mysqlv1alpha1.OperationRunning {
+		var done bool
+		var err error
+		switch operation.Spec.Type {
+		case mysqlv1alpha1.OperationTypeRestart:
+			done, err = om.MonitorRestart(&operation)
+		case mysqlv1alpha1.OperationTypeRotate:
+			done, err = om.MonitorRotate(&operation)
+		case mysqlv1alpha1.OperationTypeUpgrade:
+			done, err = om.MonitorUpgrade(&operation)
+		}
+		if err == nil && !done {
+			return ctrl.Result{RequeueAfter: restartPollInterval}, nil
+		}
+		now := metav1.Now()
+		operation.Status.EndTime = &now
+		duration := time.Duration(0)
+		if operation.Status.StartTime != nil {
+			duration = now.Sub(operation.Status.StartTime.Time).Round(time.Second)
+		}
+		if err != nil {
+			condition := metav1.Condition{
+				Type:               "available",
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             mysqlv1alpha1.OperationExecutedWithFailure,
+				Message:            fmt.Sprintf("The %s has failed after %s: %v", operation.Spec.Type, duration, err),
+			}
+			return om.setOperationCondition(&operation, condition)
+		}
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+			Reason:             mysqlv1alpha1.OperationExecutedWithSuccess,
+			Message:            fmt.Sprintf("The %s has been executed in %s", operation.Spec.Type, duration),
+		}
+		return om.setOperationCondition(&operation, condition)
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -9,11 +9,14 @@ import (
 	"github.com/go-logr/zapr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
@@ -35,9 +38,10 @@ var _ = Describe("Operation Controller", func() {
 
 		zapLog, _ := zap.NewDevelopment()
 		reconcile := &OperationReconciler{
-			Client: k8sClient,
-			Log:    zapr.NewLogger(zapLog),
-			Scheme: scheme.Scheme,
+			Client:    k8sClient,
+			Log:       zapr.NewLogger(zapLog),
+			Scheme:    scheme.Scheme,
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
 		}
 		Expect(k8sClient.Create(ctx, &operation)).To(Succeed())
 
@@ -81,9 +85,10 @@ var _ = Describe("Operation Controller", func() {
 
 		zapLog, _ := zap.NewDevelopment()
 		reconcile := &OperationReconciler{
-			Client: k8sClient,
-			Log:    zapr.NewLogger(zapLog),
-			Scheme: scheme.Scheme,
+			Client:    k8sClient,
+			Log:       zapr.NewLogger(zapLog),
+			Scheme:    scheme.Scheme,
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
 		}
 		Expect(k8sClient.Create(ctx, &operation)).To(Succeed())
 
@@ -111,4 +116,274 @@ var _ = Describe("Operation Controller", func() {
 		Expect(k8sClient.Get(ctx, operationName, &response)).To(Succeed())
 		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.OperationSucceeded), "Expected reconcile to change the status to Succeeded")
 	})
+
+	It("Create a switchover operation on an instance without replica", func() {
+		ctx := context.Background()
+		instance := mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "standalone",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Database: "blue",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		operation := mysqlv1alpha1.Operation{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "switchover-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.OperationSpec{
+				Instance: "standalone",
+				Type:     mysqlv1alpha1.OperationTypeSwitchover,
+				Mode:     mysqlv1alpha1.OperationModeImmediate,
+			},
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &OperationReconciler{
+			Client:    k8sClient,
+			Log:       zapr.NewLogger(zapLog),
+			Scheme:    scheme.Scheme,
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
+		}
+		Expect(k8sClient.Create(ctx, &operation)).To(Succeed())
+
+		operationName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Name}
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{Requeue: false}))
+		response := mysqlv1alpha1.Operation{}
+		Expect(k8sClient.Get(ctx, operationName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.OperationRequested), "Expected reconcile to change the status to Requested")
+
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{Requeue: false}))
+		response = mysqlv1alpha1.Operation{}
+		Expect(k8sClient.Get(ctx, operationName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.OperationError), "Expected reconcile to change the status to OperationError")
+		Expect(response.Status.Message).To(ContainSubstring(ErrSwitchoverNotSupported.Error()))
+	})
+	It("Create a restart operation and roll the statefulset", func() {
+		ctx := context.Background()
+		labels := map[string]string{"app": "restart"}
+		statefulset := appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "restart",
+				Namespace: "default",
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "mysql", Image: "mysql:8.0.24"}},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &statefulset)).To(Succeed())
+
+		operation := mysqlv1alpha1.Operation{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "restart-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.OperationSpec{
+				Instance: "restart",
+				Type:     mysqlv1alpha1.OperationTypeRestart,
+				Mode:     mysqlv1alpha1.OperationModeImmediate,
+			},
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		connector := NewMockReplicationConnector(map[string]*agent.Replication{})
+		reconcile := &OperationReconciler{
+			Client:    k8sClient,
+			Log:       zapr.NewLogger(zapLog),
+			Scheme:    scheme.Scheme,
+			Connector: connector,
+		}
+		Expect(k8sClient.Create(ctx, &operation)).To(Succeed())
+
+		operationName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Name}
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{Requeue: false}))
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{Requeue: false}))
+		response := mysqlv1alpha1.Operation{}
+		Expect(k8sClient.Get(ctx, operationName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.OperationRunning), "Expected reconcile to change the status to Running")
+		Expect(response.Status.StartTime).NotTo(BeNil())
+
+		restarted := appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "restart"}, &restarted)).To(Succeed())
+		Expect(restarted.Spec.Template.Annotations).To(HaveKey(restartedAtAnnotation))
+
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).
+			To(Equal(ctrl.Result{RequeueAfter: restartPollInterval}), "Expected the operation to wait for the rollout")
+
+		restarted.Status = appsv1.StatefulSetStatus{
+			ObservedGeneration: restarted.Generation,
+			Replicas:           1,
+			ReadyReplicas:      1,
+			UpdatedReplicas:    1,
+			CurrentRevision:    "restart-2",
+			UpdateRevision:     "restart-2",
+		}
+		Expect(k8sClient.Status().Update(ctx, &restarted)).To(Succeed())
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "restart-0",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app":                                 "restart",
+					appsv1.ControllerRevisionHashLabelKey: "restart-2",
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "mysql", Image: "mysql:8.0.24"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &pod)).To(Succeed())
+		pod.Status = corev1.PodStatus{
+			PodIP:      "10.0.0.20",
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		}
+		Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).
+			To(Equal(ctrl.Result{RequeueAfter: restartPollInterval}), "Expected the operation to wait for the agent")
+
+		connector.Members["10.0.0.20"] = &agent.Replication{Role: "primary", State: "Running"}
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{}))
+		Expect(k8sClient.Get(ctx, operationName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.OperationExecutedWithSuccess), "Expected reconcile to change the status to ExecutedWithSuccess")
+		Expect(response.Status.EndTime).NotTo(BeNil())
+	})
+	It("Create a rotate operation on an instance that is not ready", func() {
+		ctx := context.Background()
+		instance := mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rotate",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Database: "blue",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		operation := mysqlv1alpha1.Operation{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "rotate-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.OperationSpec{
+				Instance: "rotate",
+				Type:     mysqlv1alpha1.OperationTypeRotate,
+				Mode:     mysqlv1alpha1.OperationModeImmediate,
+			},
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &OperationReconciler{
+			Client:    k8sClient,
+			Log:       zapr.NewLogger(zapLog),
+			Scheme:    scheme.Scheme,
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
+		}
+		Expect(k8sClient.Create(ctx, &operation)).To(Succeed())
+
+		operationName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Name}
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{Requeue: false}))
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{Requeue: false}))
+		response := mysqlv1alpha1.Operation{}
+		Expect(k8sClient.Get(ctx, operationName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.OperationError), "Expected reconcile to change the status to OperationError")
+		Expect(response.Status.Message).To(ContainSubstring(ErrInstanceNotReady.Error()))
+	})
+	It("Create an upgrade operation and take a backup first", func() {
+		ctx := context.Background()
+		instance := mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "upgrade",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.InstanceSpec{
+				Version: "8.0.24",
+				BackupSchedule: mysqlv1alpha1.BackupScheduleSpec{
+					Store: "upgrade-store",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		instance.Status.Version = "8.0.23"
+		instance.Status.Reason = mysqlv1alpha1.InstanceStatefulSetReady
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+
+		labels := map[string]string{"app": "upgrade"}
+		statefulset := appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "upgrade",
+				Namespace: "default",
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "mysql", Image: "mysql:8.0.23"}},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &statefulset)).To(Succeed())
+
+		operation := mysqlv1alpha1.Operation{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "upgrade-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.OperationSpec{
+				Instance: "upgrade",
+				Type:     mysqlv1alpha1.OperationTypeUpgrade,
+				Mode:     mysqlv1alpha1.OperationModeImmediate,
+			},
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &OperationReconciler{
+			Client:    k8sClient,
+			Log:       zapr.NewLogger(zapLog),
+			Scheme:    scheme.Scheme,
+			Connector: NewMockReplicationConnector(map[string]*agent.Replication{}),
+		}
+		Expect(k8sClient.Create(ctx, &operation)).To(Succeed())
+
+		operationName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Name}
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{Requeue: false}))
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).To(Equal(ctrl.Result{Requeue: false}))
+		response := mysqlv1alpha1.Operation{}
+		Expect(k8sClient.Get(ctx, operationName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.OperationRunning), "Expected reconcile to change the status to Running")
+		Expect(response.Status.Backup).To(HavePrefix("upgrade-upgrade-"))
+		Expect(response.Status.Version).To(Equal("8.0.24"))
+
+		backup := mysqlv1alpha1.Backup{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: response.Status.Backup}, &backup)).To(Succeed())
+		Expect(backup.Spec.Store).To(Equal("upgrade-store"))
+
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).
+			To(Equal(ctrl.Result{RequeueAfter: restartPollInterval}), "Expected the operation to wait for the backup")
+		upgraded := appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "upgrade"}, &upgraded)).To(Succeed())
+		Expect(upgraded.Spec.Template.Spec.Containers[0].Image).To(Equal("mysql:8.0.23"), "Expected the image to change after the backup")
+
+		backup.Status.Reason = mysqlv1alpha1.BackupSucceeded
+		Expect(k8sClient.Status().Update(ctx, &backup)).To(Succeed())
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: operationName})).
+			To(Equal(ctrl.Result{RequeueAfter: restartPollInterval}), "Expected the operation to wait for the rollout")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "upgrade"}, &upgraded)).To(Succeed())
+		Expect(upgraded.Spec.Template.Spec.Containers[0].Image).To(Equal("mysql:8.0.24"))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "upgrade"}, &instance)).To(Succeed())
+		Expect(instance.Status.Version).To(Equal("8.0.23"), "Expected the instance to keep its version until the pods run the new one")
+	})
 })
@@ -2,14 +2,66 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
 
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+	"github.com/google/uuid"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 const (
 	maxOperationConditions = 10
+
+	// restartedAtAnnotation is changed on the pod template to restart the
+	// pods of an instance
+	restartedAtAnnotation = "mysql.blaqkube.io/restartedAt"
+
+	// restartPollInterval is how often a rolling restart is checked
+	restartPollInterval = 10 * time.Second
+
+	// restartTimeout is how long the pods have to restart before the
+	// operation fails
+	restartTimeout = 30 * time.Minute
+
+	// upgradeTimeout is how long the pre-upgrade backup and the restart with
+	// the new version can take before the operation fails
+	upgradeTimeout = 2 * time.Hour
+)
+
+var (
+	// ErrSwitchoverNotSupported is reported when the instance has no replica
+	ErrSwitchoverNotSupported = errors.New("SwitchoverNotSupported")
+
+	// ErrPrimaryNotFound is reported when the primary of an instance is not available
+	ErrPrimaryNotFound = errors.New("PrimaryNotFound")
+
+	// ErrRestartTimeout is reported when the pods are not back after a restart
+	ErrRestartTimeout = errors.New("RestartTimeout")
+
+	// ErrUpgradeNotRequired is reported when the instance already runs its version
+	ErrUpgradeNotRequired = errors.New("UpgradeNotRequired")
+
+	// ErrDowngradeNotSupported is reported when the version is older than the running one
+	ErrDowngradeNotSupported = errors.New("DowngradeNotSupported")
+
+	// ErrBackupStoreMissing is reported when the instance has no store for the pre-upgrade backup
+	ErrBackupStoreMissing = errors.New("BackupStoreMissing")
+
+	// ErrUpgradeTimeout is reported when the pods do not run the version
+	// before the end of the upgrade
+	ErrUpgradeTimeout = errors.New("UpgradeTimeout")
+
+	// ErrVersionMismatch is reported when a server does not run the version after an upgrade
+	ErrVersionMismatch = errors.New("VersionMismatch")
 )
 
 // OperationManager provides methods to manage operations
@@ -49,3 +101,387 @@ func (om *OperationManager) setOperationCondition(operation *mysqlv1alpha1.Opera
 func (om *OperationManager) NoOp() error {
 	return nil
 }
+
+// Restart restarts the pods of the operation instance so that MySQL starts
+// again with its my.cnf. The statefulset controller rolls the pods one at a
+// time and MonitorRestart follows its progress.
+func (om *OperationManager) Restart(operation *mysqlv1alpha1.Operation) error {
+	log := om.Reconciler.Log.WithValues("namespace", operation.Namespace, "operation", operation.Name)
+
+	statefulset := &appsv1.StatefulSet{}
+	statefulsetName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance}
+	if err := om.Reconciler.Get(om.Context, statefulsetName, statefulset); err != nil {
+		return ErrInstanceNotFound
+	}
+	if statefulset.Spec.Template.Annotations == nil {
+		statefulset.Spec.Template.Annotations = map[string]string{}
+	}
+	statefulset.Spec.Template.Annotations[restartedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if err := om.Reconciler.Update(om.Context, statefulset); err != nil {
+		log.Error(err, "Unable to update statefulset")
+		return err
+	}
+	log.Info("Restart requested", "statefulset", statefulset.Name)
+	return nil
+}
+
+// MonitorRestart checks the rolling restart of the operation instance. It
+// reports true once every pod runs the restarted template, is ready and its
+// agent answers, and fails when it takes more than restartTimeout.
+func (om *OperationManager) MonitorRestart(operation *mysqlv1alpha1.Operation) (bool, error) {
+	log := om.Reconciler.Log.WithValues("namespace", operation.Namespace, "operation", operation.Name)
+
+	done, err := om.isRestarted(operation)
+	if err != nil || done {
+		return done, err
+	}
+	if operation.Status.StartTime != nil && time.Since(operation.Status.StartTime.Time) > restartTimeout {
+		return false, ErrRestartTimeout
+	}
+	log.Info("Waiting for the pods to restart")
+	return false, nil
+}
+
+// isRestarted reports if the statefulset rollout is complete and every pod
+// can be used
+func (om *OperationManager) isRestarted(operation *mysqlv1alpha1.Operation) (bool, error) {
+	log := om.Reconciler.Log.WithValues("namespace", operation.Namespace, "operation", operation.Name)
+
+	statefulset := &appsv1.StatefulSet{}
+	statefulsetName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance}
+	if err := om.Reconciler.Get(om.Context, statefulsetName, statefulset); err != nil {
+		return false, ErrInstanceNotFound
+	}
+	if statefulset.Status.ObservedGeneration < statefulset.Generation ||
+		statefulset.Status.CurrentRevision != statefulset.Status.UpdateRevision {
+		return false, nil
+	}
+	replicas := int32(1)
+	if statefulset.Spec.Replicas != nil {
+		replicas = *statefulset.Spec.Replicas
+	}
+	if statefulset.Status.ReadyReplicas != replicas {
+		return false, nil
+	}
+	for i := int32(0); i < replicas; i++ {
+		pod := &corev1.Pod{}
+		podName := types.NamespacedName{Namespace: operation.Namespace, Name: fmt.Sprintf("%s-%d", statefulset.Name, i)}
+		if err := om.Reconciler.Get(om.Context, podName, pod); err != nil {
+			return false, nil
+		}
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != statefulset.Status.UpdateRevision || !isPodReady(pod) {
+			return false, nil
+		}
+		if _, err := om.Reconciler.Connector.GetReplication(om.Context, pod.Status.PodIP); err != nil {
+			log.Info(fmt.Sprintf("Agent does not answer, error: %v", err), "pod", pod.Name)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Upgrade starts the upgrade of the operation instance to its version with
+// a backup to the store of its backup schedule. MonitorUpgrade rolls the
+// image once the backup has succeeded.
+func (om *OperationManager) Upgrade(operation *mysqlv1alpha1.Operation) error {
+	log := om.Reconciler.Log.WithValues("namespace", operation.Namespace, "operation", operation.Name)
+
+	instance := &mysqlv1alpha1.Instance{}
+	instanceName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance}
+	if err := om.Reconciler.Get(om.Context, instanceName, instance); err != nil {
+		return ErrInstanceNotFound
+	}
+	if instance.Spec.Version == "" || instance.Spec.Version == instance.Status.Version {
+		return ErrUpgradeNotRequired
+	}
+	if instance.Status.Version != "" && compareVersions(instance.Spec.Version, instance.Status.Version) < 0 {
+		return ErrDowngradeNotSupported
+	}
+	if instance.Status.Reason != mysqlv1alpha1.InstanceStatefulSetReady {
+		return ErrInstanceNotReady
+	}
+	if instance.Spec.BackupSchedule.Store == "" {
+		return ErrBackupStoreMissing
+	}
+	backup := &mysqlv1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-upgrade-%s", instance.Name, time.Now().Format("20060102-150405")),
+			Namespace: instance.Namespace,
+		},
+		Spec: mysqlv1alpha1.BackupSpec{
+			Store:       instance.Spec.BackupSchedule.Store,
+			Instance:    instance.Name,
+			Compression: instance.Spec.BackupSchedule.Compression,
+			Method:      instance.Spec.BackupSchedule.Method,
+			Dump:        instance.Spec.BackupSchedule.Dump,
+		},
+	}
+	if err := controllerutil.SetControllerReference(instance, backup, om.Reconciler.Scheme); err != nil {
+		return err
+	}
+	if err := om.Reconciler.Create(om.Context, backup); err != nil {
+		log.Error(err, "Unable to create the pre-upgrade backup")
+		return err
+	}
+	log.Info("Pre-upgrade backup created", "backup", backup.Name, "version", instance.Spec.Version)
+	operation.Status.Backup = backup.Name
+	operation.Status.Version = instance.Spec.Version
+	return nil
+}
+
+// MonitorUpgrade follows an upgrade. Once the pre-upgrade backup has
+// succeeded, it changes the statefulset image to the version of the
+// operation. MySQL upgrades its data dictionary when it starts and the
+// version is recorded in the instance status when every server reports it.
+// The instance keeps its version when the upgrade fails.
+func (om *OperationManager) MonitorUpgrade(operation *mysqlv1alpha1.Operation) (bool, error) {
+	log := om.Reconciler.Log.WithValues("namespace", operation.Namespace, "operation", operation.Name)
+
+	timeout := operation.Status.StartTime != nil && time.Since(operation.Status.StartTime.Time) > upgradeTimeout
+	instance := &mysqlv1alpha1.Instance{}
+	instanceName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance}
+	if err := om.Reconciler.Get(om.Context, instanceName, instance); err != nil {
+		return false, ErrInstanceNotFound
+	}
+	version := operation.Status.Version
+	if version == "" {
+		return false, ErrUpgradeNotRequired
+	}
+	backup := &mysqlv1alpha1.Backup{}
+	backupName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Status.Backup}
+	if err := om.Reconciler.Get(om.Context, backupName, backup); err != nil {
+		return false, ErrBackupFailed
+	}
+	switch backup.Status.Reason {
+	case mysqlv1alpha1.BackupSucceeded:
+	case mysqlv1alpha1.BackupFailed, mysqlv1alpha1.BackupNotImplemented:
+		return false, ErrBackupFailed
+	default:
+		if timeout {
+			return false, ErrBackupRunning
+		}
+		log.Info("Waiting for the pre-upgrade backup", "backup", backup.Name)
+		return false, nil
+	}
+
+	statefulset := &appsv1.StatefulSet{}
+	if err := om.Reconciler.Get(om.Context, instanceName, statefulset); err != nil {
+		return false, ErrInstanceNotFound
+	}
+	if statefulSetVersion(statefulset) != version {
+		setStatefulSetVersion(statefulset, version)
+		if err := om.Reconciler.Update(om.Context, statefulset); err != nil {
+			log.Error(err, "Unable to update statefulset")
+			return false, err
+		}
+		log.Info("Upgrade started", "statefulset", statefulset.Name, "version", version)
+		return false, nil
+	}
+
+	done, err := om.isRestarted(operation)
+	if err != nil {
+		return false, err
+	}
+	if !done {
+		if timeout {
+			return false, fmt.Errorf("%w, the pods do not run %s, the instance keeps %s", ErrUpgradeTimeout, version, instance.Status.Version)
+		}
+		log.Info("Waiting for the pods to restart with the new version", "version", version)
+		return false, nil
+	}
+	for i := int32(0); i < instanceReplicas(instance); i++ {
+		pod := &corev1.Pod{}
+		podName := types.NamespacedName{Namespace: operation.Namespace, Name: fmt.Sprintf("%s-%d", instance.Name, i)}
+		if err := om.Reconciler.Get(om.Context, podName, pod); err != nil {
+			return false, nil
+		}
+		variable, _, err := newAgentClient(pod.Status.PodIP).MysqlApi.GetVariableByName(om.Context, "version", nil)
+		if err != nil {
+			log.Info(fmt.Sprintf("Could not read the version, error: %v", err), "pod", pod.Name)
+			return false, nil
+		}
+		if !matchVersion(variable.Value, version) {
+			return false, fmt.Errorf("%w, %s runs %s instead of %s", ErrVersionMismatch, pod.Name, variable.Value, version)
+		}
+	}
+	instance.Status.Version = version
+	if err := om.Reconciler.Status().Update(om.Context, instance); err != nil {
+		log.Error(err, "Unable to update instance")
+		return false, err
+	}
+	log.Info("Upgrade succeeded", "version", version)
+	return true, nil
+}
+
+// rotatedCredentials are the secrets of the accounts a rotation changes
+var rotatedCredentials = []struct {
+	suffix string
+	data   func(string) map[string]string
+}{
+	{suffix: "root", data: rootSecretData},
+	{suffix: "agent", data: agentSecretData},
+	{suffix: "exporter", data: exporterSecretData},
+}
+
+// Rotate changes the root, agent and exporter passwords of the operation
+// instance. The agent of the primary changes each password and retains the
+// current one, so that the agent and the exporter keep connecting until they
+// use the new password from the updated secrets. MySQL is not restarted:
+// the agent reloads its credentials and the kubelet restarts the exporter
+// when the secrets change, and MonitorRotate discards the retained passwords.
+func (om *OperationManager) Rotate(operation *mysqlv1alpha1.Operation) error {
+	log := om.Reconciler.Log.WithValues("namespace", operation.Namespace, "operation", operation.Name)
+
+	a := &APIReconciler{
+		Client: om.Reconciler.Client,
+		Log:    om.Reconciler.Log,
+	}
+	api, err := a.GetAPI(om.Context, types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance})
+	if err != nil {
+		return err
+	}
+	for _, c := range rotatedCredentials {
+		secret := &corev1.Secret{}
+		secretName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance + "-" + c.suffix}
+		if err := om.Reconciler.Get(om.Context, secretName, secret); err != nil {
+			return err
+		}
+		user := agent.User{
+			Username: string(secret.Data["username"]),
+			Password: uuid.New().String(),
+		}
+		_, response, err := api.MysqlApi.RotateCredential(om.Context, user, nil)
+		if err != nil {
+			log.Error(err, "Unable to rotate credential", "user", user.Username)
+			return err
+		}
+		if response == nil || response.StatusCode != http.StatusCreated {
+			return ErrAgentRequestFailed
+		}
+		secret.StringData = c.data(user.Password)
+		if err := om.Reconciler.Update(om.Context, secret); err != nil {
+			log.Error(err, "Unable to update secret", "secret", secret.Name)
+			return err
+		}
+		log.Info("Credential rotated", "user", user.Username, "secret", secret.Name)
+	}
+	return nil
+}
+
+// MonitorRotate follows a rotation. The kubelet updates the secrets of a pod
+// together and the exporter is restarted once its copy of .my.cnf differs
+// from the secret. When the exporter of every pod has started after the
+// rotation, the pods use the new passwords and the retained ones are
+// discarded. It fails when it takes more than restartTimeout.
+func (om *OperationManager) MonitorRotate(operation *mysqlv1alpha1.Operation) (bool, error) {
+	log := om.Reconciler.Log.WithValues("namespace", operation.Namespace, "operation", operation.Name)
+
+	instance := &mysqlv1alpha1.Instance{}
+	instanceName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance}
+	if err := om.Reconciler.Get(om.Context, instanceName, instance); err != nil {
+		return false, ErrInstanceNotFound
+	}
+	rotatedAt := time.Time{}
+	if operation.Status.StartTime != nil {
+		rotatedAt = operation.Status.StartTime.Time
+	}
+	for i := int32(0); i < instanceReplicas(instance); i++ {
+		pod := &corev1.Pod{}
+		podName := types.NamespacedName{Namespace: operation.Namespace, Name: fmt.Sprintf("%s-%d", instance.Name, i)}
+		if err := om.Reconciler.Get(om.Context, podName, pod); err == nil && isExporterRestarted(pod, rotatedAt) {
+			continue
+		}
+		if operation.Status.StartTime != nil && time.Since(rotatedAt) > restartTimeout {
+			return false, ErrRestartTimeout
+		}
+		log.Info("Waiting for the exporter to use the new password", "pod", podName.Name)
+		return false, nil
+	}
+
+	a := &APIReconciler{
+		Client: om.Reconciler.Client,
+		Log:    om.Reconciler.Log,
+	}
+	api, err := a.GetAPI(om.Context, instanceName)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range rotatedCredentials {
+		secret := &corev1.Secret{}
+		secretName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance + "-" + c.suffix}
+		if err := om.Reconciler.Get(om.Context, secretName, secret); err != nil {
+			return false, err
+		}
+		user := agent.User{Username: string(secret.Data["username"])}
+		_, response, err := api.MysqlApi.DiscardCredential(om.Context, user, nil)
+		if err != nil {
+			log.Error(err, "Unable to discard the retained password", "user", user.Username)
+			return false, err
+		}
+		if response == nil || response.StatusCode != http.StatusCreated {
+			return false, ErrAgentRequestFailed
+		}
+		log.Info("Retained password discarded", "user", user.Username)
+	}
+	return true, nil
+}
+
+// isExporterRestarted reports if the exporter of a pod runs and has started
+// after a time
+func isExporterRestarted(pod *corev1.Pod, since time.Time) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "exporter" {
+			return status.Ready && status.State.Running != nil && status.State.Running.StartedAt.After(since)
+		}
+	}
+	return false
+}
+
+// Switchover promotes a replica of the operation instance and demotes its
+// primary. The instance must be ready and the new primary is recorded in
+// the instance status.
+func (om *OperationManager) Switchover(operation *mysqlv1alpha1.Operation) error {
+	log := om.Reconciler.Log.WithValues("namespace", operation.Namespace, "operation", operation.Name)
+
+	instance := &mysqlv1alpha1.Instance{}
+	instanceName := types.NamespacedName{Namespace: operation.Namespace, Name: operation.Spec.Instance}
+	if err := om.Reconciler.Get(om.Context, instanceName, instance); err != nil {
+		return ErrInstanceNotFound
+	}
+	if instanceReplicas(instance) < 2 {
+		return ErrSwitchoverNotSupported
+	}
+	if instance.Status.Reason != mysqlv1alpha1.InstanceStatefulSetReady {
+		return ErrInstanceNotReady
+	}
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name + "-replication"}
+	if err := om.Reconciler.Get(om.Context, secretName, secret); err != nil {
+		return err
+	}
+	primary, standbys := getMembers(om.Context, om.Reconciler.Client, instance)
+	if primary == nil {
+		return ErrPrimaryNotFound
+	}
+	f := &Failover{
+		Context:   om.Context,
+		Connector: om.Reconciler.Connector,
+		Log:       om.Reconciler.Log.WithValues("namespace", instance.Namespace, "instance", instance.Name),
+		Timeout:   defaultPromoteTimeout,
+	}
+	user := agent.User{
+		Username: string(secret.Data["username"]),
+		Password: string(secret.Data["password"]),
+	}
+	elected, err := f.Run(*primary, false, standbys, user, operation.Spec.Target)
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Switchover from %s to %s succeeded", primary.Name, elected.Name))
+	instance.Status.Primary = elected.Name
+	if err := om.Reconciler.Status().Update(om.Context, instance); err != nil {
+		log.Error(err, "Unable to update instance")
+		return err
+	}
+	return syncServices(om.Context, om.Reconciler.Client, om.Reconciler.Scheme, instance)
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+// RestoreReconciler reconciles a Restore object
+type RestoreReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=stores,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=backups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=instances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=instances/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=restores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=restores/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=restores/finalizers,verbs=update
+
+// Reconcile implement the reconciliation loop for restores
+func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("restore", req.NamespacedName)
+	log.Info("Running a reconcile loop")
+
+	// Fetch the Restore instance
+	restore := &mysqlv1alpha1.Restore{}
+	if err := r.Get(ctx, req.NamespacedName, restore); err != nil {
+		log.Info("Unable to fetch restore from kubernetes")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	rm := &RestoreManager{
+		Context:     ctx,
+		Reconciler:  r,
+		TimeManager: NewTimeManager(),
+	}
+
+	if !restore.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, rm.ReleaseInstance(restore)
+	}
+
+	if restore.Status.Reason == mysqlv1alpha1.RestoreSucceeded ||
+		restore.Status.Reason == mysqlv1alpha1.RestoreFailed {
+		return ctrl.Result{}, nil
+	}
+
+	if restore.Status.Reason == mysqlv1alpha1.RestoreRunning {
+		d, err := rm.MonitorRestore(restore)
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+		}
+		switch err {
+		case nil:
+			condition.Reason = mysqlv1alpha1.RestoreSucceeded
+			condition.Message = "Restore Succeeded"
+			condition.Status = metav1.ConditionTrue
+		case ErrRestoreRunning:
+			condition.Reason = mysqlv1alpha1.RestoreRunning
+			condition.Message = fmt.Sprintf("Restore is running, step: %s", d.Step)
+			return rm.setRestoreCondition(restore, condition, d)
+		default:
+			condition.Reason = mysqlv1alpha1.RestoreFailed
+			condition.Message = fmt.Sprintf("Restore Failed: %v", err)
+		}
+		if err := rm.ReleaseInstance(restore); err != nil {
+			log.Error(err, "Unable to release the instance from maintenance")
+			return ctrl.Result{}, err
+		}
+		return rm.setRestoreCondition(restore, condition, d)
+	}
+
+	d, err := rm.CreateRestore(restore)
+	if err != nil {
+		condition := metav1.Condition{
+			Type:               "available",
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+		}
+		switch err {
+		case ErrRestoreSpecInvalid:
+			condition.Reason = mysqlv1alpha1.RestoreSpecInvalid
+			condition.Message = "Restore requires a backup or a store and a location"
+		case ErrBackupNotFound:
+			condition.Reason = mysqlv1alpha1.RestoreBackupAccessError
+			condition.Message = "Restore backup not found"
+		case ErrBackupNotReady:
+			condition.Reason = mysqlv1alpha1.RestoreBackupNotReady
+			condition.Message = "Restore backup has not succeeded yet"
+		case ErrBackupPhysical:
+			condition.Reason = mysqlv1alpha1.RestoreBackupPhysical
+			condition.Message = fmt.Sprintf("Restore backup %s is a physical backup, use it to create an instance", restore.Spec.Backup)
+		case ErrBackupFailed:
+			condition.Reason = mysqlv1alpha1.RestoreFailed
+			condition.Message = fmt.Sprintf("Restore backup %s has failed", restore.Spec.Backup)
+		case ErrStoreNotFound:
+			condition.Reason = mysqlv1alpha1.RestoreStoreAccessError
+			condition.Message = "Restore store not found"
+		case ErrStoreNotReady:
+			condition.Reason = mysqlv1alpha1.RestoreStoreNotReady
+			condition.Message = "Restore store not ready"
+		case ErrInstanceNotFound:
+			condition.Reason = mysqlv1alpha1.RestoreInstanceAccessError
+			condition.Message = "Restore instance not found"
+		case ErrInstanceNotReady:
+			condition.Reason = mysqlv1alpha1.RestoreInstanceNotReady
+			condition.Message = "Restore instance not ready"
+		case ErrInstanceRestoring:
+			condition.Reason = mysqlv1alpha1.RestoreInstanceNotReady
+			condition.Message = "Restore instance is restoring another backup"
+		case ErrPodNotFound, ErrAgentAccessFailed:
+			condition.Reason = mysqlv1alpha1.RestoreAgentNotFound
+			condition.Message = "Restore agent not found"
+		case ErrAgentRequestFailed:
+			condition.Reason = mysqlv1alpha1.RestoreAgentFailed
+			condition.Message = "Restore request failed"
+		case ErrMissingVariable:
+			condition.Reason = mysqlv1alpha1.RestoreMissingVariable
+			condition.Message = "Restore environment variable missing from store"
+		case ErrEncryptionKeyNotFound, ErrEncryptionKeyInvalid:
+			condition.Reason = mysqlv1alpha1.RestoreEncryptionKeyError
+			condition.Message = fmt.Sprintf("Restore store encryption keys cannot be used: %v", err)
+		default:
+			condition.Reason = mysqlv1alpha1.RestoreAgentFailed
+			condition.Message = fmt.Sprintf("Unexpected failure with agent: %v", err)
+		}
+		return rm.setRestoreCondition(restore, condition, nil)
+	}
+	condition := metav1.Condition{
+		Type:               "available",
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             mysqlv1alpha1.RestoreRunning,
+		Message:            fmt.Sprintf("Restore started on %s with success. Now monitoring progress", restore.Spec.Instance),
+	}
+	return rm.setRestoreCondition(restore, condition, d)
+}
+
+// SetupWithManager configure type of events the manager should watch
+func (r *RestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mysqlv1alpha1.Restore{}).
+		Complete(r)
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/go-logr/zapr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+	// +kubebuilder:scaffold:imports
+)
+
+var _ = Describe("Restore Controller", func() {
+	It("Create a restore without backup or location", func() {
+		ctx := context.Background()
+		restore := mysqlv1alpha1.Restore{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "restore-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.RestoreResourceSpec{
+				Instance: "instance",
+				Store:    "store",
+			},
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &RestoreReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+		}
+		Expect(k8sClient.Create(ctx, &restore)).To(Succeed())
+
+		restoreName := types.NamespacedName{Namespace: restore.Namespace, Name: restore.Name}
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: restoreName})).To(Equal(ctrl.Result{Requeue: false}))
+		response := mysqlv1alpha1.Restore{}
+		Expect(k8sClient.Get(ctx, restoreName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.RestoreSpecInvalid), "Expected reconcile to reject the restore")
+	})
+
+	It("Create a restore from a missing backup", func() {
+		ctx := context.Background()
+		restore := mysqlv1alpha1.Restore{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "restore-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.RestoreResourceSpec{
+				Instance: "instance",
+				Backup:   "backup",
+			},
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &RestoreReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+		}
+		Expect(k8sClient.Create(ctx, &restore)).To(Succeed())
+
+		restoreName := types.NamespacedName{Namespace: restore.Namespace, Name: restore.Name}
+		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: restoreName})).To(Equal(ctrl.Result{Requeue: false}))
+		response := mysqlv1alpha1.Restore{}
+		Expect(k8sClient.Get(ctx, restoreName, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.RestoreBackupAccessError), "Expected reconcile to change the status to BackupAccessError")
+	})
+
+	It("Restore a backup from the bucket it has been taken in", func() {
+		ctx := context.Background()
+		backup := mysqlv1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "backup-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.BackupSpec{
+				Store:    "store",
+				Instance: "instance",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &backup)).To(Succeed())
+		backup.Status = mysqlv1alpha1.BackupStatus{
+			Reason: mysqlv1alpha1.BackupSucceeded,
+			Details: &mysqlv1alpha1.BackupDetails{
+				Bucket:   "former",
+				Location: "/instance-20210301-000000.sql",
+			},
+		}
+		Expect(k8sClient.Status().Update(ctx, &backup)).To(Succeed())
+
+		zapLog, _ := zap.NewDevelopment()
+		rm := &RestoreManager{
+			Context: ctx,
+			Reconciler: &RestoreReconciler{
+				Client: k8sClient,
+				Log:    zapr.NewLogger(zapLog),
+				Scheme: scheme.Scheme,
+			},
+		}
+		restore := &mysqlv1alpha1.Restore{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+			Spec:       mysqlv1alpha1.RestoreResourceSpec{Instance: "instance", Backup: backup.Name},
+		}
+		store, bucket, location, err := rm.restoreSource(restore)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store).To(Equal("store"))
+		Expect(bucket).To(Equal("former"), "Expected the bucket of the backup rather than the one of the store")
+		Expect(location).To(Equal("/instance-20210301-000000.sql"))
+	})
+})
@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	maxRestoreConditions   = 10
+	restorePollingInterval = 30 * time.Second
+
+	// restoreFinalizer keeps a running restore until the instance has left
+	// maintenance
+	restoreFinalizer = "mysql.blaqkube.io/restore"
+)
+
+var (
+	// ErrRestoreSpecInvalid is reported when the restore has no backup and
+	// no store and location
+	ErrRestoreSpecInvalid = errors.New("RestoreSpecInvalid")
+
+	// ErrBackupPhysical is reported when the restore references a physical
+	// backup, it can only be restored when an instance is created
+	ErrBackupPhysical = errors.New("BackupPhysical")
+
+	// ErrRestoreRunning is reported when the restore is still running
+	ErrRestoreRunning = errors.New("RestoreRunning")
+
+	// ErrInstanceRestoring is reported when the instance is restoring
+	// another backup
+	ErrInstanceRestoring = errors.New("InstanceRestoring")
+)
+
+// RestoreManager provides methods to manage the restore subcomponents
+type RestoreManager struct {
+	Context     context.Context
+	Reconciler  *RestoreReconciler
+	TimeManager *TimeManager
+}
+
+func (rm *RestoreManager) setRestoreCondition(restore *mysqlv1alpha1.Restore, condition metav1.Condition, details *mysqlv1alpha1.RestoreDetails) (ctrl.Result, error) {
+	log := rm.Reconciler.Log.WithValues("namespace", restore.Namespace, "restore", restore.Name)
+	if condition.Reason == restore.Status.Reason {
+		if condition.Reason == mysqlv1alpha1.RestoreRunning {
+			if details != nil && restore.Status.Details != nil && details.Step != restore.Status.Details.Step {
+				restore.Status.Details = details
+				restore.Status.Message = condition.Message
+				log.Info("Updating restore progress", "Step", details.Step)
+				if err := rm.Reconciler.Status().Update(rm.Context, restore); err != nil {
+					log.Error(err, "Unable to update the restore")
+					return ctrl.Result{}, err
+				}
+			}
+			return ctrl.Result{Requeue: true, RequeueAfter: restorePollingInterval}, nil
+		}
+		c := len(restore.Status.Conditions) - 1
+		d := rm.TimeManager.Next(restore.Status.Conditions[c].LastTransitionTime.Time)
+		return ctrl.Result{Requeue: true, RequeueAfter: d}, nil
+	}
+	if details != nil {
+		restore.Status.Details = details
+	}
+	restore.Status.Ready = condition.Status
+	restore.Status.Reason = condition.Reason
+	restore.Status.Message = condition.Message
+	conditions := append(restore.Status.Conditions, condition)
+	if len(conditions) > maxRestoreConditions {
+		conditions = conditions[1:]
+	}
+	restore.Status.Conditions = conditions
+	log.Info("Updating restore with new Status", "Reason", condition.Reason, "Message", condition.Message)
+	if err := rm.Reconciler.Status().Update(rm.Context, restore); err != nil {
+		log.Error(err, "Unable to update the restore")
+		return ctrl.Result{}, err
+	}
+	if condition.Reason == mysqlv1alpha1.RestoreRunning {
+		return ctrl.Result{Requeue: true, RequeueAfter: restorePollingInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// restoreSource returns the store, the bucket and the location of the dump,
+// from the backup when the restore references one. The bucket is empty when
+// the dump is in the bucket of the store
+func (rm *RestoreManager) restoreSource(restore *mysqlv1alpha1.Restore) (string, string, string, error) {
+	if restore.Spec.Backup == "" {
+		if restore.Spec.Store == "" || restore.Spec.Location == "" {
+			return "", "", "", ErrRestoreSpecInvalid
+		}
+		return restore.Spec.Store, "", restore.Spec.Location, nil
+	}
+	a := &APIReconciler{
+		Client: rm.Reconciler.Client,
+		Log:    rm.Reconciler.Log,
+	}
+	backup, err := a.GetBackup(
+		rm.Context,
+		types.NamespacedName{
+			Name:      restore.Spec.Backup,
+			Namespace: restore.Namespace,
+		},
+	)
+	if err != nil {
+		return "", "", "", err
+	}
+	if isPhysical(backup.Status.Details.Method) {
+		return "", "", "", ErrBackupPhysical
+	}
+	return backup.Spec.Store, backup.Status.Details.Bucket, backup.Status.Details.Location, nil
+}
+
+// CreateRestore puts the instance in maintenance and requests the agent of
+// the primary to load the dump
+func (rm *RestoreManager) CreateRestore(restore *mysqlv1alpha1.Restore) (*mysqlv1alpha1.RestoreDetails, error) {
+	log := rm.Reconciler.Log.WithValues("namespace", restore.Namespace, "restore", restore.Name)
+	storeName, bucket, location, err := rm.restoreSource(restore)
+	if err != nil {
+		return nil, err
+	}
+	a := &APIReconciler{
+		Client: rm.Reconciler.Client,
+		Log:    rm.Reconciler.Log,
+	}
+	store, err := a.GetStore(
+		rm.Context,
+		types.NamespacedName{
+			Name:      storeName,
+			Namespace: restore.Namespace,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	api, err := a.GetAPI(
+		rm.Context,
+		types.NamespacedName{
+			Name:      restore.Spec.Instance,
+			Namespace: restore.Namespace,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	em := &EnvManager{
+		Client: rm.Reconciler.Client,
+		Log:    rm.Reconciler.Log,
+	}
+	envs, err := em.GetEnvVars(rm.Context, *store)
+	if err != nil {
+		return nil, err
+	}
+	agentEnvs := []agent.EnvVar{}
+	for k, v := range envs {
+		agentEnvs = append(agentEnvs, agent.EnvVar{Name: k, Value: v})
+	}
+	encryption, err := em.GetEncryption(rm.Context, *store)
+	if err != nil {
+		return nil, err
+	}
+	if bucket == "" {
+		bucket = store.Spec.Bucket
+	}
+	payload := agent.BackupRequest{
+		Backend:    string(store.Spec.Backend),
+		Bucket:     bucket,
+		Location:   location,
+		Envs:       agentEnvs,
+		Encryption: encryption,
+		S3:         s3Options(store),
+	}
+
+	if err := rm.holdInstance(restore); err != nil {
+		return nil, err
+	}
+	r, response, err := api.MysqlApi.CreateRestore(rm.Context, payload, nil)
+	if err != nil || response == nil || response.StatusCode != http.StatusCreated {
+		if releaseErr := rm.ReleaseInstance(restore); releaseErr != nil {
+			log.Error(releaseErr, "Unable to release the instance from maintenance")
+		}
+		if err != nil || response == nil {
+			msg := "NoResponse"
+			if err != nil {
+				msg = err.Error()
+			}
+			log.Info(fmt.Sprintf("Could not access agent, error: %s", msg))
+			return nil, ErrAgentAccessFailed
+		}
+		log.Info("Agent returned unexpected response", "httpcode", response.StatusCode)
+		return nil, ErrAgentRequestFailed
+	}
+	return &mysqlv1alpha1.RestoreDetails{
+		Identifier: r.Identifier,
+		Bucket:     r.Bucket,
+		Location:   r.Location,
+		Step:       r.Step,
+		StartTime:  &metav1.Time{Time: r.StartTime},
+	}, nil
+}
+
+// MonitorRestore watch restore progress and update results
+func (rm *RestoreManager) MonitorRestore(restore *mysqlv1alpha1.Restore) (*mysqlv1alpha1.RestoreDetails, error) {
+	log := rm.Reconciler.Log.WithValues("namespace", restore.Namespace, "restore", restore.Name)
+	if restore.Status.Details == nil {
+		log.Info("Missing restore details, monitoring fails")
+		return &mysqlv1alpha1.RestoreDetails{}, errors.New("the restore details are missing")
+	}
+	d := restore.Status.Details.DeepCopy()
+
+	a := &APIReconciler{
+		Client: rm.Reconciler.Client,
+		Log:    rm.Reconciler.Log,
+	}
+	api, err := a.GetAPI(
+		rm.Context,
+		types.NamespacedName{
+			Name:      restore.Spec.Instance,
+			Namespace: restore.Namespace,
+		},
+	)
+	if err == ErrInstanceNotFound {
+		return d, errors.New("the instance has been deleted")
+	}
+	if err != nil {
+		log.Info(fmt.Sprintf("Agent not available, error: %v", err))
+		return d, ErrRestoreRunning
+	}
+	data, response, err := api.MysqlApi.GetRestoreByID(rm.Context, d.Identifier, nil)
+	if err != nil || response == nil || response.StatusCode != http.StatusOK {
+		log.Info(fmt.Sprintf("Error calling GetRestoreByID, err: %v", err))
+		v := metav1.Now()
+		d.EndTime = &v
+		return d, errors.New("the agent has lost the restore, check agent logs for details")
+	}
+	d.Step = data.Step
+	if data.EndTime != nil {
+		d.EndTime = &metav1.Time{Time: *data.EndTime}
+	}
+	switch data.Status {
+	case "Running":
+		return d, ErrRestoreRunning
+	case "Succeeded":
+		return d, nil
+	}
+	return d, errors.New(data.Message)
+}
+
+// holdInstance puts the instance in maintenance for the restore. The
+// restore keeps a finalizer until the instance is released
+func (rm *RestoreManager) holdInstance(restore *mysqlv1alpha1.Restore) error {
+	instance := &mysqlv1alpha1.Instance{}
+	instanceName := types.NamespacedName{Name: restore.Spec.Instance, Namespace: restore.Namespace}
+	if err := rm.Reconciler.Client.Get(rm.Context, instanceName, instance); err != nil {
+		return ErrInstanceNotFound
+	}
+	if instance.Status.Restore != "" && instance.Status.Restore != restore.Name {
+		return ErrInstanceRestoring
+	}
+	if !controllerutil.ContainsFinalizer(restore, restoreFinalizer) {
+		controllerutil.AddFinalizer(restore, restoreFinalizer)
+		if err := rm.Reconciler.Update(rm.Context, restore); err != nil {
+			return err
+		}
+	}
+	instance.Status.MaintenanceMode = true
+	instance.Status.Restore = restore.Name
+	rm.Reconciler.Log.Info("Instance in maintenance for restore", "namespace", restore.Namespace, "instance", instance.Name, "restore", restore.Name)
+	return rm.Reconciler.Status().Update(rm.Context, instance)
+}
+
+// ReleaseInstance ends the maintenance of the instance unless it is in its
+// maintenance window and removes the restore finalizer
+func (rm *RestoreManager) ReleaseInstance(restore *mysqlv1alpha1.Restore) error {
+	instance := &mysqlv1alpha1.Instance{}
+	instanceName := types.NamespacedName{Name: restore.Spec.Instance, Namespace: restore.Namespace}
+	err := rm.Reconciler.Client.Get(rm.Context, instanceName, instance)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && instance.Status.Restore == restore.Name {
+		instance.Status.Restore = ""
+		end := instance.Status.Schedules.MaintenanceEndTime
+		if end == nil || end.Time.Before(time.Now()) {
+			instance.Status.MaintenanceMode = false
+		}
+		rm.Reconciler.Log.Info("Instance released from restore", "namespace", restore.Namespace, "instance", instance.Name, "restore", restore.Name)
+		if err := rm.Reconciler.Status().Update(rm.Context, instance); err != nil {
+			return err
+		}
+	}
+	if controllerutil.ContainsFinalizer(restore, restoreFinalizer) {
+		controllerutil.RemoveFinalizer(restore, restoreFinalizer)
+		return rm.Reconciler.Update(rm.Context, restore)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -18,16 +19,21 @@ import (
 // StoreReconciler reconciles a Store object
 type StoreReconciler struct {
 	client.Client
-	Log      logr.Logger
-	Scheme   *runtime.Scheme
-	Storages map[string]backend.Storage
+	Log        logr.Logger
+	Scheme     *runtime.Scheme
+	Properties StatefulSetProperties
+	Storages   map[string]backend.Storage
+	PodLogs    PodLogs
 }
 
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=stores,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=stores/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=stores/finalizers,verbs=update
+// +kubebuilder:rbac:groups=mysql.blaqkube.io,resources=backups,verbs=get;list;watch;create
 
 // Reconcile implement the reconciliation loop for stores
 func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -67,7 +73,11 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		if store.Spec.Backend != "" {
 			storage = string(store.Spec.Backend)
 		}
-		if storage == "s3" || storage == "blackhole" || storage == "gcp" {
+		if storage == string(mysqlv1alpha1.BackendFilesystem) {
+			store.Status.CheckRequested = false
+			return sm.CheckFilesystem(&store)
+		}
+		if storage == "s3" || storage == "blackhole" || storage == "gcp" || storage == "azure" {
 			store.Status.CheckRequested = false
 			envs, err := sm.GetEnvVars(store)
 			if err != nil {
@@ -80,6 +90,20 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 				}
 				return sm.setStoreCondition(&store, condition)
 			}
+			em := &EnvManager{
+				Client: r.Client,
+				Log:    r.Log,
+			}
+			if _, err := em.GetEncryption(ctx, store); err != nil {
+				condition := metav1.Condition{
+					Type:               "available",
+					Status:             metav1.ConditionFalse,
+					LastTransitionTime: metav1.Now(),
+					Reason:             mysqlv1alpha1.StoreCheckFailed,
+					Message:            fmt.Sprintf("Cannot use the encryption keys, error: %v", err),
+				}
+				return sm.setStoreCondition(&store, condition)
+			}
 			e := []openapi.EnvVar{}
 			for k := range envs {
 				e = append(e, openapi.EnvVar{Name: k, Value: envs[k]})
@@ -99,6 +123,7 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 				Bucket:   store.Spec.Bucket,
 				Location: "/blaqkube/.mysql-operator.out",
 				Envs:     e,
+				S3:       storageS3Options(&store),
 			}
 			log.Info("Checking access for bucket", "bucket", request.Bucket)
 			err = r.Storages[storage].Push(request, *filename)
@@ -125,6 +150,10 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 			return sm.setStoreCondition(&store, condition)
 		}
 	}
+
+	if store.Status.Reason == mysqlv1alpha1.StoreCheckSucceeded && store.Spec.Discovery != nil {
+		return sm.DiscoverBackups(&store)
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -132,5 +161,6 @@ func (r *StoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 func (r *StoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&mysqlv1alpha1.Store{}).
+		Owns(&corev1.Pod{}).
 		Complete(r)
 }
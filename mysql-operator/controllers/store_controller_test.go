@@ -3,6 +3,8 @@ package controllers
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -10,15 +12,18 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/blaqkube/mysql-operator/agent/backend"
+	bhstorage "github.com/blaqkube/mysql-operator/agent/backend/blackhole"
 	openapi "github.com/blaqkube/mysql-operator/agent/go"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
 	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
@@ -27,6 +32,7 @@ const (
 	storeMockStatusSucceed  = "succeed"
 	storeMockStatusWithKeys = "keys"
 	storeMockStatusFailS3   = "fail/s3"
+	storeMockStatusFailDel  = "fail/delete"
 )
 
 // NewStorage takes a S3 connection and creates a default storage
@@ -39,7 +45,9 @@ func NewStorage(status string) *Storage {
 // Storage is the default storage for S3
 type Storage struct {
 	mock.Mock
-	Status string
+	Status  string
+	Deleted []string
+	Objects []string
 }
 
 // Push pushes a file
@@ -69,11 +77,43 @@ func (s *Storage) Pull(backup *openapi.BackupRequest, filename string) error {
 	return nil
 }
 
+// PullStream pulls an object to a stream
+func (s *Storage) PullStream(backup *openapi.BackupRequest, w io.Writer) error {
+	return nil
+}
+
+// PushStream pushes a stream
+func (s *Storage) PushStream(backup *openapi.BackupRequest, r io.Reader) error {
+	return s.Push(backup, "")
+}
+
 // Delete deletes a file from S3
 func (s *Storage) Delete(backup *openapi.BackupRequest) error {
+	if s.Status == storeMockStatusFailDel {
+		return errors.New("AccessDenied")
+	}
+	s.Deleted = append(s.Deleted, backup.Location)
 	return nil
 }
 
+// List lists the objects of the storage
+func (s *Storage) List(backup *openapi.BackupRequest, prefix string) ([]string, error) {
+	if s.Status == storeMockStatusFailS3 {
+		return nil, errors.New("AccessDenied")
+	}
+	return s.Objects, nil
+}
+
+// StaticPodLogs returns the same logs for all the pods
+type StaticPodLogs struct {
+	Output string
+}
+
+// Get returns the logs
+func (l *StaticPodLogs) Get(ctx context.Context, namespace, name, container string) ([]byte, error) {
+	return []byte(l.Output), nil
+}
+
 var _ = Describe("Store Controller", func() {
 	It("Create a new store and check success/failure", func() {
 		ctx := context.Background()
@@ -357,4 +397,291 @@ var _ = Describe("Store Controller", func() {
 		Expect(reconcile.Reconcile(context.TODO(), ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{Requeue: false}))
 	})
 
+	It("Discover the backups of a store", func() {
+		ctx := context.Background()
+
+		interval := int32(600)
+		store := mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "discovery",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Bucket:    "pong",
+				Prefix:    "/backups",
+				Discovery: &mysqlv1alpha1.DiscoverySpec{Interval: &interval},
+			},
+		}
+		storage := NewStorage(storeMockStatusSucceed)
+		storage.Objects = []string{
+			"/backups/blue-20210301-000000.sql.zst",
+			"/backups/blue-20210301-000000.sql.zst.manifest.json",
+			"/backups/blue-binlog/mysql-bin.000001",
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &StoreReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+			Storages: map[string]backend.Storage{
+				"s3": storage,
+			},
+		}
+
+		Expect(k8sClient.Create(ctx, &store)).To(Succeed())
+
+		name := types.NamespacedName{Namespace: store.Namespace, Name: store.Name}
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{}))
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{}))
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{RequeueAfter: 600 * time.Second}))
+		response := mysqlv1alpha1.Store{}
+		Expect(k8sClient.Get(ctx, name, &response)).To(Succeed())
+		Expect(response.Status.Discovery).ToNot(BeNil())
+		Expect(response.Status.Discovery.Discovered).To(Equal(int32(1)))
+
+		backup := mysqlv1alpha1.Backup{}
+		backupName := types.NamespacedName{Namespace: store.Namespace, Name: "discovery-blue-20210301-000000.sql.zst"}
+		Expect(k8sClient.Get(ctx, backupName, &backup)).To(Succeed())
+		Expect(backup.Spec.Instance).To(Equal("blue"))
+		Expect(backup.Spec.Location).To(Equal("/backups/blue-20210301-000000.sql.zst"))
+		Expect(backup.Spec.DeletionPolicy).To(Equal(mysqlv1alpha1.DeletionPolicyRetain))
+	})
+
+	It("Discover the backups of a blackhole store", func() {
+		ctx := context.Background()
+
+		store := mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "discovery-blackhole",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend:   mysqlv1alpha1.BackendBlackhole,
+				Bucket:    "pong",
+				Prefix:    "/backups",
+				Discovery: &mysqlv1alpha1.DiscoverySpec{},
+			},
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &StoreReconciler{
+			Client: k8sClient,
+			Log:    zapr.NewLogger(zapLog),
+			Scheme: scheme.Scheme,
+			Storages: map[string]backend.Storage{
+				"blackhole": bhstorage.NewStorage(),
+			},
+		}
+
+		Expect(k8sClient.Create(ctx, &store)).To(Succeed())
+
+		name := types.NamespacedName{Namespace: store.Namespace, Name: store.Name}
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{}))
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{}))
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{RequeueAfter: time.Hour}))
+		response := mysqlv1alpha1.Store{}
+		Expect(k8sClient.Get(ctx, name, &response)).To(Succeed())
+		Expect(response.Status.Discovery).ToNot(BeNil())
+		Expect(response.Status.Discovery.Discovered).To(Equal(int32(1)))
+
+		backup := mysqlv1alpha1.Backup{}
+		backupName := types.NamespacedName{Namespace: store.Namespace, Name: "discovery-blackhole-blue.sql"}
+		Expect(k8sClient.Get(ctx, backupName, &backup)).To(Succeed())
+		Expect(backup.Spec.Location).To(Equal("/backups/blue.sql"))
+		Expect(backup.Spec.Method).To(Equal(mysqlv1alpha1.BackupMethodMysqldump))
+	})
+
+	It("Infer the details of a discovered backup", func() {
+		details, instance, ok := locationDetails("/backups/blue-20210301-000000.xbstream.gz")
+		Expect(ok).To(BeTrue())
+		Expect(instance).To(Equal("blue"))
+		Expect(details.Method).To(Equal(mysqlv1alpha1.BackupMethodXtrabackup))
+		Expect(details.Compression).To(Equal("gzip"))
+		Expect(details.StartTime.UTC()).To(Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)))
+
+		details, instance, ok = locationDetails("/backups/export.sql")
+		Expect(ok).To(BeTrue())
+		Expect(instance).To(Equal(""))
+		Expect(details.Method).To(Equal(mysqlv1alpha1.BackupMethodMysqldump))
+		Expect(details.Compression).To(Equal("none"))
+		Expect(details.StartTime).To(BeNil())
+
+		_, _, ok = locationDetails("/backups/blue-20210301-000000.sql.manifest.json")
+		Expect(ok).To(BeFalse())
+		_, _, ok = locationDetails("/backups/blue-binlog/mysql-bin.000001")
+		Expect(ok).To(BeFalse())
+
+		Expect(discoveredBackupName("docs", "/backups", "/backups/Daily/blue_1.sql")).To(Equal("docs-daily-blue-1.sql"))
+		Expect(discoveryInterval(nil)).To(Equal(time.Hour))
+	})
+
+	It("Check a filesystem store with a pod", func() {
+		ctx := context.Background()
+
+		store := mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "store-",
+				Namespace:    "default",
+			},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend:    mysqlv1alpha1.BackendFilesystem,
+				Bucket:     "backups",
+				Filesystem: &mysqlv1alpha1.FilesystemSpec{ClaimName: "nfs"},
+			},
+		}
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &StoreReconciler{
+			Client:     k8sClient,
+			Log:        zapr.NewLogger(zapLog),
+			Scheme:     scheme.Scheme,
+			Properties: StatefulSetProperties{AgentVersion: "latest"},
+		}
+
+		Expect(k8sClient.Create(ctx, &store)).To(Succeed())
+
+		name := types.NamespacedName{Namespace: store.Namespace, Name: store.Name}
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{}))
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{RequeueAfter: storeCheckPollingInterval}))
+
+		pod := corev1.Pod{}
+		podName := types.NamespacedName{Namespace: store.Namespace, Name: store.Name + "-check"}
+		Expect(k8sClient.Get(ctx, podName, &pod)).To(Succeed())
+		Expect(pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("nfs"))
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{RequeueAfter: storeCheckPollingInterval}))
+
+		pod.Status.Phase = corev1.PodSucceeded
+		Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{}))
+		response := mysqlv1alpha1.Store{}
+		Expect(k8sClient.Get(ctx, name, &response)).To(Succeed())
+		Expect(response.Status.Reason).To(Equal(mysqlv1alpha1.StoreCheckSucceeded))
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, podName, &pod))).To(BeTrue())
+	})
+
+	It("Discover the backups of a filesystem store with a pod", func() {
+		ctx := context.Background()
+
+		store := mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "discovery-nfs",
+				Namespace: "default",
+			},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend:    mysqlv1alpha1.BackendFilesystem,
+				Bucket:     "backups",
+				Prefix:     "/daily",
+				Filesystem: &mysqlv1alpha1.FilesystemSpec{ClaimName: "nfs"},
+				Discovery:  &mysqlv1alpha1.DiscoverySpec{},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &store)).To(Succeed())
+		store.Status.Reason = mysqlv1alpha1.StoreCheckSucceeded
+		Expect(k8sClient.Status().Update(ctx, &store)).To(Succeed())
+
+		zapLog, _ := zap.NewDevelopment()
+		reconcile := &StoreReconciler{
+			Client:     k8sClient,
+			Log:        zapr.NewLogger(zapLog),
+			Scheme:     scheme.Scheme,
+			Properties: StatefulSetProperties{AgentVersion: "latest"},
+			PodLogs: &StaticPodLogs{
+				Output: "/daily/blue-20210301-000000.sql\n/daily/blue-20210301-000000.sql.manifest.json\n",
+			},
+		}
+
+		name := types.NamespacedName{Namespace: store.Namespace, Name: store.Name}
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{RequeueAfter: storeCheckPollingInterval}))
+
+		pod := corev1.Pod{}
+		podName := types.NamespacedName{Namespace: store.Namespace, Name: store.Name + "-list"}
+		Expect(k8sClient.Get(ctx, podName, &pod)).To(Succeed())
+		Expect(pod.Spec.Containers[0].Command).To(Equal([]string{"./mysql-agent", "list", "--prefix", "/daily"}))
+		pod.Status.Phase = corev1.PodSucceeded
+		Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+		Expect(reconcile.Reconcile(ctx, ctrl.Request{NamespacedName: name})).To(Equal(ctrl.Result{RequeueAfter: time.Hour}))
+		response := mysqlv1alpha1.Store{}
+		Expect(k8sClient.Get(ctx, name, &response)).To(Succeed())
+		Expect(response.Status.Discovery).ToNot(BeNil())
+		Expect(response.Status.Discovery.Discovered).To(Equal(int32(1)))
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, podName, &pod))).To(BeTrue())
+
+		backup := mysqlv1alpha1.Backup{}
+		backupName := types.NamespacedName{Namespace: store.Namespace, Name: "discovery-nfs-blue-20210301-000000.sql"}
+		Expect(k8sClient.Get(ctx, backupName, &backup)).To(Succeed())
+		Expect(backup.Spec.Location).To(Equal("/daily/blue-20210301-000000.sql"))
+	})
+
+	It("Mount the claim of a filesystem store", func() {
+		store := &mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{Name: "nfs", Namespace: "default"},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend:    mysqlv1alpha1.BackendFilesystem,
+				Bucket:     "backups",
+				Filesystem: &mysqlv1alpha1.FilesystemSpec{ClaimName: "nfs-backups"},
+			},
+		}
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{Name: "blue", Namespace: "default"},
+		}
+		properties := &StatefulSetProperties{AgentVersion: "latest", MySQLVersion: "8.0.23"}
+		sts := properties.NewStatefulSetForInstance(instance, store, "/blue-20210301-000000.sql")
+		mountFilesystemStore(sts, store)
+
+		volumes := 0
+		for _, v := range sts.Spec.Template.Spec.Volumes {
+			if v.Name == "store-nfs" {
+				volumes++
+				Expect(v.PersistentVolumeClaim.ClaimName).To(Equal("nfs-backups"))
+			}
+		}
+		Expect(volumes).To(Equal(1))
+		mount := corev1.VolumeMount{Name: "store-nfs", MountPath: "/var/lib/blaqkube/stores/backups"}
+		Expect(sts.Spec.Template.Spec.InitContainers[0].VolumeMounts).To(ContainElement(mount))
+		Expect(sts.Spec.Template.Spec.Containers[1].Name).To(Equal("agent"))
+		Expect(sts.Spec.Template.Spec.Containers[1].VolumeMounts).To(ContainElement(mount))
+	})
+
+	It("Connect to a S3 compatible store", func() {
+		store := &mysqlv1alpha1.Store{
+			ObjectMeta: metav1.ObjectMeta{Name: "minio", Namespace: "default"},
+			Spec: mysqlv1alpha1.StoreSpec{
+				Backend: mysqlv1alpha1.BackendS3,
+				Bucket:  "backups",
+				Envs:    []corev1.EnvVar{{Name: "AWS_ACCESS_KEY_ID", Value: "minioadmin"}},
+				S3: &mysqlv1alpha1.S3Spec{
+					Endpoint:       "http://minio.minio.svc:9000",
+					ForcePathStyle: true,
+				},
+			},
+		}
+		Expect(s3Options(store)).To(Equal(&agent.S3Options{
+			Endpoint:       "http://minio.minio.svc:9000",
+			ForcePathStyle: true,
+		}))
+		Expect(storageS3Options(store)).To(Equal(&openapi.S3Options{
+			Endpoint:       "http://minio.minio.svc:9000",
+			ForcePathStyle: true,
+		}))
+
+		instance := &mysqlv1alpha1.Instance{
+			ObjectMeta: metav1.ObjectMeta{Name: "blue", Namespace: "default"},
+		}
+		properties := &StatefulSetProperties{AgentVersion: "latest", MySQLVersion: "8.0.23"}
+		sts := properties.NewStatefulSetForInstance(instance, store, "/blue-20210301-000000.sql")
+		env := sts.Spec.Template.Spec.InitContainers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "AGT_S3_ENDPOINT", Value: "http://minio.minio.svc:9000"}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "AGT_S3_FORCE_PATH_STYLE", Value: "true"}))
+		for _, v := range env {
+			Expect(v.Name).NotTo(Equal("AGT_S3_REGION"))
+		}
+
+		store.Spec.S3 = nil
+		Expect(s3Options(store)).To(BeNil())
+		Expect(storageS3Options(store)).To(BeNil())
+		Expect(s3EnvVars(store)).To(BeEmpty())
+	})
+
 })
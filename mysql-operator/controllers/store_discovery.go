@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	defaultDiscoveryInterval = 3600 * time.Second
+	maxBackupNameLength      = 253
+)
+
+// discoveryInterval returns the time between two scans of a store
+func discoveryInterval(spec *mysqlv1alpha1.DiscoverySpec) time.Duration {
+	if spec == nil || spec.Interval == nil {
+		return defaultDiscoveryInterval
+	}
+	return time.Duration(*spec.Interval) * time.Second
+}
+
+// locationDetails infers the details of a backup and the instance it has
+// been taken from with the name of its location. It returns false when the
+// location is not a backup, e.g. a manifest or an archived binary log
+func locationDetails(location string) (*mysqlv1alpha1.BackupDetails, string, bool) {
+	name := path.Base(location)
+	details := &mysqlv1alpha1.BackupDetails{
+		Location:    location,
+		Compression: "none",
+	}
+	for _, compression := range []string{"gzip", "zstd"} {
+		if ext := compressionExtension(compression); strings.HasSuffix(name, ext) {
+			details.Compression = compression
+			name = strings.TrimSuffix(name, ext)
+		}
+	}
+	ext := path.Ext(name)
+	switch ext {
+	case ".sql":
+		details.Method = mysqlv1alpha1.BackupMethodMysqldump
+	case ".xbstream":
+		details.Method = mysqlv1alpha1.BackupMethodXtrabackup
+	case ".tar":
+		details.Method = mysqlv1alpha1.BackupMethodClone
+	default:
+		return nil, "", false
+	}
+	name = strings.TrimSuffix(name, ext)
+	layout := "20060102-150405"
+	if len(name) <= len(layout)+1 || name[len(name)-len(layout)-1] != '-' {
+		return details, "", true
+	}
+	t, err := time.Parse(layout, name[len(name)-len(layout):])
+	if err != nil {
+		return details, "", true
+	}
+	details.StartTime = &metav1.Time{Time: t}
+	return details, name[:len(name)-len(layout)-1], true
+}
+
+// discoveredBackupName returns the name of the backup that references a
+// location of a store
+func discoveredBackupName(store, prefix, location string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(strings.TrimPrefix(location, prefix)))
+	name = fmt.Sprintf("%s-%s", store, strings.Trim(name, "-."))
+	if len(name) > maxBackupNameLength {
+		name = strings.TrimRight(name[:maxBackupNameLength], "-.")
+	}
+	return name
+}
+
+// DiscoverBackups scans the prefix of a store once per interval and updates
+// the discovery status
+func (sm *StoreManager) DiscoverBackups(store *mysqlv1alpha1.Store) (ctrl.Result, error) {
+	interval := discoveryInterval(store.Spec.Discovery)
+	if store.Status.Discovery != nil && store.Status.Discovery.LastScanTime != nil {
+		next := store.Status.Discovery.LastScanTime.Add(interval)
+		if now := time.Now(); now.Before(next) {
+			return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+	log := sm.Reconciler.Log.WithValues("namespace", store.Namespace, "store", store.Name)
+	var locations []string
+	var err error
+	if store.Spec.Backend == mysqlv1alpha1.BackendFilesystem {
+		var done bool
+		locations, done, err = sm.listFilesystemStore(store)
+		if err == nil && !done {
+			return ctrl.Result{RequeueAfter: storeCheckPollingInterval}, nil
+		}
+	} else {
+		locations, err = sm.listStore(store)
+	}
+	status := &mysqlv1alpha1.DiscoveryStatus{
+		LastScanTime: &metav1.Time{Time: time.Now()},
+	}
+	count := 0
+	if err == nil {
+		count, err = sm.importLocations(store, locations)
+	}
+	status.Discovered = int32(count)
+	status.Message = fmt.Sprintf("%d backup(s) discovered", count)
+	if err != nil {
+		log.Info("Cannot scan the store", "error", err)
+		status.Message = fmt.Sprintf("Cannot scan the store, error: %v", err)
+	}
+	store.Status.Discovery = status
+	if err := sm.Reconciler.Status().Update(sm.Context, store); err != nil {
+		log.Error(err, "Unable to update store")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// listStore lists the objects of the store prefix with the storage of its
+// backend
+func (sm *StoreManager) listStore(store *mysqlv1alpha1.Store) ([]string, error) {
+	storage := "s3"
+	if store.Spec.Backend != "" {
+		storage = string(store.Spec.Backend)
+	}
+	s, ok := sm.Reconciler.Storages[storage]
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+	envs, err := sm.GetEnvVars(*store)
+	if err != nil {
+		return nil, err
+	}
+	e := []openapi.EnvVar{}
+	for k := range envs {
+		e = append(e, openapi.EnvVar{Name: k, Value: envs[k]})
+	}
+	return s.List(&openapi.BackupRequest{Bucket: store.Spec.Bucket, Envs: e, S3: storageS3Options(store)}, store.Spec.Prefix)
+}
+
+// importLocations creates a backup for the locations of the store that no
+// backup references. It returns the number of backups created
+func (sm *StoreManager) importLocations(store *mysqlv1alpha1.Store, locations []string) (int, error) {
+	list := &mysqlv1alpha1.BackupList{}
+	if err := sm.Reconciler.List(sm.Context, list, client.InNamespace(store.Namespace)); err != nil {
+		return 0, err
+	}
+	known := map[string]bool{}
+	for _, backup := range list.Items {
+		if backup.Spec.Store != store.Name {
+			continue
+		}
+		known[backup.Spec.Location] = true
+		if backup.Status.Details != nil {
+			known[backup.Status.Details.Location] = true
+		}
+	}
+
+	count := 0
+	for _, location := range locations {
+		details, instance, ok := locationDetails(location)
+		if !ok || known[location] {
+			continue
+		}
+		backup := &mysqlv1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      discoveredBackupName(store.Name, store.Spec.Prefix, location),
+				Namespace: store.Namespace,
+			},
+			Spec: mysqlv1alpha1.BackupSpec{
+				Store:          store.Name,
+				Instance:       instance,
+				Compression:    details.Compression,
+				Method:         details.Method,
+				DeletionPolicy: mysqlv1alpha1.DeletionPolicyRetain,
+				Location:       location,
+			},
+		}
+		if err := sm.Reconciler.Create(sm.Context, backup); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// ImportBackup sets the details of a backup that references an existing
+// object of its store
+func (bm *BackupManager) ImportBackup(backup *mysqlv1alpha1.Backup) (ctrl.Result, error) {
+	condition := metav1.Condition{
+		Type:               "available",
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+	}
+	a := &APIReconciler{
+		Client: bm.Reconciler.Client,
+		Log:    bm.Reconciler.Log,
+	}
+	store, err := a.GetStore(
+		bm.Context,
+		types.NamespacedName{
+			Name:      backup.Spec.Store,
+			Namespace: backup.Namespace,
+		},
+	)
+	if err != nil {
+		condition.Reason = mysqlv1alpha1.BackupStoreAccessError
+		condition.Message = "Backup store not found"
+		return bm.setBackupCondition(backup, condition, nil)
+	}
+	details, _, ok := locationDetails(backup.Spec.Location)
+	if !ok {
+		details = &mysqlv1alpha1.BackupDetails{Location: backup.Spec.Location}
+	}
+	details.Bucket = store.Spec.Bucket
+	if backup.Spec.Method != "" {
+		details.Method = backup.Spec.Method
+	}
+	if backup.Spec.Compression != "" {
+		details.Compression = backup.Spec.Compression
+	}
+	if details.StartTime == nil {
+		details.StartTime = backup.CreationTimestamp.DeepCopy()
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = mysqlv1alpha1.BackupSucceeded
+	condition.Message = fmt.Sprintf("Backup imported from %s", backup.Spec.Location)
+	return bm.setBackupCondition(backup, condition, details)
+}
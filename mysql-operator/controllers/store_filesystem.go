@@ -0,0 +1,312 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+const (
+	// filesystemStoresDir is the directory the claims of the filesystem
+	// stores are mounted in, the agent reads the bucket directories there
+	filesystemStoresDir = "/var/lib/blaqkube/stores"
+
+	storeCheckPollingInterval = 10 * time.Second
+
+	// storePodContainer is the container of the pods that run the agent on
+	// the claim of a filesystem store
+	storePodContainer = "agent"
+)
+
+// PodLogs reads the logs of the containers of the pods, the operator reads
+// the output of the store pods with it
+type PodLogs interface {
+	Get(ctx context.Context, namespace, name, container string) ([]byte, error)
+}
+
+// DefaultPodLogs reads the logs of the pods with the Kubernetes API
+type DefaultPodLogs struct {
+	Clientset kubernetes.Interface
+}
+
+// NewDefaultPodLogs creates a log reader from a REST configuration
+func NewDefaultPodLogs(config *rest.Config) (*DefaultPodLogs, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &DefaultPodLogs{Clientset: clientset}, nil
+}
+
+// Get returns the logs of a container
+func (l *DefaultPodLogs) Get(ctx context.Context, namespace, name, container string) ([]byte, error) {
+	return l.Clientset.CoreV1().Pods(namespace).GetLogs(name, &corev1.PodLogOptions{Container: container}).DoRaw(ctx)
+}
+
+// storeVolumeName returns the name of the volume of a filesystem store
+func storeVolumeName(store *mysqlv1alpha1.Store) string {
+	return "store-" + store.Name
+}
+
+// storeCheckPodName returns the name of the pod that checks a filesystem
+// store
+func storeCheckPodName(store *mysqlv1alpha1.Store) string {
+	return store.Name + "-check"
+}
+
+// mountFilesystemStore mounts the claim of a filesystem store in the bucket
+// directory of the agent and of the restore init container
+func mountFilesystemStore(sts *appsv1.StatefulSet, store *mysqlv1alpha1.Store) {
+	if store == nil || store.Spec.Backend != mysqlv1alpha1.BackendFilesystem || store.Spec.Filesystem == nil {
+		return
+	}
+	name := storeVolumeName(store)
+	for _, v := range sts.Spec.Template.Spec.Volumes {
+		if v.Name == name {
+			return
+		}
+	}
+	sts.Spec.Template.Spec.Volumes = append(sts.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: store.Spec.Filesystem.ClaimName,
+			},
+		},
+	})
+	mount := corev1.VolumeMount{
+		Name:      name,
+		MountPath: filesystemStoresDir + "/" + store.Spec.Bucket,
+	}
+	for i, c := range sts.Spec.Template.Spec.Containers {
+		if c.Name == "agent" {
+			sts.Spec.Template.Spec.Containers[i].VolumeMounts = append(c.VolumeMounts, mount)
+		}
+	}
+	for i, c := range sts.Spec.Template.Spec.InitContainers {
+		if c.Name == "restore" {
+			sts.Spec.Template.Spec.InitContainers[i].VolumeMounts = append(c.VolumeMounts, mount)
+		}
+	}
+}
+
+// mountFilesystemStores mounts the claims of the filesystem stores of the
+// instance namespace, so that any of them can be used by a backup or a
+// restore. The stores are sorted so that the statefulset does not change
+// when they are listed in another order
+func (im *InstanceManager) mountFilesystemStores(instance *mysqlv1alpha1.Instance, sts *appsv1.StatefulSet) {
+	stores := &mysqlv1alpha1.StoreList{}
+	if err := im.Reconciler.List(im.Context, stores, client.InNamespace(instance.Namespace)); err != nil {
+		return
+	}
+	sort.Slice(stores.Items, func(i, j int) bool { return stores.Items[i].Name < stores.Items[j].Name })
+	for i := range stores.Items {
+		mountFilesystemStore(sts, &stores.Items[i])
+	}
+}
+
+// newStorePod returns a pod that runs an agent command on the claim of a
+// filesystem store
+func newStorePod(store *mysqlv1alpha1.Store, name, agentVersion string, args ...string) *corev1.Pod {
+	env := append([]corev1.EnvVar{}, store.Spec.Envs...)
+	env = append(env,
+		corev1.EnvVar{
+			Name:  "AGT_TYPE",
+			Value: string(mysqlv1alpha1.BackendFilesystem),
+		},
+		corev1.EnvVar{
+			Name:  "AGT_BUCKET",
+			Value: store.Spec.Bucket,
+		},
+	)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: store.Namespace,
+			Labels: map[string]string{
+				"app": store.Name,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:                     storePodContainer,
+					Image:                    "quay.io/blaqkube/mysql-agent:" + agentVersion,
+					Env:                      env,
+					TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					Command:                  append([]string{"./mysql-agent"}, args...),
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      storeVolumeName(store),
+							MountPath: filesystemStoresDir + "/" + store.Spec.Bucket,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: storeVolumeName(store),
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: store.Spec.Filesystem.ClaimName,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// runStorePod creates a store pod owned by an object and returns it once it
+// has completed, it returns nil while the pod runs. The caller deletes the
+// completed pod after it has read its outcome
+func runStorePod(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner metav1.Object, desired *corev1.Pod) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, pod)
+	if errors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(owner, desired, scheme); err != nil {
+			return nil, err
+		}
+		return nil, c.Create(ctx, desired)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !pod.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+		return nil, nil
+	}
+	return pod, nil
+}
+
+// terminationMessage returns the message of the terminated containers of a
+// pod
+func terminationMessage(pod *corev1.Pod) string {
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.State.Terminated != nil && s.State.Terminated.Message != "" {
+			return s.State.Terminated.Message
+		}
+	}
+	return "no message"
+}
+
+// CheckFilesystem checks a filesystem store with a pod that mounts its claim,
+// the operator does not mount the claims of the stores. The pod is deleted
+// once it has completed
+func (sm *StoreManager) CheckFilesystem(store *mysqlv1alpha1.Store) (ctrl.Result, error) {
+	log := sm.Reconciler.Log.WithValues("namespace", store.Namespace, "store", store.Name)
+	condition := metav1.Condition{
+		Type:               "available",
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             mysqlv1alpha1.StoreCheckFailed,
+	}
+	if store.Spec.Filesystem == nil || store.Spec.Filesystem.ClaimName == "" {
+		condition.Message = "A filesystem store requires filesystem.claimName"
+		return sm.setStoreCondition(store, condition)
+	}
+
+	pod, err := runStorePod(sm.Context, sm.Reconciler.Client, sm.Reconciler.Scheme, store, newStorePod(store, storeCheckPodName(store), sm.Reconciler.Properties.AgentVersion, "check"))
+	if err != nil {
+		log.Error(err, "Unable to run the check pod")
+		condition.Message = fmt.Sprintf("Cannot run the check pod, error: %v", err)
+		return sm.setStoreCondition(store, condition)
+	}
+	if pod == nil {
+		return ctrl.Result{RequeueAfter: storeCheckPollingInterval}, nil
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = mysqlv1alpha1.StoreCheckSucceeded
+		condition.Message = "The check has succeeded"
+	case corev1.PodFailed:
+		condition.Message = fmt.Sprintf("Cannot write to claim %s, error: %s", store.Spec.Filesystem.ClaimName, terminationMessage(pod))
+	}
+	if err := sm.Reconciler.Delete(sm.Context, pod); client.IgnoreNotFound(err) != nil {
+		log.Error(err, "Unable to delete the check pod")
+		return ctrl.Result{}, err
+	}
+	return sm.setStoreCondition(store, condition)
+}
+
+// listFilesystemStore lists the objects of the store prefix with a pod that
+// mounts its claim and reads the locations from the pod logs. It returns
+// false while the pod runs
+func (sm *StoreManager) listFilesystemStore(store *mysqlv1alpha1.Store) ([]string, bool, error) {
+	if store.Spec.Filesystem == nil || store.Spec.Filesystem.ClaimName == "" {
+		return nil, false, fmt.Errorf("a filesystem store requires filesystem.claimName")
+	}
+	if sm.Reconciler.PodLogs == nil {
+		return nil, false, ErrNotImplemented
+	}
+	desired := newStorePod(store, store.Name+"-list", sm.Reconciler.Properties.AgentVersion, "list", "--prefix", store.Spec.Prefix)
+	pod, err := runStorePod(sm.Context, sm.Reconciler.Client, sm.Reconciler.Scheme, store, desired)
+	if err != nil || pod == nil {
+		return nil, false, err
+	}
+	defer func() {
+		if err := sm.Reconciler.Delete(sm.Context, pod); client.IgnoreNotFound(err) != nil {
+			sm.Reconciler.Log.Error(err, "Unable to delete the list pod", "namespace", pod.Namespace, "pod", pod.Name)
+		}
+	}()
+	if pod.Status.Phase == corev1.PodFailed {
+		return nil, true, fmt.Errorf("%s", terminationMessage(pod))
+	}
+	output, err := sm.Reconciler.PodLogs.Get(sm.Context, pod.Namespace, pod.Name, storePodContainer)
+	if err != nil {
+		return nil, true, err
+	}
+	locations := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			locations = append(locations, line)
+		}
+	}
+	return locations, true, nil
+}
+
+// deleteFilesystemObject deletes the object of a backup and its manifest with
+// a pod that mounts the claim of the store. It returns false while the pod
+// runs
+func (bm *BackupManager) deleteFilesystemObject(store *mysqlv1alpha1.Store, backup *mysqlv1alpha1.Backup) (bool, error) {
+	if store.Spec.Filesystem == nil || store.Spec.Filesystem.ClaimName == "" {
+		return false, fmt.Errorf("a filesystem store requires filesystem.claimName")
+	}
+	args := []string{"delete", backup.Status.Details.Location}
+	if backup.Status.Details.Manifest != nil {
+		args = append(args, manifestLocation(backup.Status.Details.Location))
+	}
+	desired := newStorePod(store, backup.Name+"-delete", bm.Reconciler.Properties.AgentVersion, args...)
+	pod, err := runStorePod(bm.Context, bm.Reconciler.Client, bm.Reconciler.Scheme, backup, desired)
+	if err != nil || pod == nil {
+		return false, err
+	}
+	if err := bm.Reconciler.Delete(bm.Context, pod); client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	if pod.Status.Phase == corev1.PodFailed {
+		return false, fmt.Errorf("%s", terminationMessage(pod))
+	}
+	return true, nil
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"strconv"
+
+	openapi "github.com/blaqkube/mysql-operator/agent/go"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/blaqkube/mysql-operator/mysql-operator/agent"
+	mysqlv1alpha1 "github.com/blaqkube/mysql-operator/mysql-operator/api/v1alpha1"
+)
+
+// s3Options returns the S3 options of the agent requests for a store
+func s3Options(store *mysqlv1alpha1.Store) *agent.S3Options {
+	if store.Spec.S3 == nil {
+		return nil
+	}
+	return &agent.S3Options{
+		Endpoint:       store.Spec.S3.Endpoint,
+		Region:         store.Spec.S3.Region,
+		ForcePathStyle: store.Spec.S3.ForcePathStyle,
+		CaBundle:       store.Spec.S3.CABundle,
+	}
+}
+
+// storageS3Options returns the S3 options of the requests the operator sends
+// to its own storages, e.g. to check a store or delete a backup
+func storageS3Options(store *mysqlv1alpha1.Store) *openapi.S3Options {
+	if store.Spec.S3 == nil {
+		return nil
+	}
+	return &openapi.S3Options{
+		Endpoint:       store.Spec.S3.Endpoint,
+		Region:         store.Spec.S3.Region,
+		ForcePathStyle: store.Spec.S3.ForcePathStyle,
+		CaBundle:       store.Spec.S3.CABundle,
+	}
+}
+
+// s3EnvVars returns the variables of the restore init container that
+// connect it to a S3 compatible store
+func s3EnvVars(store *mysqlv1alpha1.Store) []corev1.EnvVar {
+	if store.Spec.S3 == nil {
+		return nil
+	}
+	env := []corev1.EnvVar{}
+	for _, v := range []corev1.EnvVar{
+		{Name: "AGT_S3_ENDPOINT", Value: store.Spec.S3.Endpoint},
+		{Name: "AGT_S3_REGION", Value: store.Spec.S3.Region},
+		{Name: "AGT_S3_CA_BUNDLE", Value: store.Spec.S3.CABundle},
+	} {
+		if v.Value != "" {
+			env = append(env, v)
+		}
+	}
+	if store.Spec.S3.ForcePathStyle {
+		env = append(env, corev1.EnvVar{Name: "AGT_S3_FORCE_PATH_STYLE", Value: strconv.FormatBool(true)})
+	}
+	return env
+}
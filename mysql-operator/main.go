@@ -9,6 +9,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"github.com/blaqkube/mysql-operator/agent/backend"
+	azurestorage "github.com/blaqkube/mysql-operator/agent/backend/azure"
 	bhstorage "github.com/blaqkube/mysql-operator/agent/backend/blackhole"
 	gcpstorage "github.com/blaqkube/mysql-operator/agent/backend/gcp"
 	s3storage "github.com/blaqkube/mysql-operator/agent/backend/s3"
@@ -35,7 +36,7 @@ const (
 	DefaultAgentVersion = "944749610bd63779"
 
 	// DefaultMySQLVersion is the current defaut MySQL version
-	DefaultMySQLVersion = "8.0.22"
+	DefaultMySQLVersion = "8.0.23"
 )
 
 func init() {
@@ -91,19 +92,35 @@ func main() {
 			AgentVersion: agentVersion(),
 			MySQLVersion: DefaultMySQLVersion,
 		},
+		Storages: map[string]backend.Storage{
+			"azure":     azurestorage.NewStorage(),
+			"blackhole": bhstorage.NewStorage(),
+			"gcp":       gcpstorage.NewStorage(),
+			"s3":        s3storage.NewStorage(),
+		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Backup")
 		os.Exit(1)
 	}
+	podLogs, err := controllers.NewDefaultPodLogs(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create the pod log reader")
+		os.Exit(1)
+	}
 	if err = (&controllers.StoreReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("Store"),
 		Scheme: mgr.GetScheme(),
+		Properties: controllers.StatefulSetProperties{
+			AgentVersion: agentVersion(),
+		},
 		Storages: map[string]backend.Storage{
+			"azure":     azurestorage.NewStorage(),
 			"blackhole": bhstorage.NewStorage(),
 			"gcp":       gcpstorage.NewStorage(),
 			"s3":        s3storage.NewStorage(),
 		},
+		PodLogs: podLogs,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Store")
 		os.Exit(1)
@@ -116,7 +133,8 @@ func main() {
 			AgentVersion: DefaultAgentVersion,
 			MySQLVersion: DefaultMySQLVersion,
 		},
-		Crontab: controllers.NewDefaultCrontab(),
+		Crontab:   controllers.NewDefaultCrontab(),
+		Connector: controllers.NewDefaultReplicationConnector(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Instance")
 		os.Exit(1)
@@ -156,11 +174,20 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&controllers.OperationReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("Operation"),
+		Scheme:    mgr.GetScheme(),
+		Connector: controllers.NewDefaultReplicationConnector(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Operation")
+		os.Exit(1)
+	}
+	if err = (&controllers.RestoreReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Operation"),
+		Log:    ctrl.Log.WithName("controllers").WithName("Restore"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Operation")
+		setupLog.Error(err, "unable to create controller", "controller", "Restore")
 		os.Exit(1)
 	}
 	// +kubebuilder:scaffold:builder